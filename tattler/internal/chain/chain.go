@@ -0,0 +1,222 @@
+/*
+Package chain keeps a tamper-evident hash chain over the Batches sent to processors, so that a record of them
+can be shown to not have been altered or truncated after it was emitted.
+
+Chainer adds a data.ChainLink entry to every Batches. The link has the Batches' sequence number in the chain
+and a SHA-256 hash over the link's fields, the previous link's hash and the canonical encoding of the
+Batches' other entries. Changing, adding or removing an entry changes the hash, and removing or reordering a
+Batches breaks the sequence and the previous hashes of the links after it.
+
+A hash chain alone does not stop someone rewriting a record and rehashing it, so on an interval a link is
+made a checkpoint by signing its hash with an ed25519 key. A valid checkpoint shows the chain up to it was
+made by the key's holder.
+
+The canonical encoding of an entry is wire.Marshal() with wire.FProto. The hash is over the encoded entries
+in byte order, so it does not depend on the order they are recorded in. Entries that cannot be encoded, such
+as registered types without an Encoder, cannot be recorded and are not covered.
+
+Verifier checks a recorded stream of entries, such as the output of the filesink processor or Batches
+received from the grpcstream processor, and reports gaps and modifications. The record must hold every entry
+of the Batches, so a grpcstream subscription with a filter cannot be verified.
+
+Chainer sits between the batcher and the router, and is added to the pipeline with tattler.WithHashChain().
+*/
+package chain
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+)
+
+// domain starts everything that is hashed, so hashes from this package cannot be mistaken for others.
+const domain = "tattler.chain.v1"
+
+// Chainer adds a data.ChainLink to each Batches it receives.
+type Chainer struct {
+	in  <-chan batching.Batches
+	out chan batching.Batches
+
+	key      ed25519.PrivateKey
+	interval time.Duration
+
+	chain          string
+	seq            uint64
+	prev           []byte
+	lastCheckpoint time.Time
+
+	now func() time.Time
+	log *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Chainer) error
+
+// WithLogger sets the logger. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Chainer) error {
+		if l == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		c.log = l
+		return nil
+	}
+}
+
+// New creates a new Chainer that reads Batches from in, adds a link to each and sends them on out. The
+// first link and the first link after each interval are checkpoints signed with key. out is closed when in
+// is closed.
+func New(ctx context.Context, in <-chan batching.Batches, out chan batching.Batches, key ed25519.PrivateKey, interval time.Duration, options ...Option) (*Chainer, error) {
+	switch {
+	case in == nil || out == nil:
+		return nil, fmt.Errorf("chain.New: in and out cannot be nil")
+	case len(key) != ed25519.PrivateKeySize:
+		return nil, fmt.Errorf("chain.New: key must be an ed25519 private key")
+	case interval <= 0:
+		return nil, fmt.Errorf("chain.New: checkpoint interval must be > 0")
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("chain.New: %w", err)
+	}
+
+	c := &Chainer{
+		in:       in,
+		out:      out,
+		key:      key,
+		interval: interval,
+		chain:    hex.EncodeToString(id),
+		now:      time.Now,
+		log:      slog.Default(),
+	}
+	for _, o := range options {
+		if err := o(c); err != nil {
+			return nil, err
+		}
+	}
+
+	go c.run()
+
+	return c, nil
+}
+
+// Chain returns the identifier of the chain the Chainer is making.
+func (c *Chainer) Chain() string {
+	return c.chain
+}
+
+func (c *Chainer) run() {
+	defer close(c.out)
+
+	for batches := range c.in {
+		c.link(batches)
+		c.out <- batches
+	}
+}
+
+// link adds the next link to batches.
+func (c *Chainer) link(batches batching.Batches) {
+	c.seq++
+	now := c.now()
+	link := data.NewChainLink(c.chain, c.seq, now)
+	link.Prev = c.prev
+
+	entries, err := encode(batches)
+	if err != nil {
+		c.log.Error(fmt.Sprintf("chain: batch %d: %s", c.seq, err))
+	}
+	link.Entries = len(entries)
+	link.Hash = Hash(link, entries)
+	if c.seq == 1 || now.Sub(c.lastCheckpoint) >= c.interval {
+		link.Signature = ed25519.Sign(c.key, signed(link.Hash))
+		c.lastCheckpoint = now
+	}
+	c.prev = link.Hash
+
+	e := data.MustNewEntry(link)
+	e.Observed = now
+	e.Seq = c.seq
+	batches[data.ETChainLink] = batching.Batch{e.UID(): e}
+}
+
+// encode returns the canonical encoding of the entries in batches, except any links. Entries that cannot be
+// encoded are left out, and the error says how many there were.
+func encode(batches batching.Batches) ([][]byte, error) {
+	var (
+		out    [][]byte
+		failed int
+		first  error
+	)
+	for et, batch := range batches {
+		if et == data.ETChainLink {
+			continue
+		}
+		for _, e := range batch {
+			b, err := wire.Marshal(wire.FProto, e)
+			if err != nil {
+				failed++
+				if first == nil {
+					first = fmt.Errorf("could not encode %v %s: %w", e.Type, e.UID(), err)
+				}
+				continue
+			}
+			out = append(out, b)
+		}
+	}
+	if failed > 0 {
+		return out, fmt.Errorf("%d entries are not covered by the hash, first: %w", failed, first)
+	}
+	return out, nil
+}
+
+// Hash returns the hash of link over the canonical encodings of the entries it covers. The fields of link
+// other than Hash and Signature are covered. entries is sorted.
+func Hash(link *data.ChainLink, entries [][]byte) []byte {
+	slices.SortFunc(entries, func(a, b []byte) int { return slices.Compare(a, b) })
+
+	h := sha256.New()
+	field := func(b []byte) {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(b))))
+		h.Write(b)
+	}
+	num := func(n uint64) {
+		h.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+
+	field([]byte(domain))
+	field([]byte(link.Chain))
+	num(link.Seq)
+	num(uint64(link.Time.UnixNano()))
+	num(uint64(link.Entries))
+	field(link.Prev)
+	num(uint64(len(entries)))
+	for _, e := range entries {
+		field(e)
+	}
+	return h.Sum(nil)
+}
+
+// signed returns the message a checkpoint signs for a link's hash.
+func signed(hash []byte) []byte {
+	return append([]byte(domain+" checkpoint\x00"), hash...)
+}
+
+// VerifyCheckpoint reports if link is a checkpoint with a valid signature by key.
+func VerifyCheckpoint(key ed25519.PublicKey, link *data.ChainLink) bool {
+	if len(key) != ed25519.PublicKeySize || len(link.Signature) == 0 {
+		return false
+	}
+	return ed25519.Verify(key, signed(link.Hash), link.Signature)
+}
@@ -0,0 +1,381 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testBatches returns n Batches of size entries each, with sequence numbers following on from each other.
+// The entries cycle through pods, events and secrets with the timestamps, quantities and nested fields a
+// recorded stream holds, so the hash must survive the FJSON round trip the Verifier makes.
+func testBatches(n, size int) []batching.Batches {
+	created := time.Now().Add(-time.Hour)
+
+	var out []batching.Batches
+	var seq, i uint64
+	for j := 0; j < n; j++ {
+		b := batching.Batches{}
+		for k := 0; k < size; k++ {
+			i++
+			var e data.Entry
+			switch i % 3 {
+			case 1:
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              fmt.Sprintf("pod-%d", i),
+						Namespace:         "default",
+						UID:               types.UID(fmt.Sprintf("pod-uid-%d", i)),
+						ResourceVersion:   fmt.Sprint(100 + i),
+						CreationTimestamp: metav1.NewTime(created),
+						Labels:            map[string]string{"app": "web"},
+					},
+					Spec: corev1.PodSpec{
+						NodeName: "node-1",
+						Containers: []corev1.Container{
+							{
+								Name:  "web",
+								Image: "nginx:1.27",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										corev1.ResourceCPU:    resource.MustParse("250m"),
+										corev1.ResourceMemory: resource.MustParse("64Mi"),
+									},
+									Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+								},
+							},
+						},
+					},
+					Status: corev1.PodStatus{
+						Phase:     corev1.PodRunning,
+						StartTime: &metav1.Time{Time: created.Add(time.Second)},
+					},
+				}
+				e = data.MustNewEntry(data.MustNewInformer(data.MustNewChange(pod, nil, data.CTAdd)))
+				e.Reader = "informers"
+			case 2:
+				ev := data.MustNewEvent(data.MustNewChange(
+					&corev1.Event{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:              fmt.Sprintf("pod-%d.17a0", i-1),
+							Namespace:         "default",
+							UID:               types.UID(fmt.Sprintf("event-uid-%d", i)),
+							CreationTimestamp: metav1.NewTime(created),
+						},
+						InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: fmt.Sprintf("pod-%d", i-1)},
+						Reason:         "Pulled",
+						Message:        "Container image \"nginx:1.27\" already present on machine",
+						Type:           corev1.EventTypeNormal,
+						EventTime:      metav1.NewMicroTime(created),
+					},
+					nil,
+					data.CTAdd,
+				))
+				ev.Count = 3
+				ev.FirstTimestamp = created
+				ev.LastTimestamp = created.Add(time.Minute)
+				e = data.MustNewEntry(ev)
+				e.Reader = "events"
+			default:
+				secret := &data.SecretMeta{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              fmt.Sprintf("secret-%d", i),
+						Namespace:         "default",
+						UID:               types.UID(fmt.Sprintf("secret-uid-%d", i)),
+						CreationTimestamp: metav1.NewTime(created),
+					},
+					Type: corev1.SecretTypeOpaque,
+					Keys: []data.KeyInfo{{Name: "password", Size: 16}},
+				}
+				e = data.MustNewEntry(data.MustNewInformer(data.MustNewChange(secret, nil, data.CTAdd)))
+				e.Reader = "informers"
+			}
+			e.Observed = time.Now()
+			if b[e.Type] == nil {
+				b[e.Type] = batching.Batch{}
+			}
+			b[e.Type][e.UID()] = e
+		}
+		// Number the entries in the order they are recorded, as the Runner's sequence follows arrival.
+		for _, e := range wire.Entries(b) {
+			seq++
+			e.Seq = seq
+			b[e.Type][e.UID()] = e
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// chained sends batches through a new Chainer and returns what it outputs.
+func chained(t *testing.T, key ed25519.PrivateKey, batches []batching.Batches) []batching.Batches {
+	t.Helper()
+
+	in := make(chan batching.Batches)
+	out := make(chan batching.Batches, len(batches))
+	if _, err := New(context.Background(), in, out, key, time.Hour); err != nil {
+		t.Fatalf("New(): got err == %s, want err == nil", err)
+	}
+	for _, b := range batches {
+		in <- b
+	}
+	close(in)
+
+	var got []batching.Batches
+	for b := range out {
+		got = append(got, b)
+	}
+	return got
+}
+
+// lines encodes batches as the filesink processor records them.
+func lines(t *testing.T, batches []batching.Batches) [][]byte {
+	t.Helper()
+
+	var out [][]byte
+	for _, b := range batches {
+		for _, e := range wire.Entries(b) {
+			line, err := wire.Marshal(wire.FJSON, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func testKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func TestChainer(t *testing.T) {
+	t.Parallel()
+
+	pub, priv := testKey(t)
+	c := &Chainer{key: priv, interval: time.Minute, chain: "chain", log: slog.Default()}
+
+	start := time.Now()
+	offsets := []time.Duration{0, 30 * time.Second, 61 * time.Second, 70 * time.Second, 125 * time.Second}
+	var links []*data.ChainLink
+	for i, b := range testBatches(len(offsets), 2) {
+		c.now = func() time.Time { return start.Add(offsets[i]) }
+		c.link(b)
+
+		if len(b[data.ETChainLink]) != 1 {
+			t.Fatalf("TestChainer(%d): got %d links, want 1", i, len(b[data.ETChainLink]))
+		}
+		for _, e := range b[data.ETChainLink] {
+			link, err := e.ChainLink()
+			if err != nil {
+				t.Fatalf("TestChainer(%d): got err == %s, want err == nil", i, err)
+			}
+			links = append(links, link)
+		}
+	}
+
+	var checkpoints []uint64
+	for i, link := range links {
+		if link.Seq != uint64(i+1) || link.Entries != 2 || link.Chain != "chain" {
+			t.Errorf("TestChainer(%d): got seq %d, %d entries, chain %q, want %d, 2, %q", i, link.Seq, link.Entries, link.Chain, i+1, "chain")
+		}
+		if i > 0 && !bytes.Equal(link.Prev, links[i-1].Hash) {
+			t.Errorf("TestChainer(%d): got Prev that is not the previous Hash", i)
+		}
+		if VerifyCheckpoint(pub, link) {
+			checkpoints = append(checkpoints, link.Seq)
+		}
+	}
+	if diff := pretty.Compare([]uint64{1, 3, 5}, checkpoints); diff != "" {
+		t.Errorf("TestChainer(checkpoints): -want/+got:\n%s", diff)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	pub, priv := testKey(t)
+	otherPub, _ := testKey(t)
+	// 3 Batches of 2 entries, so lines 2, 5 and 8 are links and line 2 is a checkpoint.
+	recorded := lines(t, chained(t, priv, testBatches(3, 2)))
+
+	// mutateLink changes the link on line i.
+	mutateLink := func(ls [][]byte, i int, f func(*data.ChainLink)) {
+		e, err := wire.Unmarshal(wire.FJSON, ls[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		link, err := e.ChainLink()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f(link)
+		if ls[i], err = wire.Marshal(wire.FJSON, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		key    ed25519.PublicKey
+		tamper func(ls [][]byte) [][]byte
+		want   []ProblemKind
+	}{
+		{name: "Intact", key: pub},
+		{
+			name: "Changed entry",
+			key:  pub,
+			tamper: func(ls [][]byte) [][]byte {
+				// Line 3 is pod-4, whose CPU request is raised.
+				ls[3] = bytes.Replace(ls[3], []byte(`"cpu":"250m"`), []byte(`"cpu":"4"`), 1)
+				return ls
+			},
+			want: []ProblemKind{PKModified},
+		},
+		{
+			name:   "Removed entry",
+			key:    pub,
+			tamper: func(ls [][]byte) [][]byte { return append(ls[:3], ls[4:]...) },
+			want:   []ProblemKind{PKModified},
+		},
+		{
+			name:   "Removed batch",
+			key:    pub,
+			tamper: func(ls [][]byte) [][]byte { return append(ls[:3], ls[6:]...) },
+			want:   []ProblemKind{PKGap},
+		},
+		{
+			name:   "Truncated",
+			key:    pub,
+			tamper: func(ls [][]byte) [][]byte { return ls[:7] },
+			want:   []ProblemKind{PKUnlinked},
+		},
+		{
+			name: "Undecodable line",
+			key:  pub,
+			tamper: func(ls [][]byte) [][]byte {
+				ls[4] = []byte("not json")
+				return ls
+			},
+			want: []ProblemKind{PKModified, PKModified},
+		},
+		{
+			name: "Changed hash",
+			key:  pub,
+			tamper: func(ls [][]byte) [][]byte {
+				mutateLink(ls, 5, func(l *data.ChainLink) { l.Hash[0] ^= 1 })
+				return ls
+			},
+			want: []ProblemKind{PKModified, PKBrokenLink},
+		},
+		{
+			name: "Changed signature",
+			key:  pub,
+			tamper: func(ls [][]byte) [][]byte {
+				mutateLink(ls, 2, func(l *data.ChainLink) { l.Signature[0] ^= 1 })
+				return ls
+			},
+			want: []ProblemKind{PKBadSignature},
+		},
+		{name: "Other key", key: otherPub, want: []ProblemKind{PKBadSignature}},
+	}
+
+	for _, test := range tests {
+		ls := make([][]byte, 0, len(recorded))
+		for _, l := range recorded {
+			ls = append(ls, append([]byte{}, l...))
+		}
+		if test.tamper != nil {
+			ls = test.tamper(ls)
+		}
+
+		v, err := NewVerifier(test.key)
+		if err != nil {
+			t.Fatalf("TestVerifier(%s): got err == %s, want err == nil", test.name, err)
+		}
+		if err := v.Read(bytes.NewReader(append(bytes.Join(ls, []byte("\n")), '\n'))); err != nil {
+			t.Fatalf("TestVerifier(%s): got err == %s, want err == nil", test.name, err)
+		}
+
+		var got []ProblemKind
+		for _, p := range v.Report().Problems {
+			got = append(got, p.Kind)
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestVerifier(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestVerifierBatches(t *testing.T) {
+	t.Parallel()
+
+	pub, priv := testKey(t)
+	batches := chained(t, priv, testBatches(4, 3))
+
+	// Batches received from a stream have been through the wire encoding, as they would be recorded.
+	v, err := NewVerifier(pub)
+	if err != nil {
+		t.Fatalf("TestVerifierBatches: got err == %s, want err == nil", err)
+	}
+	for _, b := range batches {
+		enc, err := wire.MarshalBatches(wire.FProto, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := wire.UnmarshalBatches(wire.FProto, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.Batches(dec)
+	}
+
+	want := Report{Chains: 1, Batches: 4, Entries: 12, Checkpoints: 1, Unsigned: 3}
+	if diff := pretty.Compare(want, v.Report()); diff != "" {
+		t.Errorf("TestVerifierBatches: -want/+got:\n%s", diff)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, priv := testKey(t)
+
+	tests := []struct {
+		name     string
+		in       chan batching.Batches
+		out      chan batching.Batches
+		key      ed25519.PrivateKey
+		interval time.Duration
+	}{
+		{name: "nil in", out: make(chan batching.Batches), key: priv, interval: time.Minute},
+		{name: "nil out", in: make(chan batching.Batches), key: priv, interval: time.Minute},
+		{name: "Bad key", in: make(chan batching.Batches), out: make(chan batching.Batches), key: priv[:10], interval: time.Minute},
+		{name: "Bad interval", in: make(chan batching.Batches), out: make(chan batching.Batches), key: priv},
+	}
+	for _, test := range tests {
+		if _, err := New(context.Background(), test.in, test.out, test.key, test.interval); err == nil {
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
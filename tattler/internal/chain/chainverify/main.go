@@ -0,0 +1,143 @@
+// chainverify checks records of Batches made with a hash chain and reports gaps and modifications.
+//
+// Usage:
+//
+//	chainverify -key key.pub /var/log/tattler
+//	chainverify -key key.pub recording.jsonl segment.jsonl.gz
+//	recorder | chainverify -key key.pub -
+//
+// key.pub holds the hex encoded ed25519 public key of the key the checkpoints were signed with. Arguments
+// are JSON Lines files, gzip compressed if they end in .gz, or directories written by the filesink
+// processor. They are read in order as one record, and the segments of a directory are read oldest first.
+// "-" reads stdin. chainverify exits 1 if the record has problems.
+package main
+
+import (
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/chain"
+)
+
+var keyFile = flag.String("key", "", "file holding the hex encoded ed25519 public key checkpoints are signed with")
+
+func main() {
+	flag.Parse()
+
+	if *keyFile == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: chainverify -key <file> <file, directory or -> ...")
+		os.Exit(2)
+	}
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	v, err := chain.NewVerifier(key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, arg := range flag.Args() {
+		paths, err := expand(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		for _, path := range paths {
+			if err := read(v, path); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+				os.Exit(2)
+			}
+		}
+	}
+
+	r := v.Report()
+	fmt.Printf("chains: %d, batches: %d, entries: %d, checkpoints: %d\n", r.Chains, r.Batches, r.Entries, r.Checkpoints)
+	if r.Unsigned > 0 {
+		fmt.Printf("%d batches are after the last checkpoint of their chain and are not signed\n", r.Unsigned)
+	}
+	for _, p := range r.Problems {
+		fmt.Println(p)
+	}
+	if len(r.Problems) > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
+
+// readKey reads a hex encoded ed25519 public key from path.
+func readKey(path string) (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %s: %w", path, err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("key file %s: got %d bytes, want %d", path, len(key), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// expand returns the files to read for arg. A directory is the segments in it, oldest first.
+func expand(arg string) ([]string, error) {
+	if arg == "-" {
+		return []string{arg}, nil
+	}
+	fi, err := os.Stat(arg)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{arg}, nil
+	}
+
+	var paths []string
+	for _, pattern := range []string{"*.jsonl", "*.jsonl.gz"} {
+		matches, err := filepath.Glob(filepath.Join(arg, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	// Segment names sort in time order, the active segment is the newest.
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.TrimSuffix(paths[i], ".gz") < strings.TrimSuffix(paths[j], ".gz")
+	})
+	return paths, nil
+}
+
+// read adds the entries in the file at path to v.
+func read(v *chain.Verifier, path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return v.Read(r)
+}
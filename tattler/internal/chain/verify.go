@@ -0,0 +1,237 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+)
+
+//go:generate stringer -type=ProblemKind -linecomment
+
+// ProblemKind is the kind of a Problem.
+type ProblemKind uint8
+
+const (
+	// PKUnknown indicates a bug in the code.
+	PKUnknown ProblemKind = 0 // Unknown
+	// PKGap indicates Batches are missing between two links.
+	PKGap ProblemKind = 1 // Gap
+	// PKOrder indicates a link has a sequence number at or before one already seen.
+	PKOrder ProblemKind = 2 // Order
+	// PKModified indicates the entries of a Batches do not match its link's hash.
+	PKModified ProblemKind = 3 // Modified
+	// PKBrokenLink indicates a link's previous hash is not the hash of the link before it.
+	PKBrokenLink ProblemKind = 4 // BrokenLink
+	// PKBadSignature indicates a checkpoint's signature is not valid.
+	PKBadSignature ProblemKind = 5 // BadSignature
+	// PKUnlinked indicates entries at the end of the record that no link covers.
+	PKUnlinked ProblemKind = 6 // Unlinked
+)
+
+// Problem is something wrong with a record.
+type Problem struct {
+	// Kind is the kind of problem.
+	Kind ProblemKind
+	// Chain is the chain the problem is in.
+	Chain string
+	// Seq is the sequence number of the link the problem was found at.
+	Seq uint64
+	// Detail describes the problem.
+	Detail string
+}
+
+// String implements fmt.Stringer.
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: chain %s seq %d: %s", p.Kind, p.Chain, p.Seq, p.Detail)
+}
+
+// Report is the result of verifying a record.
+type Report struct {
+	// Chains is the number of chains in the record. There is one for each time the pipeline started.
+	Chains int
+	// Batches is the number of links in the record.
+	Batches int
+	// Entries is the number of entries in the record, not counting links.
+	Entries int
+	// Checkpoints is the number of checkpoints with valid signatures.
+	Checkpoints int
+	// Unsigned is the number of links after the last valid checkpoint in their chain. Until a checkpoint
+	// after them is recorded, they could have been rewritten along with their hashes.
+	Unsigned int
+	// Problems are the problems found, in the order they were found. A record with none is intact.
+	Problems []Problem
+}
+
+// Verifier checks a record of Batches made with a Chainer. Pass it each entry of the record, in the order
+// they were recorded, then call Report().
+type Verifier struct {
+	key ed25519.PublicKey
+
+	// pending are the entries since the last link.
+	pending []data.Entry
+	// undecodable is the number of lines since the last link that Read() could not decode.
+	undecodable int
+	// last is the last link seen.
+	last *data.ChainLink
+	// unsigned is the number of links since the last valid checkpoint in the current chain.
+	unsigned int
+
+	report Report
+}
+
+// NewVerifier creates a Verifier that checks checkpoints were signed with key.
+func NewVerifier(key ed25519.PublicKey) (*Verifier, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("chain.NewVerifier: key must be an ed25519 public key")
+	}
+	return &Verifier{key: key}, nil
+}
+
+// Batches adds the entries of b to the record. b must hold a whole Batches as it was emitted.
+func (v *Verifier) Batches(b batching.Batches) {
+	for _, e := range wire.Entries(b) {
+		v.Entry(e)
+	}
+}
+
+// Entry adds e to the record. The entries of a Batches must be added together, with its link last, which
+// is the order wire.Entries() gives.
+func (v *Verifier) Entry(e data.Entry) {
+	if e.Type != data.ETChainLink {
+		v.pending = append(v.pending, e)
+		v.report.Entries++
+		return
+	}
+
+	link, err := e.ChainLink()
+	if err != nil {
+		v.problem(PKModified, &data.ChainLink{}, "a ChainLink entry does not hold a link")
+		return
+	}
+	v.link(link, v.pending)
+	v.pending = nil
+	v.undecodable = 0
+}
+
+// Read adds the entries in r to the record. r holds JSON Lines, where each line is an entry encoded with
+// wire.Marshal() or Batches encoded with wire.MarshalBatches(), using wire.FJSON. The filesink processor
+// writes entries this way. Lines that cannot be decoded are reported as a PKModified of the Batches they are
+// in. The error is only for failing to read r.
+func (v *Verifier) Read(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var probe struct {
+			Entries json.RawMessage `json:"entries"`
+		}
+		if err := json.Unmarshal(line, &probe); err != nil {
+			v.undecodable++
+			continue
+		}
+		if probe.Entries != nil {
+			b, err := wire.UnmarshalBatches(wire.FJSON, line)
+			if err != nil {
+				v.undecodable++
+				continue
+			}
+			v.Batches(b)
+			continue
+		}
+		e, err := wire.Unmarshal(wire.FJSON, line)
+		if err != nil {
+			v.undecodable++
+			continue
+		}
+		v.Entry(e)
+	}
+	return s.Err()
+}
+
+// link checks link and the entries it covers against the last link.
+func (v *Verifier) link(link *data.ChainLink, entries []data.Entry) {
+	v.report.Batches++
+
+	last := v.last
+	switch {
+	case last == nil || last.Chain != link.Chain:
+		// The start of a record or of a new chain. The record may start part way through a chain, so
+		// Prev cannot be checked.
+		v.report.Chains++
+		v.report.Unsigned += v.unsigned
+		v.unsigned = 0
+	case link.Seq <= last.Seq:
+		v.problem(PKOrder, link, fmt.Sprintf("follows seq %d", last.Seq))
+	case link.Seq > last.Seq+1:
+		v.problem(PKGap, link, fmt.Sprintf("%d batches missing after seq %d", link.Seq-last.Seq-1, last.Seq))
+	case !bytes.Equal(link.Prev, last.Hash):
+		v.problem(PKBrokenLink, link, fmt.Sprintf("previous hash does not match seq %d", last.Seq))
+	}
+
+	if v.undecodable > 0 {
+		v.problem(PKModified, link, fmt.Sprintf("%d lines could not be decoded", v.undecodable))
+	}
+
+	encoded := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		b, err := wire.Marshal(wire.FProto, e)
+		if err != nil {
+			v.problem(PKModified, link, fmt.Sprintf("could not encode %v %s: %s", e.Type, e.UID(), err))
+			continue
+		}
+		encoded = append(encoded, b)
+	}
+	switch {
+	case len(entries) != link.Entries:
+		v.problem(PKModified, link, fmt.Sprintf("has %d entries, link covers %d", len(entries), link.Entries))
+	case !bytes.Equal(Hash(link, encoded), link.Hash):
+		v.problem(PKModified, link, "entries do not match the hash")
+	}
+
+	switch {
+	case len(link.Signature) == 0:
+		v.unsigned++
+	case VerifyCheckpoint(v.key, link):
+		v.report.Checkpoints++
+		v.unsigned = 0
+	default:
+		v.problem(PKBadSignature, link, "checkpoint signature is not valid")
+		v.unsigned++
+	}
+
+	v.last = link
+}
+
+func (v *Verifier) problem(kind ProblemKind, link *data.ChainLink, detail string) {
+	v.report.Problems = append(v.report.Problems, Problem{Kind: kind, Chain: link.Chain, Seq: link.Seq, Detail: detail})
+}
+
+// Report returns the report for the record so far. Entries added since the last link are reported as
+// PKUnlinked, as the record should not end part way through a Batches.
+func (v *Verifier) Report() Report {
+	r := v.report
+	r.Unsigned += v.unsigned
+	r.Problems = append([]Problem(nil), r.Problems...)
+	if len(v.pending) > 0 || v.undecodable > 0 {
+		p := Problem{
+			Kind:   PKUnlinked,
+			Detail: fmt.Sprintf("%d entries and %d undecodable lines at the end are not covered by a link", len(v.pending), v.undecodable),
+		}
+		if v.last != nil {
+			p.Chain, p.Seq = v.last.Chain, v.last.Seq
+		}
+		r.Problems = append(r.Problems, p)
+	}
+	return r
+}
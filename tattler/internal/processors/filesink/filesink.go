@@ -0,0 +1,622 @@
+/*
+Package filesink provides a processor that keeps an append-only, on host record of everything it receives as
+JSON Lines files.
+
+Each entry is written as one line of wire.Marshal() with wire.FJSON. Lines go to the active segment, which is
+rotated when it reaches a maximum size or age. A rotated segment is closed, gzip compressed and given a
+manifest, a JSON file recording how many entries it holds and hashes of its content. Closed segments past a
+retention count or age are deleted along with their manifests. The active segment is fsynced on an interval,
+so at most that much data is lost if the host fails.
+
+For a segment named tattler-20240501T120000.000000000Z the files are:
+
+	tattler-20240501T120000.000000000Z.jsonl          the active segment
+	tattler-20240501T120000.000000000Z.jsonl.gz       the segment once closed
+	tattler-20240501T120000.000000000Z.manifest.json  the Manifest of the closed segment
+
+Active segments found when the processor starts are from a previous run that did not stop cleanly, and are
+closed before anything is written.
+
+Usage:
+
+	in := make(chan batching.Batches, 10)
+	_, err := filesink.New(
+		ctx,
+		in,
+		"/var/log/tattler",
+		filesink.WithMaxSize(64 << 20),
+		filesink.WithRetention(100, 30 * 24 * time.Hour),
+	)
+	if err != nil {
+		// Do something
+	}
+	if err := runner.AddProcessor(ctx, "filesink", in); err != nil {
+		// Do something
+	}
+
+The processor closes the active segment and stops when in is closed. Done() is closed once it has.
+*/
+package filesink
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+)
+
+const (
+	// segmentPrefix starts the name of every segment.
+	segmentPrefix = "tattler-"
+	// timeFormat is the time in a segment name. It sorts in time order.
+	timeFormat = "20060102T150405.000000000Z"
+
+	activeExt   = ".jsonl"
+	closedExt   = ".jsonl.gz"
+	manifestExt = ".manifest.json"
+)
+
+// Manifest describes a closed segment. It is written next to the segment when it is closed.
+type Manifest struct {
+	// Version is the wire.Version of the entries in the segment.
+	Version int `json:"version"`
+	// Segment is the name of the segment, without an extension.
+	Segment string `json:"segment"`
+	// File is the file name of the compressed segment.
+	File string `json:"file"`
+	// Opened is when the segment was opened.
+	Opened time.Time `json:"opened"`
+	// Closed is when the segment was closed.
+	Closed time.Time `json:"closed"`
+	// Entries is the number of entries, which is the number of lines, in the segment.
+	Entries int `json:"entries"`
+	// Bytes is the size of the uncompressed segment.
+	Bytes int64 `json:"bytes"`
+	// SHA256 is the hex encoded SHA-256 of the uncompressed segment.
+	SHA256 string `json:"sha256"`
+	// FileBytes is the size of File.
+	FileBytes int64 `json:"fileBytes"`
+	// FileSHA256 is the hex encoded SHA-256 of File.
+	FileSHA256 string `json:"fileSha256"`
+	// Recovered is set if the segment was closed when the processor started, after a run that did not stop
+	// cleanly, or after writing to it failed. Entries, Bytes and SHA256 are then of what reached the file, and
+	// for a previous run Opened is the time in the segment name.
+	Recovered bool `json:"recovered,omitempty"`
+}
+
+// Processor writes Batches to rotating JSON Lines files.
+type Processor struct {
+	in  chan batching.Batches
+	dir string
+
+	maxSize      int64
+	maxAge       time.Duration
+	keepCount    int
+	keepAge      time.Duration
+	syncInterval time.Duration
+
+	// active is the segment being written and lastOpened when the last segment was opened. These are only
+	// used by run().
+	active     *segment
+	lastOpened time.Time
+
+	// closing tracks segments being closed in the background. closeMu closes them one at a time, so that
+	// retention never sees a segment that is half closed.
+	closing sync.WaitGroup
+	closeMu sync.Mutex
+
+	now  func() time.Time
+	done chan struct{}
+	log  *slog.Logger
+}
+
+// segment is the active segment.
+type segment struct {
+	name    string
+	opened  time.Time
+	f       *os.File
+	w       *bufio.Writer
+	hash    hash.Hash
+	entries int
+	bytes   int64
+	dirty   bool
+}
+
+// Option is an option for New().
+type Option func(*Processor) error
+
+// WithLogger sets the logger. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(p *Processor) error {
+		if l == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		p.log = l
+		return nil
+	}
+}
+
+// WithMaxSize sets the size in bytes a segment is rotated at. A segment is rotated after the batch that
+// takes it to this size, so segments may be larger. Defaults to 100 MiB.
+func WithMaxSize(size int64) Option {
+	return func(p *Processor) error {
+		if size <= 0 {
+			return fmt.Errorf("max size must be > 0")
+		}
+		p.maxSize = size
+		return nil
+	}
+}
+
+// WithMaxAge sets how long a segment is written to before it is rotated. Defaults to 1 hour.
+func WithMaxAge(d time.Duration) Option {
+	return func(p *Processor) error {
+		if d <= 0 {
+			return fmt.Errorf("max age must be > 0")
+		}
+		p.maxAge = d
+		return nil
+	}
+}
+
+// WithRetention deletes closed segments once there are more than count of them, or once they were closed
+// more than age ago. A zero count or age does not limit by it. Defaults to keeping everything.
+func WithRetention(count int, age time.Duration) Option {
+	return func(p *Processor) error {
+		if count < 0 || age < 0 {
+			return fmt.Errorf("retention count and age must be >= 0")
+		}
+		p.keepCount = count
+		p.keepAge = age
+		return nil
+	}
+}
+
+// WithSyncInterval sets how often the active segment is fsynced. 0 fsyncs after every batch. Defaults to
+// 1 second.
+func WithSyncInterval(d time.Duration) Option {
+	return func(p *Processor) error {
+		if d < 0 {
+			return fmt.Errorf("sync interval must be >= 0")
+		}
+		p.syncInterval = d
+		return nil
+	}
+}
+
+// New creates a new Processor that writes Batches received on in to files in dir until in is closed. dir is
+// created if it does not exist.
+func New(ctx context.Context, in chan batching.Batches, dir string, options ...Option) (*Processor, error) {
+	if in == nil {
+		return nil, fmt.Errorf("filesink.New: input channel cannot be nil")
+	}
+
+	p := &Processor{
+		in:           in,
+		dir:          dir,
+		maxSize:      100 << 20,
+		maxAge:       time.Hour,
+		syncInterval: time.Second,
+		now:          time.Now,
+		done:         make(chan struct{}),
+		log:          slog.Default(),
+	}
+	for _, o := range options {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("filesink.New: %w", err)
+	}
+	if err := p.recover(); err != nil {
+		return nil, fmt.Errorf("filesink.New: could not close segments from a previous run: %w", err)
+	}
+
+	go p.run()
+
+	return p, nil
+}
+
+// Done returns a channel that is closed once in has been closed and every segment has been closed.
+func (p *Processor) Done() <-chan struct{} {
+	return p.done
+}
+
+// run writes Batches until p.in is closed.
+func (p *Processor) run() {
+	defer close(p.done)
+	defer p.closing.Wait()
+
+	// The ticker checks the age of the active segment and syncs it. It runs at least often enough to
+	// rotate close to maxAge, but no more than every millisecond, and a ticker cannot have a 0 period.
+	tick := min(p.maxAge/10, time.Second)
+	if p.syncInterval > 0 {
+		tick = min(tick, p.syncInterval)
+	}
+	tick = max(tick, time.Millisecond)
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	lastSync := p.now()
+
+	for {
+		select {
+		case batches, ok := <-p.in:
+			if !ok {
+				if err := p.rotate(); err != nil {
+					p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+				}
+				return
+			}
+			if err := p.write(batches); err != nil {
+				p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+			}
+			if p.syncInterval == 0 {
+				if err := p.sync(); err != nil {
+					p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+				}
+			}
+		case <-ticker.C:
+			now := p.now()
+			if p.active != nil && now.Sub(p.active.opened) >= p.maxAge {
+				if err := p.rotate(); err != nil {
+					p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+				}
+			}
+			if p.syncInterval > 0 && now.Sub(lastSync) >= p.syncInterval {
+				lastSync = now
+				if err := p.sync(); err != nil {
+					p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+				}
+			}
+		}
+	}
+}
+
+// write writes the entries in batches to the active segment, opening one if needed, and rotates it if it
+// has reached the maximum size.
+func (p *Processor) write(batches batching.Batches) error {
+	entries := wire.Entries(batches)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if p.active == nil {
+		if err := p.open(); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, e := range entries {
+		b, err := wire.Marshal(wire.FJSON, e)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not encode %v %s: %w", e.Type, e.UID(), err))
+			continue
+		}
+		b = append(b, '\n')
+		if _, err := p.active.w.Write(b); err != nil {
+			name := p.active.name
+			p.abandon()
+			return fmt.Errorf("could not write segment %s: %w", name, err)
+		}
+		p.active.hash.Write(b)
+		p.active.entries++
+		p.active.bytes += int64(len(b))
+	}
+	if err := p.active.w.Flush(); err != nil {
+		name := p.active.name
+		p.abandon()
+		return fmt.Errorf("could not write segment %s: %w", name, err)
+	}
+	p.active.dirty = true
+
+	if p.active.bytes >= p.maxSize {
+		if err := p.rotate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors, first: %w", len(errs), errs[0])
+	}
+	return nil
+}
+
+// open opens a new active segment.
+func (p *Processor) open() error {
+	now := p.now().UTC()
+	// Segment names must be unique, even if the clock does not move between rotations.
+	if !now.After(p.lastOpened) {
+		now = p.lastOpened.Add(time.Nanosecond)
+	}
+	p.lastOpened = now
+	name := segmentPrefix + now.Format(timeFormat)
+	f, err := os.OpenFile(filepath.Join(p.dir, name+activeExt), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return fmt.Errorf("could not open segment: %w", err)
+	}
+	p.active = &segment{
+		name:   name,
+		opened: now,
+		f:      f,
+		w:      bufio.NewWriterSize(f, 64<<10),
+		hash:   sha256.New(),
+	}
+	return nil
+}
+
+// sync fsyncs the active segment if it has been written to since the last sync.
+func (p *Processor) sync() error {
+	if p.active == nil || !p.active.dirty {
+		return nil
+	}
+	if err := p.active.f.Sync(); err != nil {
+		return fmt.Errorf("could not sync segment %s: %w", p.active.name, err)
+	}
+	p.active.dirty = false
+	return nil
+}
+
+// rotate closes the active segment, if there is one. It is compressed, given a manifest and retention is
+// applied in the background.
+func (p *Processor) rotate() error {
+	if p.active == nil {
+		return nil
+	}
+	s := p.active
+
+	if err := s.w.Flush(); err != nil {
+		p.abandon()
+		return fmt.Errorf("could not write segment %s: %w", s.name, err)
+	}
+	if err := s.f.Sync(); err != nil {
+		p.abandon()
+		return fmt.Errorf("could not sync segment %s: %w", s.name, err)
+	}
+	p.active = nil
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("could not close segment %s: %w", s.name, err)
+	}
+
+	m := Manifest{
+		Version: wire.Version,
+		Segment: s.name,
+		Opened:  s.opened,
+		Closed:  p.now().UTC(),
+		Entries: s.entries,
+		Bytes:   s.bytes,
+		SHA256:  hex.EncodeToString(s.hash.Sum(nil)),
+	}
+	p.background(func() error { return p.finish(m) })
+	return nil
+}
+
+// abandon closes the active segment after writing to it failed, so the next batch opens a new one. The
+// segment's counts and hash may include lines that did not reach the file, so it is closed like a recovered
+// segment, from what is in the file.
+func (p *Processor) abandon() {
+	s := p.active
+	p.active = nil
+	s.f.Close()
+
+	p.background(func() error {
+		m := Manifest{Version: wire.Version, Segment: s.name, Opened: s.opened, Closed: p.now().UTC(), Recovered: true}
+		var err error
+		if m.Entries, m.Bytes, m.SHA256, err = scan(filepath.Join(p.dir, s.name+activeExt)); err != nil {
+			return fmt.Errorf("segment %s: %w", s.name, err)
+		}
+		return p.finish(m)
+	})
+}
+
+// background runs finish, which closes a segment, and then applies retention, one segment at a time.
+func (p *Processor) background(finish func() error) {
+	p.closing.Add(1)
+	go func() {
+		defer p.closing.Done()
+		p.closeMu.Lock()
+		defer p.closeMu.Unlock()
+
+		if err := finish(); err != nil {
+			p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+		}
+		if err := p.retain(); err != nil {
+			p.log.Error(fmt.Sprintf("filesink(%s): %s", p.dir, err))
+		}
+	}()
+}
+
+// finish compresses the segment in m, writes its manifest and removes the uncompressed segment.
+func (p *Processor) finish(m Manifest) error {
+	src := filepath.Join(p.dir, m.Segment+activeExt)
+	m.File = m.Segment + closedExt
+	dst := filepath.Join(p.dir, m.File)
+
+	n, sum, err := compress(src, dst)
+	if err != nil {
+		return fmt.Errorf("could not compress segment %s: %w", m.Segment, err)
+	}
+	m.FileBytes = n
+	m.FileSHA256 = sum
+
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(p.dir, m.Segment+manifestExt), append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write manifest for segment %s: %w", m.Segment, err)
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("could not remove compressed segment %s: %w", m.Segment, err)
+	}
+	return nil
+}
+
+// recover closes active segments left by a previous run.
+func (p *Processor) recover() error {
+	paths, err := filepath.Glob(filepath.Join(p.dir, segmentPrefix+"*"+activeExt))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), activeExt)
+		m := Manifest{Version: wire.Version, Segment: name, Closed: p.now().UTC(), Recovered: true}
+		if t, err := time.Parse(timeFormat, strings.TrimPrefix(name, segmentPrefix)); err == nil {
+			m.Opened = t
+		}
+		if m.Entries, m.Bytes, m.SHA256, err = scan(path); err != nil {
+			return fmt.Errorf("segment %s: %w", name, err)
+		}
+		if err := p.finish(m); err != nil {
+			return err
+		}
+	}
+	return p.retain()
+}
+
+// retain deletes closed segments, and their manifests, that are past the retention count or age. p.closeMu
+// must be held after New() has returned.
+func (p *Processor) retain() error {
+	if p.keepCount == 0 && p.keepAge == 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(p.dir, segmentPrefix+"*"+closedExt))
+	if err != nil {
+		return err
+	}
+	// Names sort in the order the segments were opened, newest last.
+	sort.Strings(paths)
+
+	now := p.now()
+	var errs []error
+	for i, path := range paths {
+		remove := p.keepCount > 0 && i < len(paths)-p.keepCount
+		if !remove && p.keepAge > 0 {
+			fi, err := os.Stat(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			remove = now.Sub(fi.ModTime()) > p.keepAge
+		}
+		if !remove {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), closedExt)
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Remove(filepath.Join(p.dir, name+manifestExt)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("retention had %d errors, first: %w", len(errs), errs[0])
+	}
+	return nil
+}
+
+// compress gzips src to dst and returns the size and hex encoded SHA-256 of dst.
+func compress(src, dst string) (int64, string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return 0, "", err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	cw := &countWriter{w: io.MultiWriter(out, h)}
+	zw := gzip.NewWriter(cw)
+	if _, err := io.Copy(zw, in); err != nil {
+		return 0, "", err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := out.Sync(); err != nil {
+		return 0, "", err
+	}
+	return cw.n, hex.EncodeToString(h.Sum(nil)), out.Close()
+}
+
+// scan returns the number of lines, the size and the hex encoded SHA-256 of the file at path.
+func scan(path string) (int, int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	r := bufio.NewReader(io.TeeReader(f, h))
+	var lines int
+	var size int64
+	for {
+		line, err := r.ReadBytes('\n')
+		size += int64(len(line))
+		if bytes.HasSuffix(line, []byte{'\n'}) {
+			lines++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, "", err
+		}
+	}
+	return lines, size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFile writes b to path and fsyncs it.
+func writeFile(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
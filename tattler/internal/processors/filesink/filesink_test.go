@@ -0,0 +1,438 @@
+package filesink
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"github.com/kylelemons/godebug/pretty"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testBatches returns n Batches of size entries each, with sequence numbers following on from each other.
+// The entries cycle through node updates, persistent volume claims and node liveness, as a segment holds
+// entries from several readers.
+func testBatches(n, size int) []batching.Batches {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var out []batching.Batches
+	var seq, i uint64
+	for j := 0; j < n; j++ {
+		b := batching.Batches{}
+		for k := 0; k < size; k++ {
+			i++
+			observed := start.Add(time.Duration(i) * time.Second)
+			var e data.Entry
+			switch i % 3 {
+			case 1:
+				node := func(rv string, ready corev1.ConditionStatus) *corev1.Node {
+					return &corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:            fmt.Sprintf("node-%d", i),
+							UID:             types.UID(fmt.Sprintf("node-uid-%d", i)),
+							ResourceVersion: rv,
+						},
+						Status: corev1.NodeStatus{
+							Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+						},
+					}
+				}
+				e = data.MustNewEntry(data.MustNewInformer(data.MustNewChange(node("2", corev1.ConditionFalse), node("1", corev1.ConditionTrue), data.CTUpdate)))
+				e.Reader = "informers"
+			case 2:
+				pvc := &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("data-%d", i),
+						Namespace: "default",
+						UID:       types.UID(fmt.Sprintf("pvc-uid-%d", i)),
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{VolumeName: fmt.Sprintf("pv-%d", i)},
+				}
+				e = data.MustNewEntry(data.MustNewPersistentVolume(data.MustNewChange(pvc, nil, data.CTAdd)))
+				e.Reader = "persistentvolumes"
+			default:
+				lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i), UID: types.UID(fmt.Sprintf("lease-uid-%d", i))}}
+				e = data.MustNewEntry(data.MustNewInformer(data.MustNewChange(data.NewNodeLiveness(lease, observed), nil, data.CTAdd)))
+				e.Reader = "leases"
+			}
+			e.Origin = data.ORWatch
+			e.Observed = observed
+			if b[e.Type] == nil {
+				b[e.Type] = batching.Batch{}
+			}
+			b[e.Type][e.UID()] = e
+		}
+		// Number the entries in the order they are written, so a segment's sequence numbers ascend.
+		for _, e := range wire.Entries(b) {
+			seq++
+			e.Seq = seq
+			b[e.Type][e.UID()] = e
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// run sends batches to a new Processor in dir and waits for it to finish.
+func run(t *testing.T, dir string, batches []batching.Batches, options ...Option) {
+	t.Helper()
+
+	in := make(chan batching.Batches)
+	p, err := New(context.Background(), in, dir, options...)
+	if err != nil {
+		t.Fatalf("New(): got err == %s, want err == nil", err)
+	}
+	for _, b := range batches {
+		in <- b
+	}
+	close(in)
+	<-p.Done()
+}
+
+// readSegment is a closed segment read back from disk.
+type readSegment struct {
+	manifest Manifest
+	lines    [][]byte
+}
+
+// readSegments reads the closed segments in dir, oldest first. It fails the test if a manifest does not
+// match its segment.
+func readSegments(t *testing.T, dir string) []readSegment {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+manifestExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+
+	var segs []readSegment
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatalf("manifest %s: %s", path, err)
+		}
+
+		gz, err := os.ReadFile(filepath.Join(dir, m.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sha256.Sum256(gz); hex.EncodeToString(sum[:]) != m.FileSHA256 || int64(len(gz)) != m.FileBytes {
+			t.Errorf("segment %s: manifest file hash or size does not match", m.Segment)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(gz))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != m.SHA256 || int64(len(content)) != m.Bytes {
+			t.Errorf("segment %s: manifest content hash or size does not match", m.Segment)
+		}
+
+		seg := readSegment{manifest: m}
+		s := bufio.NewScanner(bytes.NewReader(content))
+		s.Buffer(nil, 1<<20)
+		for s.Scan() {
+			seg.lines = append(seg.lines, append([]byte{}, s.Bytes()...))
+		}
+		if len(seg.lines) != m.Entries {
+			t.Errorf("segment %s: got %d lines, manifest has %d entries", m.Segment, len(seg.lines), m.Entries)
+		}
+		segs = append(segs, seg)
+	}
+	return segs
+}
+
+// seqs decodes the lines of segs and returns the sequence numbers of their entries.
+func seqs(t *testing.T, segs []readSegment) []uint64 {
+	t.Helper()
+
+	var out []uint64
+	for _, seg := range segs {
+		for _, line := range seg.lines {
+			e, err := wire.Unmarshal(wire.FJSON, line)
+			if err != nil {
+				t.Fatalf("wire.Unmarshal(): %s", err)
+			}
+			out = append(out, e.Seq)
+		}
+	}
+	return out
+}
+
+func seqRange(n int) []uint64 {
+	out := make([]uint64, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, uint64(i))
+	}
+	return out
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	run(t, dir, testBatches(3, 4), WithSyncInterval(0))
+
+	if active, _ := filepath.Glob(filepath.Join(dir, "*"+activeExt)); len(active) != 0 {
+		t.Errorf("TestWrite: got active segments %v after stopping, want none", active)
+	}
+	segs := readSegments(t, dir)
+	if len(segs) != 1 {
+		t.Fatalf("TestWrite: got %d segments, want 1", len(segs))
+	}
+	m := segs[0].manifest
+	if m.Version != wire.Version || m.Recovered || m.Opened.IsZero() || m.Closed.Before(m.Opened) {
+		t.Errorf("TestWrite: got manifest %+v, want version %d, not recovered, opened before closed", m, wire.Version)
+	}
+	if diff := pretty.Compare(seqRange(12), seqs(t, segs)); diff != "" {
+		t.Errorf("TestWrite: -want/+got:\n%s", diff)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Size", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		// Each batch is well over 100 bytes, so every batch rotates.
+		run(t, dir, testBatches(4, 2), WithMaxSize(100))
+
+		segs := readSegments(t, dir)
+		if len(segs) != 4 {
+			t.Errorf("TestRotate(Size): got %d segments, want 4", len(segs))
+		}
+		if diff := pretty.Compare(seqRange(8), seqs(t, segs)); diff != "" {
+			t.Errorf("TestRotate(Size): -want/+got:\n%s", diff)
+		}
+	})
+
+	t.Run("Age", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		batches := testBatches(2, 1)
+		in := make(chan batching.Batches)
+		p, err := New(context.Background(), in, dir, WithMaxAge(20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("TestRotate(Age): got err == %s, want err == nil", err)
+		}
+		in <- batches[0]
+		time.Sleep(200 * time.Millisecond)
+		in <- batches[1]
+		close(in)
+		<-p.Done()
+
+		segs := readSegments(t, dir)
+		if len(segs) != 2 {
+			t.Errorf("TestRotate(Age): got %d segments, want 2", len(segs))
+		}
+		if diff := pretty.Compare(seqRange(2), seqs(t, segs)); diff != "" {
+			t.Errorf("TestRotate(Age): -want/+got:\n%s", diff)
+		}
+	})
+
+	t.Run("Tiny age", func(t *testing.T) {
+		t.Parallel()
+
+		// An age below 10ns must not give the ticker a 0 period.
+		dir := t.TempDir()
+		run(t, dir, testBatches(2, 2), WithMaxAge(5*time.Nanosecond))
+
+		if diff := pretty.Compare(seqRange(4), seqs(t, readSegments(t, dir))); diff != "" {
+			t.Errorf("TestRotate(Tiny age): -want/+got:\n%s", diff)
+		}
+	})
+}
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Count", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		run(t, dir, testBatches(5, 1), WithMaxSize(1), WithRetention(2, 0))
+
+		segs := readSegments(t, dir)
+		if diff := pretty.Compare([]uint64{4, 5}, seqs(t, segs)); diff != "" {
+			t.Errorf("TestRetention(Count): -want/+got:\n%s", diff)
+		}
+		gz, _ := filepath.Glob(filepath.Join(dir, "*"+closedExt))
+		if len(gz) != 2 {
+			t.Errorf("TestRetention(Count): got %d closed segments, want 2", len(gz))
+		}
+	})
+
+	t.Run("Age", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		old := segmentPrefix + "20200101T000000.000000000Z"
+		for _, ext := range []string{closedExt, manifestExt} {
+			path := filepath.Join(dir, old+ext)
+			if err := os.WriteFile(path, nil, 0o640); err != nil {
+				t.Fatal(err)
+			}
+			past := time.Now().Add(-48 * time.Hour)
+			if err := os.Chtimes(path, past, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run(t, dir, testBatches(1, 1), WithRetention(0, 24*time.Hour))
+
+		for _, ext := range []string{closedExt, manifestExt} {
+			if _, err := os.Stat(filepath.Join(dir, old+ext)); !os.IsNotExist(err) {
+				t.Errorf("TestRetention(Age): got %s%s kept, want it deleted", old, ext)
+			}
+		}
+		if segs := readSegments(t, dir); len(segs) != 1 {
+			t.Errorf("TestRetention(Age): got %d segments, want 1", len(segs))
+		}
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := segmentPrefix + "20240501T120000.000000000Z"
+
+	var content []byte
+	for _, b := range testBatches(2, 1) {
+		line, err := wire.Marshal(wire.FJSON, wire.Entries(b)[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = append(append(content, line...), '\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+activeExt), content, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan batching.Batches)
+	p, err := New(context.Background(), in, dir)
+	if err != nil {
+		t.Fatalf("TestRecover: got err == %s, want err == nil", err)
+	}
+	// The recovered segment is closed before New() returns.
+	segs := readSegments(t, dir)
+	close(in)
+	<-p.Done()
+
+	if len(segs) != 1 {
+		t.Fatalf("TestRecover: got %d segments, want 1", len(segs))
+	}
+	m := segs[0].manifest
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if !m.Recovered || m.Segment != name || !m.Opened.Equal(want) || m.Entries != 2 {
+		t.Errorf("TestRecover: got manifest %+v, want recovered %s opened at %s with 2 entries", m, name, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, name+activeExt)); !os.IsNotExist(err) {
+		t.Errorf("TestRecover: got the active segment kept, want it removed")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		in      chan batching.Batches
+		dir     string
+		options []Option
+	}{
+		{name: "nil in", dir: dir},
+		{name: "dir is a file", in: make(chan batching.Batches), dir: file},
+		{name: "Bad max size", in: make(chan batching.Batches), dir: dir, options: []Option{WithMaxSize(0)}},
+		{name: "Bad max age", in: make(chan batching.Batches), dir: dir, options: []Option{WithMaxAge(0)}},
+		{name: "Bad retention", in: make(chan batching.Batches), dir: dir, options: []Option{WithRetention(-1, 0)}},
+		{name: "Bad sync interval", in: make(chan batching.Batches), dir: dir, options: []Option{WithSyncInterval(-1)}},
+	}
+	for _, test := range tests {
+		if _, err := New(context.Background(), test.in, test.dir, test.options...); err == nil {
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := &Processor{dir: dir, maxSize: 100 << 20, maxAge: time.Hour, now: time.Now, log: slog.Default()}
+	batches := testBatches(3, 2)
+
+	if err := p.write(batches[0]); err != nil {
+		t.Fatalf("TestWriteError: got err == %s, want err == nil", err)
+	}
+	// The failed write must not leave the segment in place, or every later batch would fail too.
+	p.active.w = bufio.NewWriterSize(failWriter{}, 16)
+	if err := p.write(batches[1]); err == nil {
+		t.Fatalf("TestWriteError: got err == nil, want err != nil")
+	}
+	if p.active != nil {
+		t.Fatalf("TestWriteError: got an active segment after a failed write, want none")
+	}
+	if err := p.write(batches[2]); err != nil {
+		t.Fatalf("TestWriteError: got err == %s, want err == nil", err)
+	}
+	if err := p.rotate(); err != nil {
+		t.Fatalf("TestWriteError: got err == %s, want err == nil", err)
+	}
+	p.closing.Wait()
+
+	segs := readSegments(t, dir)
+	if len(segs) != 2 {
+		t.Fatalf("TestWriteError: got %d segments, want 2", len(segs))
+	}
+	if !segs[0].manifest.Recovered || segs[1].manifest.Recovered {
+		t.Errorf("TestWriteError: got Recovered %v and %v, want true and false", segs[0].manifest.Recovered, segs[1].manifest.Recovered)
+	}
+	// The first segment holds what reached the file before the failure.
+	if diff := pretty.Compare([]uint64{1, 2, 5, 6}, seqs(t, segs)); diff != "" {
+		t.Errorf("TestWriteError: -want/+got:\n%s", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package grpcstream
+
+import (
+	"context"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Client subscribes to a Server.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient creates a Client that uses cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+// Subscription is a subscription to a Server.
+type Subscription struct {
+	stream grpc.ClientStream
+	token  string
+}
+
+// Subscribe subscribes to the entries matching f. If token is set, the subscription resumes after the
+// message it came from. Cancel ctx to end the subscription.
+func (c *Client) Subscribe(ctx context.Context, f Filter, token string) (*Subscription, error) {
+	desc := &serviceDesc.Streams[0]
+	stream, err := c.cc.NewStream(ctx, desc, subscribeMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(f.toRequest(token).toProto()); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &Subscription{stream: stream, token: token}, nil
+}
+
+// Recv returns the next Batches. The error is a gRPC status error, see the package documentation for the
+// codes that need handling.
+func (s *Subscription) Recv() (batching.Batches, error) {
+	for {
+		m := dynamicpb.NewMessage(streamBatchDesc)
+		if err := s.stream.RecvMsg(m); err != nil {
+			return nil, err
+		}
+		sb := streamBatchFromProto(m)
+		s.token = sb.resumeToken
+		// The first message, and those sent while the filter matches nothing, only have the token.
+		if len(sb.batch) == 0 {
+			continue
+		}
+		return wire.UnmarshalBatches(wire.FProto, sb.batch)
+	}
+}
+
+// Token returns the resume token of the last message received. Pass it to Subscribe() to resume after it.
+func (s *Subscription) Token() string {
+	return s.token
+}
@@ -0,0 +1,528 @@
+/*
+Package grpcstream provides a processor that serves the Batches it receives to remote subscribers over a
+gRPC server stream. This lets services in other pods use one tattler instead of each running their own, with
+their own informer caches.
+
+A subscriber sends a filter of entry types, object types and namespaces, and receives each Batches as the
+entries that match it, encoded with wire.MarshalBatches() and wire.FProto. Batches with no matching entries
+are not sent.
+
+Every message has a resume token. A subscriber that reconnects with the token of the last message it
+received is sent the Batches it missed, then carries on, as long as they are still in the server's history.
+If they are not, or the server has restarted since, Subscribe fails with codes.OutOfRange and the subscriber
+must do a full resync. The first message of a subscription has no batch, only the token for the point the
+subscription started. A subscriber whose filter has matched nothing for half the history is also sent a
+message with no batch, so that its token does not fall out of the history while it is missing nothing.
+
+Each subscriber has a queue of Batches waiting to be sent. A subscriber that does not keep up fills its
+queue, and its stream is ended with codes.ResourceExhausted instead of the server dropping Batches or slowing
+the pipeline. It can resume from its last token.
+
+The service is:
+
+	syntax = "proto3";
+
+	package tattler.stream.v1;
+
+	service Stream {
+		rpc Subscribe(SubscribeRequest) returns (stream StreamBatch);
+	}
+
+	message SubscribeRequest {
+		// entry_types are the registered names of the EntryTypes to send. Empty is all.
+		repeated string entry_types = 1;
+		// object_types are the ObjectType values to send. Entries without an ObjectType are only sent if
+		// this is empty.
+		repeated uint32 object_types = 2;
+		// namespaces are the namespaces to send. Cluster scoped objects are in the "" namespace. Empty is all.
+		repeated string namespaces = 3;
+		// resume_token is the token of the last StreamBatch received, to resume after it.
+		string resume_token = 4;
+	}
+
+	message StreamBatch {
+		// batch is a tattler.wire.v1.Batch, see the wire package.
+		bytes batch = 1;
+		string resume_token = 2;
+	}
+
+Usage:
+
+	in := make(chan batching.Batches, 10)
+	stream, err := grpcstream.New(ctx, in)
+	if err != nil {
+		// Do something
+	}
+	if err := runner.AddProcessor(ctx, "grpcstream", in); err != nil {
+		// Do something
+	}
+
+	// The grpc.Server is yours, so you control the listener, TLS and authentication.
+	gs := grpc.NewServer()
+	stream.Register(gs)
+	go gs.Serve(lis)
+
+Go subscribers can use Client.
+*/
+package grpcstream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// Filter selects the entries a subscriber receives. An empty field matches everything.
+type Filter struct {
+	// EntryTypes are the types of entries to send.
+	EntryTypes []data.EntryType
+	// ObjectTypes are the types of objects to send. Entries that do not hold a change, such as
+	// SyncComplete, have no ObjectType and are only sent if this is empty.
+	ObjectTypes []data.ObjectType
+	// Namespaces are the namespaces of objects to send. Cluster scoped objects are in the "" namespace.
+	Namespaces []string
+}
+
+// toRequest converts f to a request.
+func (f Filter) toRequest(token string) request {
+	r := request{namespaces: f.Namespaces, resumeToken: token}
+	for _, et := range f.EntryTypes {
+		r.entryTypes = append(r.entryTypes, et.String())
+	}
+	for _, ot := range f.ObjectTypes {
+		r.objectTypes = append(r.objectTypes, uint32(ot))
+	}
+	return r
+}
+
+// filter is a Filter made ready for matching.
+type filter struct {
+	entryTypes  map[data.EntryType]bool
+	objectTypes map[data.ObjectType]bool
+	namespaces  map[string]bool
+}
+
+func newFilter(r request) (filter, error) {
+	var f filter
+	if len(r.entryTypes) > 0 {
+		f.entryTypes = map[data.EntryType]bool{}
+		for _, name := range r.entryTypes {
+			et, ok := data.LookupEntryType(name)
+			if !ok {
+				return filter{}, fmt.Errorf("unknown entry type %q", name)
+			}
+			f.entryTypes[et] = true
+		}
+	}
+	if len(r.objectTypes) > 0 {
+		f.objectTypes = map[data.ObjectType]bool{}
+		for _, ot := range r.objectTypes {
+			f.objectTypes[data.ObjectType(ot)] = true
+		}
+	}
+	if len(r.namespaces) > 0 {
+		f.namespaces = map[string]bool{}
+		for _, ns := range r.namespaces {
+			f.namespaces[ns] = true
+		}
+	}
+	return f, nil
+}
+
+// match reports if e matches the filter.
+func (f filter) match(e data.Entry) bool {
+	if f.entryTypes != nil && !f.entryTypes[e.Type] {
+		return false
+	}
+	if f.objectTypes != nil && !f.objectTypes[objectType(e)] {
+		return false
+	}
+	if f.namespaces != nil {
+		var ns string
+		if obj := e.Object(); obj != nil {
+			if m, err := meta.Accessor(obj); err == nil {
+				ns = m.GetNamespace()
+			}
+		}
+		if !f.namespaces[ns] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the entries in b that match the filter. It returns b if the filter matches everything.
+func (f filter) apply(b batching.Batches) batching.Batches {
+	if f.entryTypes == nil && f.objectTypes == nil && f.namespaces == nil {
+		return b
+	}
+	out := batching.Batches{}
+	for et, batch := range b {
+		for uid, e := range batch {
+			if !f.match(e) {
+				continue
+			}
+			if out[et] == nil {
+				out[et] = batching.Batch{}
+			}
+			out[et][uid] = e
+		}
+	}
+	return out
+}
+
+// objectType returns the ObjectType of the change in e, or OTUnknown if it does not hold a change.
+func objectType(e data.Entry) data.ObjectType {
+	switch e.Type {
+	case data.ETInformer:
+		if i, err := e.Informer(); err == nil {
+			return i.Type
+		}
+	case data.ETPersistentVolume:
+		if pv, err := e.PersistentVolume(); err == nil {
+			return pv.Type
+		}
+	case data.ETEvent:
+		if ev, err := e.Event(); err == nil {
+			return ev.Type
+		}
+	}
+	return data.OTUnknown
+}
+
+// item is a Batches with its place in the stream.
+type item struct {
+	seq     uint64
+	batches batching.Batches
+}
+
+// subscriber is a connected subscriber.
+type subscriber struct {
+	filter filter
+	queue  chan item
+	// sent is the sequence number of the last token sent to the subscriber.
+	sent uint64
+	// kicked is closed when the subscriber is too slow, or the server is stopping, with why set first.
+	kicked chan struct{}
+	why    error
+}
+
+// Server is the processor. It implements the Stream service.
+type Server struct {
+	in chan batching.Batches
+	// epoch identifies this run of the server in resume tokens.
+	epoch string
+
+	queueSize   int
+	historySize int
+
+	mu      sync.Mutex
+	seq     uint64
+	history []item
+	subs    map[*subscriber]struct{}
+	stopped bool
+
+	log *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Server) error
+
+// WithLogger sets the logger. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) error {
+		if l == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		s.log = l
+		return nil
+	}
+}
+
+// WithQueueSize sets how many Batches can wait to be sent to a subscriber before it is disconnected for
+// being too slow. Defaults to 64.
+func WithQueueSize(n int) Option {
+	return func(s *Server) error {
+		if n < 1 {
+			return fmt.Errorf("queue size must be >= 1")
+		}
+		s.queueSize = n
+		return nil
+	}
+}
+
+// WithHistory sets how many of the most recent Batches are kept for subscribers to resume from. Defaults
+// to 256.
+func WithHistory(n int) Option {
+	return func(s *Server) error {
+		if n < 0 {
+			return fmt.Errorf("history must be >= 0")
+		}
+		s.historySize = n
+		return nil
+	}
+}
+
+// New creates a new Server that serves Batches received on in until in is closed. Use Register() to add it
+// to a grpc.Server.
+func New(ctx context.Context, in chan batching.Batches, options ...Option) (*Server, error) {
+	if in == nil {
+		return nil, fmt.Errorf("grpcstream.New: input channel cannot be nil")
+	}
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		return nil, fmt.Errorf("grpcstream.New: %w", err)
+	}
+
+	s := &Server{
+		in:          in,
+		epoch:       hex.EncodeToString(epoch),
+		queueSize:   64,
+		historySize: 256,
+		subs:        map[*subscriber]struct{}{},
+		log:         slog.Default(),
+	}
+	for _, o := range options {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+// streamServer is the HandlerType of the service, which Server implements.
+type streamServer interface {
+	subscribe(req request, stream grpc.ServerStream) error
+}
+
+// serviceDesc describes the Stream service to gRPC.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*streamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				m := dynamicpb.NewMessage(subscribeRequestDesc)
+				if err := stream.RecvMsg(m); err != nil {
+					return err
+				}
+				return srv.(streamServer).subscribe(requestFromProto(m), stream)
+			},
+		},
+	},
+	Metadata: "tattler/stream/v1/stream.proto",
+}
+
+// Register registers the Stream service on gs.
+func (s *Server) Register(gs grpc.ServiceRegistrar) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// Subscribers returns the number of connected subscribers.
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// run sends each Batches received to the subscribers until s.in is closed, then ends their streams.
+func (s *Server) run() {
+	for batches := range s.in {
+		s.publish(batches)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for sub := range s.subs {
+		s.kick(sub, status.Error(codes.Unavailable, "server is stopping"))
+	}
+}
+
+// publish adds batches to the history and queues it for every subscriber.
+func (s *Server) publish(batches batching.Batches) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	it := item{seq: s.seq, batches: batches}
+	if s.historySize > 0 {
+		if len(s.history) == s.historySize {
+			s.history[0] = item{}
+			s.history = s.history[1:]
+		}
+		s.history = append(s.history, it)
+	}
+
+	for sub := range s.subs {
+		select {
+		case sub.queue <- it:
+		default:
+			s.kick(sub, status.Error(codes.ResourceExhausted, "subscriber is too slow, resume from the last token"))
+		}
+	}
+}
+
+// kick ends sub's stream with err. s.mu must be held.
+func (s *Server) kick(sub *subscriber, err error) {
+	sub.why = err
+	close(sub.kicked)
+	delete(s.subs, sub)
+}
+
+// subscribe implements streamServer.
+func (s *Server) subscribe(req request, stream grpc.ServerStream) error {
+	f, err := newFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sub := &subscriber{
+		filter: f,
+		queue:  make(chan item, s.queueSize),
+		kicked: make(chan struct{}),
+	}
+	start, replay, err := s.add(sub, req.resumeToken)
+	if err != nil {
+		return err
+	}
+	defer s.remove(sub)
+
+	sub.sent = start
+	if err := stream.SendMsg(streamBatch{resumeToken: s.token(start)}.toProto()); err != nil {
+		return err
+	}
+	for _, it := range replay {
+		if err := s.send(stream, sub, it); err != nil {
+			return err
+		}
+	}
+
+	ctx := stream.Context()
+	for {
+		// Kicks are checked first, so a kicked subscriber does not carry on with what is queued.
+		select {
+		case <-sub.kicked:
+			return sub.why
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-sub.kicked:
+			return sub.why
+		case it := <-sub.queue:
+			if err := s.send(stream, sub, it); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// add adds sub to the subscribers. If token is set, it returns the history after it for the subscriber to
+// be sent first. It returns the sequence number the subscription starts at.
+func (s *Server) add(sub *subscriber, token string) (uint64, []item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return 0, nil, status.Error(codes.Unavailable, "server is stopping")
+	}
+
+	start := s.seq
+	var replay []item
+	if token != "" {
+		seq, err := s.parseToken(token)
+		if err != nil {
+			return 0, nil, err
+		}
+		// The history must hold everything after seq.
+		oldest := s.seq + 1
+		if len(s.history) > 0 {
+			oldest = s.history[0].seq
+		}
+		if seq+1 < oldest {
+			return 0, nil, status.Errorf(codes.OutOfRange, "resume token %s is older than the history", token)
+		}
+		for _, it := range s.history {
+			if it.seq > seq {
+				replay = append(replay, it)
+			}
+		}
+		start = seq
+	}
+
+	s.subs[sub] = struct{}{}
+	return start, replay, nil
+}
+
+// remove removes sub from the subscribers.
+func (s *Server) remove(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subs, sub)
+}
+
+// send sends the entries in it that match the subscriber's filter. If none match, nothing is sent unless the
+// subscriber's last token is half the history old, when the token for it is sent without a batch.
+func (s *Server) send(stream grpc.ServerStream, sub *subscriber, it item) error {
+	b := sub.filter.apply(it.batches)
+	if len(b) == 0 {
+		if s.historySize == 0 || it.seq-sub.sent < max(uint64(s.historySize/2), 1) {
+			return nil
+		}
+		sub.sent = it.seq
+		return stream.SendMsg(streamBatch{resumeToken: s.token(it.seq)}.toProto())
+	}
+	enc, err := wire.MarshalBatches(wire.FProto, b)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("grpcstream: could not encode batch %d: %s", it.seq, err))
+		return status.Errorf(codes.Internal, "could not encode batch: %s", err)
+	}
+	sub.sent = it.seq
+	return stream.SendMsg(streamBatch{batch: enc, resumeToken: s.token(it.seq)}.toProto())
+}
+
+// token returns the resume token for after seq.
+func (s *Server) token(seq uint64) string {
+	return s.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+// parseToken returns the sequence number in a token. s.mu must be held.
+func (s *Server) parseToken(token string) (uint64, error) {
+	epoch, seqStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid resume token %q", token)
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid resume token %q", token)
+	}
+	if epoch != s.epoch {
+		return 0, status.Errorf(codes.OutOfRange, "resume token %s is from another run of the server", token)
+	}
+	if seq > s.seq {
+		return 0, status.Errorf(codes.InvalidArgument, "resume token %s is ahead of the server", token)
+	}
+	return seq, nil
+}
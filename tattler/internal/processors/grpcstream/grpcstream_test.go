@@ -0,0 +1,370 @@
+package grpcstream
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/dynamicpb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testBatches returns a Batches with a pod and a node entry for each namespace, with sequence numbers
+// starting after seq.
+func testBatches(seq uint64, namespaces ...string) batching.Batches {
+	batch := batching.Batch{}
+	add := func(i data.Informer) {
+		seq++
+		e := data.MustNewEntry(i)
+		e.Seq = seq
+		batch[e.UID()] = e
+	}
+	for _, ns := range namespaces {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", seq+1),
+				Namespace: ns,
+				UID:       types.UID(fmt.Sprintf("pod-%d", seq+1)),
+			},
+		}
+		add(data.MustNewInformer(data.MustNewChange(pod, nil, data.CTAdd)))
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("node-%d", seq+1),
+				UID:  types.UID(fmt.Sprintf("node-%d", seq+1)),
+			},
+		}
+		add(data.MustNewInformer(data.MustNewChange(node, nil, data.CTAdd)))
+	}
+	return batching.Batches{data.ETInformer: batch}
+}
+
+// seqs returns the sequence numbers of the entries in b, sorted.
+func seqs(b batching.Batches) []uint64 {
+	var out []uint64
+	for _, batch := range b {
+		for _, e := range batch {
+			out = append(out, e.Seq)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// setup starts a Server on an in memory listener and returns a Client connected to it.
+func setup(t *testing.T, options ...Option) (*Server, chan batching.Batches, *Client) {
+	t.Helper()
+
+	in := make(chan batching.Batches)
+	s, err := New(context.Background(), in, options...)
+	if err != nil {
+		t.Fatalf("New(): got err == %s, want err == nil", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	s.Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	cc, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+
+	return s, in, NewClient(cc)
+}
+
+// waitSubscribers waits for s to have n subscribers.
+func waitSubscribers(t *testing.T, s *Server, n int) {
+	t.Helper()
+
+	for i := 0; i < 500; i++ {
+		if s.Subscribers() == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("got %d subscribers, want %d", s.Subscribers(), n)
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	b := testBatches(0, "a", "b")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []uint64
+	}{
+		{name: "Everything", want: []uint64{1, 2, 3, 4}},
+		{name: "Namespace", filter: Filter{Namespaces: []string{"b"}}, want: []uint64{3}},
+		{name: "Cluster scoped", filter: Filter{Namespaces: []string{""}}, want: []uint64{2, 4}},
+		{name: "Object type", filter: Filter{ObjectTypes: []data.ObjectType{data.OTNode}}, want: []uint64{2, 4}},
+		{name: "Entry type", filter: Filter{EntryTypes: []data.EntryType{data.ETEvent}}, want: nil},
+		{
+			name:   "All fields",
+			filter: Filter{EntryTypes: []data.EntryType{data.ETInformer}, ObjectTypes: []data.ObjectType{data.OTPod}, Namespaces: []string{"a"}},
+			want:   []uint64{1},
+		},
+	}
+
+	for _, test := range tests {
+		f, err := newFilter(test.filter.toRequest(""))
+		if err != nil {
+			t.Errorf("TestFilter(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if diff := pretty.Compare(test.want, seqs(f.apply(b))); diff != "" {
+			t.Errorf("TestFilter(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+
+	if _, err := newFilter(request{entryTypes: []string{"NotAType"}}); err == nil {
+		t.Errorf("TestFilter(unknown entry type): got err == nil, want err != nil")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	s, in, client := setup(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := client.Subscribe(ctx, Filter{Namespaces: []string{"a"}}, "")
+	if err != nil {
+		t.Fatalf("TestSubscribe: got err == %s, want err == nil", err)
+	}
+	waitSubscribers(t, s, 1)
+
+	in <- testBatches(0, "a", "b")
+	// Nothing matches, so nothing is sent.
+	in <- testBatches(4, "b")
+	in <- testBatches(6, "a")
+
+	for _, want := range [][]uint64{{1}, {7}} {
+		b, err := sub.Recv()
+		if err != nil {
+			t.Fatalf("TestSubscribe: got err == %s, want err == nil", err)
+		}
+		if diff := pretty.Compare(want, seqs(b)); diff != "" {
+			t.Errorf("TestSubscribe: -want/+got:\n%s", diff)
+		}
+	}
+	if want := s.token(3); sub.Token() != want {
+		t.Errorf("TestSubscribe: got token %q, want %q", sub.Token(), want)
+	}
+
+	close(in)
+	if _, err := sub.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("TestSubscribe(stop): got err == %v, want code %s", err, codes.Unavailable)
+	}
+}
+
+func TestResume(t *testing.T) {
+	t.Parallel()
+
+	s, in, client := setup(t, WithHistory(2))
+	defer close(in)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := client.Subscribe(ctx, Filter{}, "")
+	if err != nil {
+		t.Fatalf("TestResume: got err == %s, want err == nil", err)
+	}
+	waitSubscribers(t, s, 1)
+
+	in <- testBatches(0, "a")
+	if _, err := sub.Recv(); err != nil {
+		t.Fatalf("TestResume: got err == %s, want err == nil", err)
+	}
+	token := sub.Token()
+	cancel()
+	waitSubscribers(t, s, 0)
+
+	in <- testBatches(2, "a")
+	in <- testBatches(4, "a")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	sub, err = client.Subscribe(ctx, Filter{}, token)
+	if err != nil {
+		t.Fatalf("TestResume: got err == %s, want err == nil", err)
+	}
+	for _, want := range [][]uint64{{3, 4}, {5, 6}} {
+		b, err := sub.Recv()
+		if err != nil {
+			t.Fatalf("TestResume: got err == %s, want err == nil", err)
+		}
+		if diff := pretty.Compare(want, seqs(b)); diff != "" {
+			t.Errorf("TestResume: -want/+got:\n%s", diff)
+		}
+	}
+
+	// The history only holds 2 Batches, so the first token can no longer be resumed from.
+	in <- testBatches(6, "a")
+	if _, err := sub.Recv(); err != nil {
+		t.Fatalf("TestResume: got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  codes.Code
+	}{
+		{name: "Too old", token: token, want: codes.OutOfRange},
+		{name: "Other epoch", token: "0000000000000000.1", want: codes.OutOfRange},
+		{name: "Ahead", token: s.token(100), want: codes.InvalidArgument},
+		{name: "Malformed", token: "nope", want: codes.InvalidArgument},
+	}
+	for _, test := range tests {
+		sub, err := client.Subscribe(ctx, Filter{}, test.token)
+		if err == nil {
+			_, err = sub.Recv()
+		}
+		if status.Code(err) != test.want {
+			t.Errorf("TestResume(%s): got err == %v, want code %s", test.name, err, test.want)
+		}
+	}
+}
+
+// recordStream is a grpc.ServerStream that records the messages sent on it.
+type recordStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan streamBatch
+}
+
+func (r *recordStream) Context() context.Context {
+	return r.ctx
+}
+
+func (r *recordStream) SendMsg(m any) error {
+	r.sent <- streamBatchFromProto(m.(*dynamicpb.Message))
+	return nil
+}
+
+func TestResumeRareFilter(t *testing.T) {
+	t.Parallel()
+
+	s, in, client := setup(t, WithHistory(4))
+	defer close(in)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &recordStream{ctx: ctx, sent: make(chan streamBatch, 20)}
+	done := make(chan error, 1)
+	go func() { done <- s.subscribe(Filter{Namespaces: []string{"rare"}}.toRequest(""), stream) }()
+	waitSubscribers(t, s, 1)
+
+	// Nothing matches, so only a token is sent for every 2 Batches, half the history.
+	for i := 0; i < 6; i++ {
+		in <- testBatches(uint64(i*2), "a")
+	}
+	var tokens []string
+	timeout := time.After(5 * time.Second)
+	for len(tokens) < 4 {
+		select {
+		case sb := <-stream.sent:
+			if len(sb.batch) != 0 {
+				t.Fatalf("TestResumeRareFilter: got a batch, want only tokens")
+			}
+			tokens = append(tokens, sb.resumeToken)
+		case <-timeout:
+			t.Fatalf("TestResumeRareFilter: timed out with %d tokens, want 4", len(tokens))
+		}
+	}
+	if diff := pretty.Compare([]string{s.token(0), s.token(2), s.token(4), s.token(6)}, tokens); diff != "" {
+		t.Errorf("TestResumeRareFilter: -want/+got:\n%s", diff)
+	}
+	cancel()
+	<-done
+
+	// The last token is still in the history, so resuming from it gets the next match.
+	in <- testBatches(12, "rare")
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := client.Subscribe(ctx, Filter{Namespaces: []string{"rare"}}, tokens[len(tokens)-1])
+	if err != nil {
+		t.Fatalf("TestResumeRareFilter: got err == %s, want err == nil", err)
+	}
+	b, err := sub.Recv()
+	if err != nil {
+		t.Fatalf("TestResumeRareFilter: got err == %s, want err == nil", err)
+	}
+	if diff := pretty.Compare([]uint64{13}, seqs(b)); diff != "" {
+		t.Errorf("TestResumeRareFilter: -want/+got:\n%s", diff)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan batching.Batches)
+	defer close(in)
+	s, err := New(context.Background(), in, WithQueueSize(1))
+	if err != nil {
+		t.Fatalf("TestSlowSubscriber: got err == %s, want err == nil", err)
+	}
+
+	sub := &subscriber{queue: make(chan item, 1), kicked: make(chan struct{})}
+	if _, _, err := s.add(sub, ""); err != nil {
+		t.Fatalf("TestSlowSubscriber: got err == %s, want err == nil", err)
+	}
+
+	// Nothing reads the queue, so the second Batches does not fit.
+	in <- testBatches(0, "a")
+	in <- testBatches(2, "a")
+
+	select {
+	case <-sub.kicked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestSlowSubscriber: subscriber was not disconnected")
+	}
+	if status.Code(sub.why) != codes.ResourceExhausted {
+		t.Errorf("TestSlowSubscriber: got err == %v, want code %s", sub.why, codes.ResourceExhausted)
+	}
+	if s.Subscribers() != 0 {
+		t.Errorf("TestSlowSubscriber: got %d subscribers, want 0", s.Subscribers())
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		in      chan batching.Batches
+		options []Option
+	}{
+		{name: "nil in"},
+		{name: "nil logger", in: make(chan batching.Batches), options: []Option{WithLogger(nil)}},
+		{name: "Bad queue size", in: make(chan batching.Batches), options: []Option{WithQueueSize(0)}},
+		{name: "Bad history", in: make(chan batching.Batches), options: []Option{WithHistory(-1)}},
+	}
+	for _, test := range tests {
+		if _, err := New(context.Background(), test.in, test.options...); err == nil {
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package grpcstream
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// ServiceName is the full name of the gRPC service.
+	ServiceName = "tattler.stream.v1.Stream"
+	// subscribeMethod is the full method name of Subscribe.
+	subscribeMethod = "/" + ServiceName + "/Subscribe"
+)
+
+// The descriptors of the schema in the package documentation. They are built here rather than generated,
+// and messages are dynamicpb.Messages, so that the standard gRPC proto codec can be used.
+var (
+	subscribeRequestDesc protoreflect.MessageDescriptor
+	streamBatchDesc      protoreflect.MessageDescriptor
+
+	reqEntryTypes  protoreflect.FieldDescriptor
+	reqObjectTypes protoreflect.FieldDescriptor
+	reqNamespaces  protoreflect.FieldDescriptor
+	reqResumeToken protoreflect.FieldDescriptor
+
+	batchBatch       protoreflect.FieldDescriptor
+	batchResumeToken protoreflect.FieldDescriptor
+)
+
+func init() {
+	fd, err := protodesc.NewFile(fileDescriptor(), nil)
+	if err != nil {
+		panic(fmt.Sprintf("grpcstream: invalid schema: %s", err))
+	}
+	subscribeRequestDesc = fd.Messages().ByName("SubscribeRequest")
+	streamBatchDesc = fd.Messages().ByName("StreamBatch")
+
+	reqEntryTypes = subscribeRequestDesc.Fields().ByName("entry_types")
+	reqObjectTypes = subscribeRequestDesc.Fields().ByName("object_types")
+	reqNamespaces = subscribeRequestDesc.Fields().ByName("namespaces")
+	reqResumeToken = subscribeRequestDesc.Fields().ByName("resume_token")
+
+	batchBatch = streamBatchDesc.Fields().ByName("batch")
+	batchResumeToken = streamBatchDesc.Fields().ByName("resume_token")
+}
+
+func fileDescriptor() *descriptorpb.FileDescriptorProto {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+	}
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("tattler/stream/v1/stream.proto"),
+		Package: proto.String("tattler.stream.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("SubscribeRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("entry_types", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, true),
+					field("object_types", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT32, true),
+					field("namespaces", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, true),
+					field("resume_token", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, false),
+				},
+			},
+			{
+				Name: proto.String("StreamBatch"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("batch", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES, false),
+					field("resume_token", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, false),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Stream"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:            proto.String("Subscribe"),
+						InputType:       proto.String(".tattler.stream.v1.SubscribeRequest"),
+						OutputType:      proto.String(".tattler.stream.v1.StreamBatch"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+}
+
+// request is a SubscribeRequest.
+type request struct {
+	entryTypes  []string
+	objectTypes []uint32
+	namespaces  []string
+	resumeToken string
+}
+
+func (r request) toProto() *dynamicpb.Message {
+	m := dynamicpb.NewMessage(subscribeRequestDesc)
+	l := m.Mutable(reqEntryTypes).List()
+	for _, s := range r.entryTypes {
+		l.Append(protoreflect.ValueOfString(s))
+	}
+	l = m.Mutable(reqObjectTypes).List()
+	for _, v := range r.objectTypes {
+		l.Append(protoreflect.ValueOfUint32(v))
+	}
+	l = m.Mutable(reqNamespaces).List()
+	for _, s := range r.namespaces {
+		l.Append(protoreflect.ValueOfString(s))
+	}
+	if r.resumeToken != "" {
+		m.Set(reqResumeToken, protoreflect.ValueOfString(r.resumeToken))
+	}
+	return m
+}
+
+func requestFromProto(m *dynamicpb.Message) request {
+	var r request
+	l := m.Get(reqEntryTypes).List()
+	for i := 0; i < l.Len(); i++ {
+		r.entryTypes = append(r.entryTypes, l.Get(i).String())
+	}
+	l = m.Get(reqObjectTypes).List()
+	for i := 0; i < l.Len(); i++ {
+		r.objectTypes = append(r.objectTypes, uint32(l.Get(i).Uint()))
+	}
+	l = m.Get(reqNamespaces).List()
+	for i := 0; i < l.Len(); i++ {
+		r.namespaces = append(r.namespaces, l.Get(i).String())
+	}
+	r.resumeToken = m.Get(reqResumeToken).String()
+	return r
+}
+
+// streamBatch is a StreamBatch.
+type streamBatch struct {
+	batch       []byte
+	resumeToken string
+}
+
+func (b streamBatch) toProto() *dynamicpb.Message {
+	m := dynamicpb.NewMessage(streamBatchDesc)
+	if b.batch != nil {
+		m.Set(batchBatch, protoreflect.ValueOfBytes(b.batch))
+	}
+	m.Set(batchResumeToken, protoreflect.ValueOfString(b.resumeToken))
+	return m
+}
+
+func streamBatchFromProto(m *dynamicpb.Message) streamBatch {
+	return streamBatch{
+		batch:       m.Get(batchBatch).Bytes(),
+		resumeToken: m.Get(batchResumeToken).String(),
+	}
+}
@@ -0,0 +1,517 @@
+/*
+Package webhook provides a processor that POSTs each batching.Batches it receives to an HTTP endpoint.
+
+Batches are encoded with the wire package, or as a CloudEvents batch. A batch whose encoding is larger than
+the maximum payload size is split across requests. Failed requests are retried with exponential backoff and
+jitter, waiting as long as the endpoint asks with Retry-After up to the maximum backoff. A batch that cannot
+be delivered is logged and dropped, as the processor must keep up with the pipeline.
+
+Usage:
+
+	in := make(chan batching.Batches, 10)
+	_, err := webhook.New(
+		ctx,
+		in,
+		"https://audit.example.com/tattler",
+		webhook.WithEncoding(webhook.EProto),
+		webhook.WithGzip(),
+		webhook.WithHMAC(key),
+	)
+	if err != nil {
+		// Do something
+	}
+	if err := runner.AddProcessor(ctx, "webhook", in); err != nil {
+		// Do something
+	}
+
+The processor stops when in is closed.
+
+With WithHMAC(), requests have these headers, which Verify() checks:
+
+	X-Tattler-Timestamp: the Unix time in seconds the request was signed
+	X-Tattler-Signature: sha256=<hex HMAC-SHA256 of the timestamp, a ".", and the body as sent>
+
+Every request also has an X-Tattler-Delivery header, which is the same for all attempts to send the same
+payload, so endpoints can ignore a retry of a payload they already accepted.
+*/
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire/cloudevents"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	// HeaderTimestamp is the header holding the time a request was signed.
+	HeaderTimestamp = "X-Tattler-Timestamp"
+	// HeaderSignature is the header holding the signature of a request.
+	HeaderSignature = "X-Tattler-Signature"
+	// HeaderDelivery is the header holding the ID of a payload, which is the same for every attempt.
+	HeaderDelivery = "X-Tattler-Delivery"
+
+	// signaturePrefix is the prefix of the HeaderSignature value, naming the algorithm.
+	signaturePrefix = "sha256="
+)
+
+var (
+	// ErrTooLarge is returned when a single entry encodes to more than the maximum payload size. The entry
+	// is not sent, the rest of its Batches is.
+	ErrTooLarge = errors.New("entry is larger than the maximum payload size")
+	// ErrEncode is returned when a single entry cannot be encoded, such as a registered type without an
+	// Encoder. The entry is not sent, the rest of its Batches is.
+	ErrEncode = errors.New("entry cannot be encoded")
+	// ErrSignature is returned by Verify() when a request is not correctly signed.
+	ErrSignature = errors.New("invalid request signature")
+)
+
+//go:generate stringer -type=Encoding -linecomment
+
+// Encoding is how Batches are encoded in a request body.
+type Encoding uint8
+
+const (
+	// EUnknown indicates a bug in the code.
+	EUnknown Encoding = 0 // Unknown
+	// EJSON is wire.MarshalBatches() with wire.FJSON.
+	EJSON Encoding = 1 // JSON
+	// EProto is wire.MarshalBatches() with wire.FProto.
+	EProto Encoding = 2 // Proto
+	// ECloudEvents is a CloudEvents batch, with each entry's data in wire.FJSON.
+	ECloudEvents Encoding = 3 // CloudEvents
+)
+
+// Processor POSTs Batches to an HTTP endpoint.
+type Processor struct {
+	in     chan batching.Batches
+	url    string
+	client *http.Client
+	ce     *cloudevents.Encoder
+
+	encoding   Encoding
+	maxPayload int
+	gzip       bool
+	hmacKey    []byte
+
+	attempts   int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	log *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Processor) error
+
+// WithLogger sets the logger. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(p *Processor) error {
+		if l == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		p.log = l
+		return nil
+	}
+}
+
+// WithClient sets the HTTP client. Defaults to a client with a 30 second timeout.
+func WithClient(c *http.Client) Option {
+	return func(p *Processor) error {
+		if c == nil {
+			return fmt.Errorf("client cannot be nil")
+		}
+		p.client = c
+		return nil
+	}
+}
+
+// WithEncoding sets how Batches are encoded. Defaults to EJSON.
+func WithEncoding(e Encoding) Option {
+	return func(p *Processor) error {
+		switch e {
+		case EJSON, EProto, ECloudEvents:
+		default:
+			return fmt.Errorf("unsupported encoding %v", e)
+		}
+		p.encoding = e
+		return nil
+	}
+}
+
+// WithMaxPayload sets the maximum size of a request body in bytes, after compression. Batches larger than
+// this are split across requests. Defaults to no maximum.
+func WithMaxPayload(size int) Option {
+	return func(p *Processor) error {
+		if size <= 0 {
+			return fmt.Errorf("max payload must be > 0")
+		}
+		p.maxPayload = size
+		return nil
+	}
+}
+
+// WithGzip gzip compresses request bodies.
+func WithGzip() Option {
+	return func(p *Processor) error {
+		p.gzip = true
+		return nil
+	}
+}
+
+// WithRetry sets how many attempts are made to send a payload and the range of the backoff between them.
+// The backoff starts at min and doubles on each attempt up to max, with jitter. A Retry-After from the
+// endpoint is used instead, but is also limited to max. Defaults to 5 attempts from 500ms to 30s.
+func WithRetry(attempts int, min, max time.Duration) Option {
+	return func(p *Processor) error {
+		switch {
+		case attempts < 1:
+			return fmt.Errorf("attempts must be >= 1")
+		case min <= 0 || max < min:
+			return fmt.Errorf("backoff must have 0 < min <= max")
+		}
+		p.attempts = attempts
+		p.minBackoff = min
+		p.maxBackoff = max
+		return nil
+	}
+}
+
+// WithHMAC signs requests with an HMAC-SHA256 of key. See the package documentation for the headers.
+func WithHMAC(key []byte) Option {
+	return func(p *Processor) error {
+		if len(key) == 0 {
+			return fmt.Errorf("HMAC key cannot be empty")
+		}
+		p.hmacKey = key
+		return nil
+	}
+}
+
+// New creates a new Processor that sends Batches received on in to endpoint until in is closed.
+func New(ctx context.Context, in chan batching.Batches, endpoint string, options ...Option) (*Processor, error) {
+	if in == nil {
+		return nil, fmt.Errorf("webhook.New: input channel cannot be nil")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("webhook.New: invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook.New: endpoint must be http or https, got %q", endpoint)
+	}
+
+	ce, err := cloudevents.New()
+	if err != nil {
+		return nil, err
+	}
+	p := &Processor{
+		in:         in,
+		url:        endpoint,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		ce:         ce,
+		encoding:   EJSON,
+		attempts:   5,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		log:        slog.Default(),
+	}
+	for _, o := range options {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+
+	go p.run(ctx)
+
+	return p, nil
+}
+
+// run sends each Batches received until p.in is closed.
+func (p *Processor) run(ctx context.Context) {
+	for batches := range p.in {
+		if err := p.send(ctx, batches); err != nil {
+			p.log.Error(fmt.Sprintf("webhook(%s): %s", p.url, err))
+		}
+	}
+}
+
+// send sends batches, split into as many requests as are needed to keep under the maximum payload size.
+// The error covers every entry that was skipped and every payload that could not be delivered.
+func (p *Processor) send(ctx context.Context, batches batching.Batches) error {
+	entries := wire.Entries(batches)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	payloads, skipped, err := p.payloads(entries)
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		p.log.Error(fmt.Sprintf("webhook(%s): skipping entry: %s", p.url, err))
+	}
+	// A payload failing does not mean the others will, so every payload is tried.
+	errs := skipped
+	failed := 0
+	for _, pl := range payloads {
+		if err := p.post(ctx, pl); err != nil {
+			failed++
+			errs = append(errs, err)
+		}
+	}
+	if failed > 0 {
+		errs = append([]error{fmt.Errorf("%d of %d payloads failed", failed, len(payloads))}, errs...)
+	}
+	return errors.Join(errs...)
+}
+
+// payload is an encoded request body.
+type payload struct {
+	body        []byte
+	contentType string
+}
+
+// payloads encodes entries into bodies no larger than the maximum payload size. Entries that do not fit in
+// one body, or that fail to encode, are split in half until they do. A single entry that is too large on its
+// own, or cannot be encoded, is left out with an error wrapping ErrTooLarge or ErrEncode in skipped.
+func (p *Processor) payloads(entries []data.Entry) (pls []payload, skipped []error, err error) {
+	pl, err := p.encode(entries)
+	switch {
+	case errors.Is(err, ErrEncode):
+		if len(entries) == 1 {
+			e := entries[0]
+			return nil, []error{fmt.Errorf("%v %s: %w", e.Type, e.UID(), err)}, nil
+		}
+	case err != nil:
+		return nil, nil, err
+	case p.maxPayload == 0 || len(pl.body) <= p.maxPayload:
+		return []payload{pl}, nil, nil
+	case len(entries) == 1:
+		e := entries[0]
+		return nil, []error{fmt.Errorf("%w: %v %s is %d bytes", ErrTooLarge, e.Type, e.UID(), len(pl.body))}, nil
+	}
+
+	half := len(entries) / 2
+	for _, part := range [][]data.Entry{entries[:half], entries[half:]} {
+		partPls, partSkipped, err := p.payloads(part)
+		if err != nil {
+			return nil, nil, err
+		}
+		pls = append(pls, partPls...)
+		skipped = append(skipped, partSkipped...)
+	}
+	return pls, skipped, nil
+}
+
+// encode encodes entries as a request body. Errors encoding the entries wrap ErrEncode.
+func (p *Processor) encode(entries []data.Entry) (payload, error) {
+	b := batching.Batches{}
+	for _, e := range entries {
+		if b[e.Type] == nil {
+			b[e.Type] = batching.Batch{}
+		}
+		b[e.Type][e.UID()] = e
+	}
+
+	var pl payload
+	switch p.encoding {
+	case EJSON, EProto:
+		f := wire.FJSON
+		if p.encoding == EProto {
+			f = wire.FProto
+		}
+		body, err := wire.MarshalBatches(f, b)
+		if err != nil {
+			return payload{}, fmt.Errorf("%w: %w", ErrEncode, err)
+		}
+		pl = payload{body: body, contentType: f.ContentType()}
+	case ECloudEvents:
+		msgs, err := p.ce.Batches(cloudevents.MBatch, b)
+		if err != nil {
+			return payload{}, fmt.Errorf("%w: %w", ErrEncode, err)
+		}
+		pl = payload{body: msgs[0].Body, contentType: msgs[0].Header.Get("Content-Type")}
+	default:
+		return payload{}, fmt.Errorf("unknown Encoding: %v", p.encoding)
+	}
+
+	if p.gzip {
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(pl.body); err != nil {
+			return payload{}, err
+		}
+		if err := w.Close(); err != nil {
+			return payload{}, err
+		}
+		pl.body = buf.Bytes()
+	}
+	return pl, nil
+}
+
+// post sends pl, retrying until it succeeds, an attempt fails in a way that will not be fixed by retrying,
+// or the attempts run out.
+func (p *Processor) post(ctx context.Context, pl payload) error {
+	delivery := string(uuid.NewUUID())
+
+	var err error
+	for attempt := 0; attempt < p.attempts; attempt++ {
+		var retryAfter time.Duration
+		var retry bool
+		retryAfter, retry, err = p.attempt(ctx, pl, delivery)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == p.attempts-1 {
+			break
+		}
+
+		t := time.NewTimer(p.wait(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return err
+}
+
+// attempt makes one request. It returns the wait the endpoint asked for with Retry-After, if any, and if
+// the request should be retried.
+func (p *Processor) attempt(ctx context.Context, pl payload, delivery string) (time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(pl.body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", pl.contentType)
+	req.Header.Set(HeaderDelivery, delivery)
+	if p.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if p.hmacKey != nil {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, signaturePrefix+Sign(p.hmacKey, ts, pl.body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// The context being done is not going to get better.
+		return 0, ctx.Err() == nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, false, nil
+	}
+	err = fmt.Errorf("endpoint returned %s", resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusRequestTimeout:
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+	default:
+		return 0, false, err
+	}
+	return retryAfter(resp.Header.Get("Retry-After"), time.Now()), true, err
+}
+
+// backoff returns the wait after attempt, which starts at 0. This is the exponential backoff with jitter
+// of up to half of it.
+func (p *Processor) backoff(attempt int) time.Duration {
+	d := p.minBackoff
+	for i := 0; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// wait returns how long to wait before the attempt after attempt. That is retryAfter if the endpoint set
+// it, but no more than the maximum backoff, so an endpoint cannot stall the processor while the router drops
+// the Batches sent to it.
+func (p *Processor) wait(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, p.maxBackoff)
+	}
+	return p.backoff(attempt)
+}
+
+// retryAfter returns the wait in a Retry-After header value, which is seconds or an HTTP date. It returns 0
+// if v is empty or invalid.
+func retryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of body sent at timestamp, as put in HeaderSignature
+// after "sha256=".
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers in h are for body and key, and that the request was signed within
+// maxSkew of now. body is the body as received, before any gzip decompression. Endpoints use this to
+// authenticate requests. A maxSkew of 0 does not check the time.
+func Verify(h http.Header, body, key []byte, maxSkew time.Duration, now time.Time) error {
+	ts := h.Get(HeaderTimestamp)
+	sig, ok := strings.CutPrefix(h.Get(HeaderSignature), signaturePrefix)
+	if ts == "" || !ok {
+		return fmt.Errorf("%w: missing %s or %s", ErrSignature, HeaderTimestamp, HeaderSignature)
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not hex", ErrSignature, HeaderSignature)
+	}
+	want, _ := hex.DecodeString(Sign(key, ts, body))
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("%w: signature does not match", ErrSignature)
+	}
+
+	if maxSkew > 0 {
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s is not a Unix time", ErrSignature, HeaderTimestamp)
+		}
+		if d := now.Sub(time.Unix(secs, 0)); d > maxSkew || d < -maxSkew {
+			return fmt.Errorf("%w: signed %s from now", ErrSignature, d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,542 @@
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire/cloudevents"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// request is a request received by a server.
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// server is an httptest.Server that records requests and replies with the next status in statuses, then
+// 200 once they run out.
+type server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []request
+	statuses []int
+	header   http.Header
+}
+
+func newServer(statuses ...int) *server {
+	s := &server{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, request{header: r.Header.Clone(), body: b})
+		if len(s.statuses) == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		for k, v := range s.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(s.statuses[0])
+		s.statuses = s.statuses[1:]
+	}))
+	return s
+}
+
+func (s *server) received() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request{}, s.requests...)
+}
+
+// testBatches returns a Batches of n entries as the batcher hands them to a processor, alternating pod
+// updates from the informers reader with events from the events reader.
+func testBatches(n int) batching.Batches {
+	observed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	b := batching.Batches{}
+	for i := 0; i < n; i++ {
+		var e data.Entry
+		if i%2 == 0 {
+			pod := func(rv string, phase corev1.PodPhase) *corev1.Pod {
+				return &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            fmt.Sprintf("pod-%d", i),
+						Namespace:       "default",
+						UID:             types.UID(fmt.Sprintf("pod-uid-%d", i)),
+						ResourceVersion: rv,
+					},
+					Spec:   corev1.PodSpec{NodeName: "node-1"},
+					Status: corev1.PodStatus{Phase: phase},
+				}
+			}
+			e = data.MustNewEntry(data.MustNewInformer(data.MustNewChange(pod("2", corev1.PodRunning), pod("1", corev1.PodPending), data.CTUpdate)))
+			e.Reader = "informers"
+		} else {
+			ev := data.MustNewEvent(data.MustNewChange(
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d.17a0", i-1), Namespace: "default", UID: types.UID(fmt.Sprintf("event-uid-%d", i))},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: fmt.Sprintf("pod-%d", i-1)},
+					Reason:         "Started",
+					Message:        "Started container web",
+					Type:           corev1.EventTypeNormal,
+				},
+				nil,
+				data.CTAdd,
+			))
+			ev.Count = 1
+			ev.FirstTimestamp = observed
+			ev.LastTimestamp = observed
+			e = data.MustNewEntry(ev)
+			e.Reader = "events"
+		}
+		e.Origin = data.ORWatch
+		e.Observed = observed
+		e.Seq = uint64(i + 1)
+		if b[e.Type] == nil {
+			b[e.Type] = batching.Batch{}
+		}
+		b[e.Type][e.UID()] = e
+	}
+	return b
+}
+
+// unencodable is a SourceData registered without an Encoder, which cannot be sent.
+type unencodable struct {
+	uid types.UID
+}
+
+func (u unencodable) GetUID() types.UID      { return u.uid }
+func (u unencodable) Object() runtime.Object { return nil }
+
+var etUnencodable = data.MustRegister(data.Registration{Name: "WebhookUnencodable", Sample: unencodable{}})
+
+func newProcessor(t *testing.T, url string, options ...Option) *Processor {
+	t.Helper()
+
+	options = append([]Option{WithRetry(3, time.Millisecond, 5*time.Millisecond)}, options...)
+	p, err := New(context.Background(), make(chan batching.Batches), url, options...)
+	if err != nil {
+		t.Fatalf("New(): got err == %s, want err == nil", err)
+	}
+	return p
+}
+
+// decode decodes the entries in a request body sent with encoding.
+func decode(t *testing.T, e Encoding, r request) []data.Entry {
+	t.Helper()
+
+	body := r.body
+	if r.header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("gzip.NewReader(): %s", err)
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			t.Fatalf("gzip read: %s", err)
+		}
+	}
+
+	switch e {
+	case EJSON, EProto:
+		f := wire.FJSON
+		if e == EProto {
+			f = wire.FProto
+		}
+		b, err := wire.UnmarshalBatches(f, body)
+		if err != nil {
+			t.Fatalf("wire.UnmarshalBatches(): %s", err)
+		}
+		return wire.Entries(b)
+	case ECloudEvents:
+		events, err := cloudevents.Decode(r.header, body)
+		if err != nil {
+			t.Fatalf("cloudevents.Decode(): %s", err)
+		}
+		var entries []data.Entry
+		for _, ev := range events {
+			e, err := ev.Entry()
+			if err != nil {
+				t.Fatalf("Event.Entry(): %s", err)
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+	t.Fatalf("unknown encoding %v", e)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		encoding        Encoding
+		gzip            bool
+		wantContentType string
+	}{
+		{name: "JSON", encoding: EJSON, wantContentType: "application/json"},
+		{name: "Proto with gzip", encoding: EProto, gzip: true, wantContentType: "application/x-protobuf"},
+		{name: "CloudEvents", encoding: ECloudEvents, wantContentType: cloudevents.ContentTypeBatch},
+	}
+
+	batches := testBatches(3)
+	want := wire.Entries(batches)
+
+	for _, test := range tests {
+		srv := newServer()
+		defer srv.Close()
+
+		options := []Option{WithEncoding(test.encoding)}
+		if test.gzip {
+			options = append(options, WithGzip())
+		}
+		p := newProcessor(t, srv.URL, options...)
+
+		if err := p.send(context.Background(), batches); err != nil {
+			t.Errorf("TestSend(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		reqs := srv.received()
+		if len(reqs) != 1 {
+			t.Errorf("TestSend(%s): got %d requests, want 1", test.name, len(reqs))
+			continue
+		}
+		if got := reqs[0].header.Get("Content-Type"); got != test.wantContentType {
+			t.Errorf("TestSend(%s): got Content-Type %s, want %s", test.name, got, test.wantContentType)
+		}
+		if reqs[0].header.Get(HeaderDelivery) == "" {
+			t.Errorf("TestSend(%s): got no %s header, want one", test.name, HeaderDelivery)
+		}
+		if diff := pretty.Compare(want, decode(t, test.encoding, reqs[0])); diff != "" {
+			t.Errorf("TestSend(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestMaxPayload(t *testing.T) {
+	t.Parallel()
+
+	batches := testBatches(10)
+	// Room for about 3 of the largest entry, so 10 entries need at least 4 requests.
+	var max int
+	for _, e := range wire.Entries(batches) {
+		one, err := wire.MarshalBatches(wire.FJSON, batching.Batches{e.Type: batching.Batch{e.UID(): e}})
+		if err != nil {
+			t.Fatalf("TestMaxPayload: got err == %s, want err == nil", err)
+		}
+		if len(one)*3 > max {
+			max = len(one) * 3
+		}
+	}
+
+	srv := newServer()
+	defer srv.Close()
+	p := newProcessor(t, srv.URL, WithMaxPayload(max))
+
+	if err := p.send(context.Background(), batches); err != nil {
+		t.Fatalf("TestMaxPayload: got err == %s, want err == nil", err)
+	}
+	reqs := srv.received()
+	if len(reqs) < 4 {
+		t.Errorf("TestMaxPayload: got %d requests, want at least 4", len(reqs))
+	}
+	var got []data.Entry
+	for _, r := range reqs {
+		if len(r.body) > max {
+			t.Errorf("TestMaxPayload: got body of %d bytes, want <= %d", len(r.body), max)
+		}
+		got = append(got, decode(t, EJSON, r)...)
+	}
+	if diff := pretty.Compare(wire.Entries(batches), got); diff != "" {
+		t.Errorf("TestMaxPayload: -want/+got:\n%s", diff)
+	}
+
+	// The first payload is not delivered, the rest still are.
+	srv = newServer(http.StatusBadRequest)
+	defer srv.Close()
+	p = newProcessor(t, srv.URL, WithMaxPayload(max))
+	err := p.send(context.Background(), batches)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("1 of %d payloads failed", len(reqs))) {
+		t.Errorf("TestMaxPayload(payload failed): got err == %v, want 1 of %d payloads failed", err, len(reqs))
+	}
+	if got := len(srv.received()); got != len(reqs) {
+		t.Errorf("TestMaxPayload(payload failed): got %d requests, want %d", got, len(reqs))
+	}
+
+	// One entry in the batch is too large on its own, the others are still sent.
+	big := testBatches(5)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "big",
+			Namespace:   "default",
+			UID:         "uid-big",
+			Annotations: map[string]string{"big": strings.Repeat("x", max)},
+		},
+	}
+	e := data.MustNewEntry(data.MustNewInformer(data.MustNewChange(pod, nil, data.CTAdd)))
+	big[data.ETInformer][e.UID()] = e
+
+	srv = newServer()
+	defer srv.Close()
+	p = newProcessor(t, srv.URL, WithMaxPayload(max))
+	if err := p.send(context.Background(), big); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("TestMaxPayload(entry too large): got err == %v, want ErrTooLarge", err)
+	}
+	got = nil
+	for _, r := range srv.received() {
+		got = append(got, decode(t, EJSON, r)...)
+	}
+	if diff := pretty.Compare(wire.Entries(testBatches(5)), got); diff != "" {
+		t.Errorf("TestMaxPayload(entry too large): -want/+got:\n%s", diff)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	t.Parallel()
+
+	for _, enc := range []Encoding{EJSON, EProto, ECloudEvents} {
+		batches := testBatches(5)
+		e := data.MustNewEntry(unencodable{uid: "uid-unencodable"})
+		batches[etUnencodable] = batching.Batch{e.UID(): e}
+
+		srv := newServer()
+		defer srv.Close()
+		p := newProcessor(t, srv.URL, WithEncoding(enc))
+		if err := p.send(context.Background(), batches); !errors.Is(err, ErrEncode) || !errors.Is(err, wire.ErrEntryType) {
+			t.Errorf("TestEncodeError(%v): got err == %v, want ErrEncode and wire.ErrEntryType", enc, err)
+		}
+		var got []data.Entry
+		for _, r := range srv.received() {
+			got = append(got, decode(t, enc, r)...)
+		}
+		if diff := pretty.Compare(wire.Entries(testBatches(5)), got); diff != "" {
+			t.Errorf("TestEncodeError(%v): -want/+got:\n%s", enc, diff)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		header       http.Header
+		wantErr      bool
+		wantRequests int
+	}{
+		{
+			name:         "Retried until success",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusInternalServerError},
+			wantRequests: 3,
+		},
+		{
+			name:         "Retry-After of 0 seconds",
+			statuses:     []int{http.StatusTooManyRequests},
+			header:       http.Header{"Retry-After": {"0"}},
+			wantRequests: 2,
+		},
+		{
+			name:         "Retry-After of a day is limited to the maximum backoff",
+			statuses:     []int{http.StatusServiceUnavailable},
+			header:       http.Header{"Retry-After": {"86400"}},
+			wantRequests: 2,
+		},
+		{
+			name:         "Out of attempts",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{
+			name:         "Not retryable",
+			statuses:     []int{http.StatusBadRequest},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+	}
+
+	for _, test := range tests {
+		srv := newServer(test.statuses...)
+		srv.header = test.header
+		defer srv.Close()
+		p := newProcessor(t, srv.URL)
+
+		err := p.send(context.Background(), testBatches(1))
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRetry(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRetry(%s): got err == %s, want err == nil", test.name, err)
+		}
+		reqs := srv.received()
+		if len(reqs) != test.wantRequests {
+			t.Errorf("TestRetry(%s): got %d requests, want %d", test.name, len(reqs), test.wantRequests)
+			continue
+		}
+		for _, r := range reqs {
+			if r.header.Get(HeaderDelivery) != reqs[0].header.Get(HeaderDelivery) {
+				t.Errorf("TestRetry(%s): got different %s headers between attempts, want the same", test.name, HeaderDelivery)
+			}
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	p := &Processor{minBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 100 * time.Millisecond},
+		{attempt: 1, want: 200 * time.Millisecond},
+		{attempt: 3, want: 800 * time.Millisecond},
+		{attempt: 4, want: time.Second},
+		{attempt: 100, want: time.Second},
+	}
+	for _, test := range tests {
+		for i := 0; i < 10; i++ {
+			got := p.backoff(test.attempt)
+			if got < test.want/2 || got > test.want {
+				t.Errorf("TestBackoff(%d): got %s, want between %s and %s", test.attempt, got, test.want/2, test.want)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		v    string
+		want time.Duration
+	}{
+		{v: "", want: 0},
+		{v: "120", want: 2 * time.Minute},
+		{v: "-1", want: 0},
+		{v: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{v: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{v: "soon", want: 0},
+	}
+	for _, test := range tests {
+		if got := retryAfter(test.v, now); got != test.want {
+			t.Errorf("TestRetryAfter(%q): got %s, want %s", test.v, got, test.want)
+		}
+	}
+
+	p := &Processor{minBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	waits := []struct {
+		name       string
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{name: "Under the maximum", retryAfter: 500 * time.Millisecond, want: 500 * time.Millisecond},
+		{name: "Over the maximum", retryAfter: 24 * time.Hour, want: time.Second},
+		{name: "Far future date", retryAfter: retryAfter(now.AddDate(1, 0, 0).Format(http.TimeFormat), now), want: time.Second},
+	}
+	for _, test := range waits {
+		if got := p.wait(0, test.retryAfter); got != test.want {
+			t.Errorf("TestRetryAfter(%s): got wait %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+	srv := newServer()
+	defer srv.Close()
+	p := newProcessor(t, srv.URL, WithHMAC(key), WithGzip())
+
+	if err := p.send(context.Background(), testBatches(2)); err != nil {
+		t.Fatalf("TestHMAC: got err == %s, want err == nil", err)
+	}
+	r := srv.received()[0]
+	now := time.Now()
+
+	if err := Verify(r.header, r.body, key, time.Minute, now); err != nil {
+		t.Errorf("TestHMAC: got err == %s, want err == nil", err)
+	}
+	if err := Verify(r.header, r.body, []byte("other"), time.Minute, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("TestHMAC(wrong key): got err == %v, want ErrSignature", err)
+	}
+	if err := Verify(r.header, append(r.body, 0), key, time.Minute, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("TestHMAC(modified body): got err == %v, want ErrSignature", err)
+	}
+	if err := Verify(r.header, r.body, key, time.Minute, now.Add(time.Hour)); !errors.Is(err, ErrSignature) {
+		t.Errorf("TestHMAC(replayed): got err == %v, want ErrSignature", err)
+	}
+	if err := Verify(http.Header{}, r.body, key, 0, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("TestHMAC(unsigned): got err == %v, want ErrSignature", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer()
+	defer srv.Close()
+
+	in := make(chan batching.Batches, 1)
+	if _, err := New(context.Background(), in, srv.URL); err != nil {
+		t.Fatalf("TestRun: got err == %s, want err == nil", err)
+	}
+	in <- testBatches(1)
+	close(in)
+
+	for i := 0; i < 100 && len(srv.received()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(srv.received()); got != 1 {
+		t.Errorf("TestRun: got %d requests, want 1", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		in       chan batching.Batches
+		endpoint string
+		options  []Option
+	}{
+		{name: "nil in", endpoint: "http://localhost"},
+		{name: "Not http", in: make(chan batching.Batches), endpoint: "ftp://localhost"},
+		{name: "Bad encoding", in: make(chan batching.Batches), endpoint: "http://localhost", options: []Option{WithEncoding(EUnknown)}},
+		{name: "Bad retry", in: make(chan batching.Batches), endpoint: "http://localhost", options: []Option{WithRetry(1, time.Second, time.Millisecond)}},
+		{name: "Empty key", in: make(chan batching.Batches), endpoint: "http://localhost", options: []Option{WithHMAC(nil)}},
+	}
+	for _, test := range tests {
+		if _, err := New(context.Background(), test.in, test.endpoint, test.options...); err == nil {
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
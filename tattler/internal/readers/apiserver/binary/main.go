@@ -60,7 +60,8 @@ func main() {
 	// Setup reader for APIServer informers.
 	informerFactory := informers.NewSharedInformerFactory(clientset, 5*time.Second)
 
-	r, err := ireader.New(bkCtx, informerFactory, ireader.RTNode|ireader.RTPod|ireader.RTNamespace)
+	// The short resync fires an update for every object every 5 seconds, drop those that change nothing.
+	r, err := ireader.New(bkCtx, informerFactory, ireader.RTNode|ireader.RTPod|ireader.RTNamespace, ireader.WithoutResyncs())
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,516 @@
+/*
+Package events provides a reader for Kubernetes Events from the APIServer.
+
+Events such as FailedScheduling, OOMKilling and BackOff are repeated constantly. The Reader collapses
+repeated events, those with the same involved object, reason and message, that are seen within a
+deduplication window. A collapsed event is emitted with the same UID as the events it repeats, so the
+batcher will only keep the latest one. Each emitted data.Event carries the Count and LastTimestamp of all
+the events it represents.
+
+The deduplication window should be set to the batching timespan given to tattler.New().
+
+Usage:
+
+	informer := informers.NewSharedInformerFactory(clientset, time.Minute*10)
+
+	r, err := events.New(
+		ctx,
+		informer,
+		events.RTCoreV1,
+		events.WithDedupWindow(5*time.Second),
+		events.WithTypes(corev1.EventTypeWarning),
+	)
+	if err != nil {
+		// Do something
+	}
+	tattler.AddReader(ctx, r)
+*/
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RetrieveType is the API group to retrieve events from. Uses as a bitwise flag.
+type RetrieveType uint8
+
+const (
+	// RTCoreV1 retrieves core/v1 events.
+	RTCoreV1 RetrieveType = 0x1
+	// RTEventsV1 retrieves events.k8s.io/v1 events. Note that these are the same objects as core/v1
+	// events viewed through a different API, so you usually only want one of them.
+	RTEventsV1 RetrieveType = 0x2
+)
+
+// aggregate tracks the events seen for a deduplication key within the window.
+type aggregate struct {
+	// start is when the window for this key started.
+	start time.Time
+	// first is the earliest timestamp seen for this key.
+	first time.Time
+	// last is the latest timestamp seen for this key.
+	last time.Time
+	// counts holds the latest count for each event object with this key. Updates to an event
+	// object replace the count instead of adding to it.
+	counts map[types.UID]int32
+}
+
+// Reader reports Events from the APIServer, collapsing repeated events.
+type Reader struct {
+	informer informers.SharedInformerFactory
+	indexes  []cache.SharedIndexInformer
+	handlers cache.ResourceEventHandlerDetailedFuncs
+	syncers  []cache.InformerSynced
+
+	// dropInitialList, initialListOut and syncComplete control handling of the initial list.
+	dropInitialList bool
+	initialListOut  chan data.Entry
+	syncComplete    bool
+
+	window  time.Duration
+	types   map[string]bool
+	reasons map[string]bool
+
+	mu        sync.Mutex
+	seen      map[types.UID]*aggregate
+	lastSweep time.Time
+	now       func() time.Time
+
+	ch      chan data.Entry
+	stop    chan struct{}
+	started bool
+	log     *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Reader) error
+
+// WithLogger sets the logger for the Reader.
+func WithLogger(log *slog.Logger) Option {
+	return func(r *Reader) error {
+		r.log = log
+		return nil
+	}
+}
+
+// WithDedupWindow sets the window in which repeated events are collapsed. This should match the
+// batching timespan. Defaults to 5 seconds.
+func WithDedupWindow(d time.Duration) Option {
+	return func(r *Reader) error {
+		if d <= 0 {
+			return fmt.Errorf("dedup window must be > 0")
+		}
+		r.window = d
+		return nil
+	}
+}
+
+// WithTypes only emits events whose type is one of types, such as corev1.EventTypeWarning.
+// By default all types are emitted.
+func WithTypes(types ...string) Option {
+	return func(r *Reader) error {
+		for _, t := range types {
+			r.types[t] = true
+		}
+		return nil
+	}
+}
+
+// WithReasons only emits events whose reason is one of reasons, such as "FailedScheduling".
+// By default all reasons are emitted.
+func WithReasons(reasons ...string) Option {
+	return func(r *Reader) error {
+		for _, reason := range reasons {
+			r.reasons[reason] = true
+		}
+		return nil
+	}
+}
+
+// WithoutInitialList drops the adds the informers send for events that exist when the Reader starts, so
+// only events recorded while the Reader is running are emitted. Cannot be used with WithInitialListOut().
+func WithoutInitialList() Option {
+	return func(r *Reader) error {
+		r.dropInitialList = true
+		return nil
+	}
+}
+
+// WithInitialListOut sends the adds the informers send for events that exist when the Reader starts on
+// out instead of the output channel. out must be read from for Run() to complete. Cannot be used with
+// WithoutInitialList().
+func WithInitialListOut(out chan data.Entry) Option {
+	return func(r *Reader) error {
+		if out == nil {
+			return fmt.Errorf("initial list channel cannot be nil")
+		}
+		r.initialListOut = out
+		return nil
+	}
+}
+
+// WithSyncComplete emits a data.ETSyncComplete marker entry once all informers have sent their initial
+// list. It is sent on the output channel and, if set, the WithInitialListOut() channel.
+func WithSyncComplete() Option {
+	return func(r *Reader) error {
+		r.syncComplete = true
+		return nil
+	}
+}
+
+// New creates a new Reader. retrieveTypes is a bitwise flag to determine which APIs to retrieve events from.
+func New(ctx context.Context, informer informers.SharedInformerFactory, retrieveTypes RetrieveType, opts ...Option) (*Reader, error) {
+	if informer == nil {
+		return nil, fmt.Errorf("informer is nil")
+	}
+
+	r := &Reader{
+		informer: informer,
+		window:   5 * time.Second,
+		types:    map[string]bool{},
+		reasons:  map[string]bool{},
+		seen:     map[types.UID]*aggregate{},
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		log:      slog.Default(),
+	}
+	r.handlers = cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc:    r.addHandler,
+		UpdateFunc: r.updateHandler,
+		DeleteFunc: r.deleteHandler,
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.dropInitialList && r.initialListOut != nil {
+		return nil, fmt.Errorf("cannot use both WithoutInitialList() and WithInitialListOut()")
+	}
+
+	informs := []struct {
+		rt     RetrieveType
+		inform func() (cache.InformerSynced, error)
+	}{
+		{RTCoreV1, r.coreV1Inform},
+		{RTEventsV1, r.eventsV1Inform},
+	}
+
+	for _, i := range informs {
+		if retrieveTypes&i.rt != i.rt {
+			continue
+		}
+		s, err := i.inform()
+		if err != nil {
+			return nil, err
+		}
+		r.syncers = append(r.syncers, s)
+	}
+
+	if len(r.syncers) == 0 {
+		return nil, fmt.Errorf("no event types to retrieve")
+	}
+
+	return r, nil
+}
+
+var closeDelay = 100 * time.Millisecond
+
+// Close closes the Reader. This will block until all indexes are stopped.
+// If the context is canceled, it will return the context error.
+func (r *Reader) Close(ctx context.Context) error {
+	close(r.stop)
+	defer close(r.ch)
+
+start:
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, index := range r.indexes {
+		if !index.IsStopped() {
+			time.Sleep(closeDelay)
+			goto start
+		}
+	}
+	return nil
+}
+
+// readerName is the name of the Reader returned by Name().
+const readerName = "events"
+
+// Name returns the name of the Reader, which the tattler Runner puts in data.Entry.Reader.
+func (r *Reader) Name() string {
+	return readerName
+}
+
+// SetOut sets the output channel that the reader must output on. Must return an error and be a no-op
+// if Run() has been called.
+func (r *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
+	if r.started {
+		return fmt.Errorf("cannot call SetOut once the Reader has had Start() called")
+	}
+	r.ch = out
+	return nil
+}
+
+// Run starts the Reader processing. You may only call this once if Run() does not return an error.
+func (r *Reader) Run(ctx context.Context) error {
+	if r.started {
+		return fmt.Errorf("cannot call Run once the Reader has already started")
+	}
+	if r.ch == nil {
+		return fmt.Errorf("cannot call Run if SetOut has not been called")
+	}
+	r.informer.Start(r.stop)
+
+	if !cache.WaitForCacheSync(r.stop, r.syncers...) {
+		r.stop = make(chan struct{})
+		return fmt.Errorf("failed to sync cache")
+	}
+	r.started = true
+
+	if r.syncComplete {
+		if err := r.sendSyncComplete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coreV1Inform sets up the core/v1 event informer.
+func (r *Reader) coreV1Inform() (cache.InformerSynced, error) {
+	return r.inform(r.informer.Core().V1().Events().Informer())
+}
+
+// eventsV1Inform sets up the events.k8s.io/v1 event informer.
+func (r *Reader) eventsV1Inform() (cache.InformerSynced, error) {
+	return r.inform(r.informer.Events().V1().Events().Informer())
+}
+
+// inform registers our handlers with an informer.
+func (r *Reader) inform(informer cache.SharedIndexInformer) (cache.InformerSynced, error) {
+	reg, err := informer.AddEventHandler(r.handlers)
+	if err != nil {
+		return nil, err
+	}
+	r.indexes = append(r.indexes, informer)
+	return reg.HasSynced, nil
+}
+
+// addHandler is the event handler for adding data.
+func (r *Reader) addHandler(obj any, isInInitialList bool) {
+	origin := data.ORWatch
+	if isInInitialList {
+		origin = data.ORInitialList
+	}
+	if err := r.add(obj, origin); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// updateHandler is the event handler for updating data.
+func (r *Reader) updateHandler(oldObj any, newObj any) {
+	if err := r.update(oldObj, newObj); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// deleteHandler is the event handler for deleting data. Events are removed by the APIServer when their
+// TTL expires, so deletes carry no useful signal and are ignored.
+func (r *Reader) deleteHandler(obj any) {}
+
+// add handles event type add. origin is set on the emitted entry.
+func (r *Reader) add(obj any, origin data.Origin) error {
+	if obj == nil {
+		return fmt.Errorf("events.Reader.add(): obj cannot be nil")
+	}
+
+	var ev data.Event
+	var err error
+	switch v := obj.(type) {
+	case *corev1.Event:
+		if !r.keep(v.Type, v.Reason) {
+			return nil
+		}
+		ev, err = data.NewEvent(data.Change[*corev1.Event]{ChangeType: data.CTAdd, ObjectType: data.OTEvent, New: v})
+	case *eventsv1.Event:
+		if !r.keep(v.Type, v.Reason) {
+			return nil
+		}
+		ev, err = data.NewEvent(data.Change[*eventsv1.Event]{ChangeType: data.CTAdd, ObjectType: data.OTEventsV1Event, New: v})
+	default:
+		return fmt.Errorf("events.Reader.add(): unknown object type: %T", obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.send(ev, origin)
+}
+
+// update handles event type update.
+func (r *Reader) update(oldObj any, newObj any) error {
+	if oldObj == nil || newObj == nil {
+		return fmt.Errorf("events.Reader.update(): oldObj and newObj cannot be nil")
+	}
+
+	if reflect.TypeOf(oldObj) != reflect.TypeOf(newObj) {
+		return fmt.Errorf("events.Reader.update(): oldObj(%T) and newObj(%T) are not the same type", oldObj, newObj)
+	}
+
+	var ev data.Event
+	var err error
+	switch v := newObj.(type) {
+	case *corev1.Event:
+		if !r.keep(v.Type, v.Reason) {
+			return nil
+		}
+		ev, err = data.NewEvent(
+			data.Change[*corev1.Event]{
+				ChangeType: data.CTUpdate,
+				ObjectType: data.OTEvent,
+				New:        v,
+				Old:        oldObj.(*corev1.Event),
+			},
+		)
+	case *eventsv1.Event:
+		if !r.keep(v.Type, v.Reason) {
+			return nil
+		}
+		ev, err = data.NewEvent(
+			data.Change[*eventsv1.Event]{
+				ChangeType: data.CTUpdate,
+				ObjectType: data.OTEventsV1Event,
+				New:        v,
+				Old:        oldObj.(*eventsv1.Event),
+			},
+		)
+	default:
+		return fmt.Errorf("events.Reader.update(): unknown object type: %T", newObj)
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.send(ev, data.UpdateOrigin(oldObj, newObj))
+}
+
+// keep reports if an event with type t and reason passes the filters.
+func (r *Reader) keep(t, reason string) bool {
+	if len(r.types) > 0 && !r.types[t] {
+		return false
+	}
+	if len(r.reasons) > 0 && !r.reasons[reason] {
+		return false
+	}
+	return true
+}
+
+// send collapses ev with any repeats in the deduplication window and sends it with origin. Events from the
+// initial list are instead dropped or sent on the initial list channel if WithoutInitialList() or
+// WithInitialListOut() were used.
+func (r *Reader) send(ev data.Event, origin data.Origin) error {
+	obj, ok := ev.Object().(interface{ GetUID() types.UID })
+	if !ok {
+		return fmt.Errorf("events.Reader.send(): object(%T) has no UID", ev.Object())
+	}
+
+	ev = r.collapse(ev, obj.GetUID())
+
+	e, err := data.NewEntry(ev)
+	if err != nil {
+		return err
+	}
+
+	e.Origin = origin
+	if origin == data.ORInitialList {
+		switch {
+		case r.dropInitialList:
+			return nil
+		case r.initialListOut != nil:
+			r.initialListOut <- e
+			return nil
+		}
+	}
+	r.ch <- e
+	return nil
+}
+
+// sendSyncComplete sends a data.ETSyncComplete marker on the output channel and the initial list channel.
+func (r *Reader) sendSyncComplete() error {
+	e, err := data.NewEntry(data.NewSyncComplete(readerName, time.Now()))
+	if err != nil {
+		return err
+	}
+	if r.initialListOut != nil {
+		r.initialListOut <- e
+	}
+	r.ch <- e
+	return nil
+}
+
+// collapse records ev, which came from the event object with UID objUID, and returns ev with the Count,
+// FirstTimestamp and LastTimestamp of all events sharing its key in the current window.
+func (r *Reader) collapse(ev data.Event, objUID types.UID) data.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	agg, ok := r.seen[ev.GetUID()]
+	if !ok || now.Sub(agg.start) >= r.window {
+		agg = &aggregate{
+			start:  now,
+			first:  ev.FirstTimestamp,
+			last:   ev.LastTimestamp,
+			counts: map[types.UID]int32{},
+		}
+		r.seen[ev.GetUID()] = agg
+	}
+
+	agg.counts[objUID] = ev.Count
+	if agg.first.IsZero() || (!ev.FirstTimestamp.IsZero() && ev.FirstTimestamp.Before(agg.first)) {
+		agg.first = ev.FirstTimestamp
+	}
+	if ev.LastTimestamp.After(agg.last) {
+		agg.last = ev.LastTimestamp
+	}
+
+	var count int32
+	for _, c := range agg.counts {
+		count += c
+	}
+	ev.Count = count
+	ev.FirstTimestamp = agg.first
+	ev.LastTimestamp = agg.last
+	return ev
+}
+
+// sweep removes aggregates whose window has passed. It only runs once per window. r.mu must be held.
+func (r *Reader) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.window {
+		return
+	}
+	r.lastSweep = now
+
+	for k, agg := range r.seen {
+		if now.Sub(agg.start) >= r.window {
+			delete(r.seen, k)
+		}
+	}
+}
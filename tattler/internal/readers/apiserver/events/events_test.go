@@ -0,0 +1,294 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestReader(now *time.Time) *Reader {
+	return &Reader{
+		ch:      make(chan data.Entry, 10),
+		window:  5 * time.Second,
+		types:   map[string]bool{},
+		reasons: map[string]bool{},
+		seen:    map[types.UID]*aggregate{},
+		now:     func() time.Time { return *now },
+	}
+}
+
+func coreEvent(uid types.UID, reason, message string, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{UID: uid},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Pod",
+			UID:  "pod",
+		},
+		Type:    corev1.EventTypeWarning,
+		Reason:  reason,
+		Message: message,
+		Count:   count,
+	}
+}
+
+func TestAdd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		obj       any
+		types     []string
+		reasons   []string
+		wantSent  bool
+		wantCount int32
+		wantErr   bool
+	}{
+		{
+			name:    "Error: obj is nil",
+			wantErr: true,
+		},
+		{
+			name:    "Error: unsupported type",
+			obj:     &corev1.Pod{},
+			wantErr: true,
+		},
+		{
+			name:      "core/v1 event",
+			obj:       coreEvent("a", "BackOff", "msg", 3),
+			wantSent:  true,
+			wantCount: 3,
+		},
+		{
+			name: "events.k8s.io/v1 event with series",
+			obj: &eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{UID: "a"},
+				Reason:     "BackOff",
+				Series:     &eventsv1.EventSeries{Count: 4},
+			},
+			wantSent:  true,
+			wantCount: 4,
+		},
+		{
+			name:     "Filtered by type",
+			obj:      coreEvent("a", "BackOff", "msg", 1),
+			types:    []string{corev1.EventTypeNormal},
+			wantSent: false,
+		},
+		{
+			name:     "Filtered by reason",
+			obj:      coreEvent("a", "BackOff", "msg", 1),
+			reasons:  []string{"FailedScheduling"},
+			wantSent: false,
+		},
+		{
+			name:      "Passes filters",
+			obj:       coreEvent("a", "BackOff", "msg", 1),
+			types:     []string{corev1.EventTypeWarning},
+			reasons:   []string{"BackOff"},
+			wantSent:  true,
+			wantCount: 1,
+		},
+	}
+
+	for _, test := range tests {
+		now := time.Now()
+		r := newTestReader(&now)
+		WithTypes(test.types...)(r)
+		WithReasons(test.reasons...)(r)
+
+		err := r.add(test.obj, data.ORWatch)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestAdd(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestAdd(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if !test.wantSent {
+			if len(r.ch) != 0 {
+				t.Errorf("TestAdd(%s): got entry, want no entry", test.name)
+			}
+			continue
+		}
+
+		e := <-r.ch
+		if e.Origin != data.ORWatch {
+			t.Errorf("TestAdd(%s): got Origin == %v, want %v", test.name, e.Origin, data.ORWatch)
+		}
+		ev, err := e.Event()
+		if err != nil {
+			t.Errorf("TestAdd(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		if ev.Count != test.wantCount {
+			t.Errorf("TestAdd(%s): got Count == %d, want %d", test.name, ev.Count, test.wantCount)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		oldObj, newObj any
+		wantErr        bool
+	}{
+		{
+			name:    "Error: oldObj is nil",
+			newObj:  coreEvent("a", "BackOff", "msg", 1),
+			wantErr: true,
+		},
+		{
+			name:    "Error: oldObj and newObj are not the same type",
+			oldObj:  coreEvent("a", "BackOff", "msg", 1),
+			newObj:  &eventsv1.Event{},
+			wantErr: true,
+		},
+		{
+			name:   "core/v1 update",
+			oldObj: coreEvent("a", "BackOff", "msg", 1),
+			newObj: coreEvent("a", "BackOff", "msg", 2),
+		},
+	}
+
+	for _, test := range tests {
+		now := time.Now()
+		r := newTestReader(&now)
+
+		err := r.update(test.oldObj, test.newObj)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestUpdate(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		e := <-r.ch
+		ev, err := e.Event()
+		if err != nil {
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		c, err := ev.Event()
+		if err != nil {
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		if c.ChangeType != data.CTUpdate {
+			t.Errorf("TestUpdate(%s): got ChangeType == %v, want CTUpdate", test.name, c.ChangeType)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	r := newTestReader(&now)
+
+	recv := func() data.Event {
+		e := <-r.ch
+		ev, err := e.Event()
+		if err != nil {
+			panic(err)
+		}
+		return ev
+	}
+
+	// Two different event objects that are repeats of each other.
+	if err := r.add(coreEvent("a", "BackOff", "msg", 2), data.ORWatch); err != nil {
+		panic(err)
+	}
+	first := recv()
+
+	now = now.Add(time.Second)
+	if err := r.add(coreEvent("b", "BackOff", "msg", 1), data.ORWatch); err != nil {
+		panic(err)
+	}
+	second := recv()
+
+	if first.GetUID() != second.GetUID() {
+		t.Fatalf("TestCollapse: repeated events got different UIDs")
+	}
+	if second.Count != 3 {
+		t.Errorf("TestCollapse: got Count == %d, want 3", second.Count)
+	}
+
+	// An update to an event object replaces its count instead of adding to it.
+	now = now.Add(time.Second)
+	if err := r.update(coreEvent("a", "BackOff", "msg", 2), coreEvent("a", "BackOff", "msg", 5)); err != nil {
+		panic(err)
+	}
+	if got := recv().Count; got != 6 {
+		t.Errorf("TestCollapse(update): got Count == %d, want 6", got)
+	}
+
+	// A different message is not a repeat.
+	if err := r.add(coreEvent("c", "BackOff", "other", 1), data.ORWatch); err != nil {
+		panic(err)
+	}
+	if got := recv(); got.GetUID() == first.GetUID() || got.Count != 1 {
+		t.Errorf("TestCollapse(different message): got UID %s Count %d, want new UID and Count 1", got.GetUID(), got.Count)
+	}
+
+	// Once the window passes, counting starts again.
+	now = now.Add(10 * time.Second)
+	if err := r.add(coreEvent("d", "BackOff", "msg", 1), data.ORWatch); err != nil {
+		panic(err)
+	}
+	if got := recv().Count; got != 1 {
+		t.Errorf("TestCollapse(window passed): got Count == %d, want 1", got)
+	}
+	if len(r.seen) != 1 {
+		t.Errorf("TestCollapse(window passed): got len(seen) == %d, want 1", len(r.seen))
+	}
+}
+
+func TestInitialList(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	// Dropped.
+	r := newTestReader(&now)
+	r.dropInitialList = true
+	r.addHandler(coreEvent("a", "BackOff", "msg", 1), true)
+	if len(r.ch) != 0 {
+		t.Errorf("TestInitialList(drop): got entry, want no entry")
+	}
+
+	// Routed, with the marker sent on both channels.
+	r = newTestReader(&now)
+	r.initialListOut = make(chan data.Entry, 2)
+	r.addHandler(coreEvent("a", "BackOff", "msg", 1), true)
+	r.addHandler(coreEvent("b", "Pulled", "msg", 1), false)
+	if err := r.sendSyncComplete(); err != nil {
+		t.Fatalf("TestInitialList(route): got err == %v, want err == nil", err)
+	}
+	if e := <-r.initialListOut; e.Origin != data.ORInitialList {
+		t.Errorf("TestInitialList(route): got initial list Origin == %v, want %v", e.Origin, data.ORInitialList)
+	}
+	if e := <-r.ch; e.Origin != data.ORWatch {
+		t.Errorf("TestInitialList(route): got output Origin == %v, want %v", e.Origin, data.ORWatch)
+	}
+	for _, ch := range []chan data.Entry{r.initialListOut, r.ch} {
+		if e := <-ch; e.Type != data.ETSyncComplete {
+			t.Errorf("TestInitialList(route): got Type == %v, want %v", e.Type, data.ETSyncComplete)
+		}
+	}
+}
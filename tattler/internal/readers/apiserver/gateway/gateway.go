@@ -0,0 +1,386 @@
+/*
+Package gateway provides a reader for Gateway API (gateway.networking.k8s.io) objects from the APIServer.
+
+The Gateway API is installed as CRDs, so there are no typed clients for it in client-go. The Reader uses
+the dynamic informers and emits *unstructured.Unstructured objects as data.OTGateway and data.OTHTTPRoute.
+Discovery is used to find which of the requested types are installed. Types that are not installed are
+skipped, so a Reader on a cluster without the CRDs is valid and never emits anything.
+
+Usage:
+
+	disc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		// Do something
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		// Do something
+	}
+	informer := dynamicinformer.NewDynamicSharedInformerFactory(dyn, time.Minute*10)
+
+	r, err := gateway.New(ctx, disc, informer, gateway.RTGateway|gateway.RTHTTPRoute)
+	if err != nil {
+		// Do something
+	}
+	tattler.AddReader(ctx, r)
+*/
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RetrieveType is the type of Gateway API data to retrieve. Uses as a bitwise flag.
+type RetrieveType uint8
+
+const (
+	// RTGateway retrieves gateway data.
+	RTGateway RetrieveType = 0x1
+	// RTHTTPRoute retrieves http route data.
+	RTHTTPRoute RetrieveType = 0x2
+)
+
+// versions are the Gateway API versions we look for, in order of preference.
+var versions = []string{"v1", "v1beta1"}
+
+// Reader reports changes made to Gateway API objects on the APIServer.
+type Reader struct {
+	informer dynamicinformer.DynamicSharedInformerFactory
+	indexes  []cache.SharedIndexInformer
+	handlers cache.ResourceEventHandlerDetailedFuncs
+	syncers  []cache.InformerSynced
+
+	// dropInitialList, initialListOut and syncComplete control handling of the initial list.
+	dropInitialList bool
+	initialListOut  chan data.Entry
+	syncComplete    bool
+
+	ch      chan data.Entry
+	stop    chan struct{}
+	started bool
+	log     *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Reader) error
+
+// WithLogger sets the logger for the Reader.
+func WithLogger(log *slog.Logger) Option {
+	return func(r *Reader) error {
+		r.log = log
+		return nil
+	}
+}
+
+// WithoutInitialList drops the adds the informers send for objects that exist when the Reader starts, so
+// only changes made while the Reader is running are emitted. Cannot be used with WithInitialListOut().
+func WithoutInitialList() Option {
+	return func(r *Reader) error {
+		r.dropInitialList = true
+		return nil
+	}
+}
+
+// WithInitialListOut sends the adds the informers send for objects that exist when the Reader starts on
+// out instead of the output channel. out must be read from for Run() to complete. Cannot be used with
+// WithoutInitialList().
+func WithInitialListOut(out chan data.Entry) Option {
+	return func(r *Reader) error {
+		if out == nil {
+			return fmt.Errorf("initial list channel cannot be nil")
+		}
+		r.initialListOut = out
+		return nil
+	}
+}
+
+// WithSyncComplete emits a data.ETSyncComplete marker entry once all informers have sent their initial
+// list. It is sent on the output channel and, if set, the WithInitialListOut() channel.
+func WithSyncComplete() Option {
+	return func(r *Reader) error {
+		r.syncComplete = true
+		return nil
+	}
+}
+
+// New creates a new Reader. retrieveTypes is a bitwise flag to determine what data to retrieve. disc is used
+// to find which types are installed, any that are not are skipped and logged.
+func New(ctx context.Context, disc discovery.DiscoveryInterface, informer dynamicinformer.DynamicSharedInformerFactory, retrieveTypes RetrieveType, opts ...Option) (*Reader, error) {
+	if disc == nil {
+		return nil, fmt.Errorf("discovery client is nil")
+	}
+	if informer == nil {
+		return nil, fmt.Errorf("informer is nil")
+	}
+	if retrieveTypes == 0 {
+		return nil, fmt.Errorf("no data types to retrieve")
+	}
+
+	r := &Reader{
+		informer: informer,
+		stop:     make(chan struct{}),
+		log:      slog.Default(),
+	}
+	r.handlers = cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc:    r.addHandler,
+		UpdateFunc: r.updateHandler,
+		DeleteFunc: r.deleteHandler,
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.dropInitialList && r.initialListOut != nil {
+		return nil, fmt.Errorf("cannot use both WithoutInitialList() and WithInitialListOut()")
+	}
+
+	informs := []struct {
+		rt       RetrieveType
+		resource string
+	}{
+		{RTGateway, "gateways"},
+		{RTHTTPRoute, "httproutes"},
+	}
+
+	for _, i := range informs {
+		if retrieveTypes&i.rt != i.rt {
+			continue
+		}
+		gvr, ok, err := installed(disc, i.resource)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			r.log.Info(fmt.Sprintf("gateway: %s.%s is not installed, skipping", i.resource, data.GatewayGroup))
+			continue
+		}
+		s, err := r.inform(informer.ForResource(gvr).Informer())
+		if err != nil {
+			return nil, err
+		}
+		r.syncers = append(r.syncers, s)
+	}
+
+	return r, nil
+}
+
+// installed finds the preferred version of resource in the Gateway API group that the APIServer serves.
+// It returns false if the resource is not installed.
+func installed(disc discovery.DiscoveryInterface, resource string) (schema.GroupVersionResource, bool, error) {
+	for _, v := range versions {
+		gv := schema.GroupVersion{Group: data.GatewayGroup, Version: v}
+		list, err := disc.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return schema.GroupVersionResource{}, false, fmt.Errorf("gateway: discovery of %s failed: %w", gv, err)
+		}
+		for _, res := range list.APIResources {
+			if res.Name == resource {
+				return gv.WithResource(resource), true, nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false, nil
+}
+
+// inform registers our handlers with an informer and records the informer so that Close() can
+// wait for it to stop.
+func (r *Reader) inform(informer cache.SharedIndexInformer) (cache.InformerSynced, error) {
+	reg, err := informer.AddEventHandler(r.handlers)
+	if err != nil {
+		return nil, err
+	}
+	r.indexes = append(r.indexes, informer)
+	return reg.HasSynced, nil
+}
+
+var closeDelay = 100 * time.Millisecond
+
+// Close closes the Reader. This will block until all indexes are stopped.
+// If the context is canceled, it will return the context error.
+func (r *Reader) Close(ctx context.Context) error {
+	close(r.stop)
+	defer close(r.ch)
+
+start:
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, index := range r.indexes {
+		if !index.IsStopped() {
+			time.Sleep(closeDelay)
+			goto start
+		}
+	}
+	return nil
+}
+
+// readerName is the name of the Reader returned by Name().
+const readerName = "gateway"
+
+// Name returns the name of the Reader, which the tattler Runner puts in data.Entry.Reader.
+func (r *Reader) Name() string {
+	return readerName
+}
+
+// SetOut sets the output channel that the reader must output on. Must return an error and be a no-op
+// if Run() has been called.
+func (r *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
+	if r.started {
+		return fmt.Errorf("cannot call SetOut once the Reader has had Start() called")
+	}
+	r.ch = out
+	return nil
+}
+
+// Run starts the Reader processing. You may only call this once if Run() does not return an error.
+func (r *Reader) Run(ctx context.Context) error {
+	if r.started {
+		return fmt.Errorf("cannot call Run once the Reader has already started")
+	}
+	if r.ch == nil {
+		return fmt.Errorf("cannot call Run if SetOut has not been called")
+	}
+	r.informer.Start(r.stop)
+
+	if !cache.WaitForCacheSync(r.stop, r.syncers...) {
+		r.stop = make(chan struct{})
+		return fmt.Errorf("failed to sync cache")
+	}
+	r.started = true
+
+	if r.syncComplete {
+		if err := r.sendSyncComplete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addHandler is the event handler for adding data. This is a shim around addOrDelete.
+func (r *Reader) addHandler(obj any, isInInitialList bool) {
+	origin := data.ORWatch
+	if isInInitialList {
+		origin = data.ORInitialList
+	}
+	if err := r.addOrDelete(obj, data.CTAdd, origin); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+func (r *Reader) updateHandler(oldObj any, newObj any) {
+	if err := r.update(oldObj, newObj); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// deleteHandler is the event handler for deleting data. This is a shim around addOrDelete.
+func (r *Reader) deleteHandler(obj any) {
+	if err := r.addOrDelete(obj, data.CTDelete, data.ORWatch); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// addOrDelete handles event types add and delete. origin is set on the emitted entry.
+func (r *Reader) addOrDelete(obj any, ct data.ChangeType, origin data.Origin) error {
+	// A tombstone holds the last state the informer had for an object whose delete the watch missed.
+	var flags data.ChangeFlag
+	if t, ok := obj.(cache.DeletedFinalStateUnknown); ok && ct == data.CTDelete {
+		obj = t.Obj
+		flags = data.CFStaleFinalState
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || u == nil {
+		return fmt.Errorf("gateway.Reader.addOrDelete(): unknown object type: %T", obj)
+	}
+
+	change := data.Change[*unstructured.Unstructured]{ChangeType: ct, ObjectType: data.UnstructuredObjectType(u), Flags: flags}
+	switch ct {
+	case data.CTAdd:
+		change.New = u
+	case data.CTDelete:
+		change.Old = u
+	default:
+		return fmt.Errorf("unsupported change type in gateway.Reader.addOrDelete(): %d", ct)
+	}
+	return r.send(change, origin)
+}
+
+// update is the event handler for updating data.
+func (r *Reader) update(oldObj any, newObj any) error {
+	if oldObj == nil || newObj == nil {
+		return fmt.Errorf("gateway.Reader.update(): oldObj and newObj cannot be nil")
+	}
+	if reflect.TypeOf(oldObj) != reflect.TypeOf(newObj) {
+		return fmt.Errorf("gateway.Reader.update(): oldObj(%T) and newObj(%T) are not the same type", oldObj, newObj)
+	}
+	n, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("gateway.Reader.update(): unknown object type: %T", newObj)
+	}
+
+	change := data.Change[*unstructured.Unstructured]{
+		ChangeType: data.CTUpdate,
+		ObjectType: data.UnstructuredObjectType(n),
+		New:        n,
+		Old:        oldObj.(*unstructured.Unstructured),
+	}
+	return r.send(change, data.UpdateOrigin(oldObj, newObj))
+}
+
+// send wraps change in an Entry with origin and sends it on the output channel. Entries from the initial
+// list are instead dropped or sent on the initial list channel if WithoutInitialList() or
+// WithInitialListOut() were used.
+func (r *Reader) send(change data.Change[*unstructured.Unstructured], origin data.Origin) error {
+	d, err := data.NewInformer(change)
+	if err != nil {
+		return err
+	}
+	e, err := data.NewEntry(d)
+	if err != nil {
+		return err
+	}
+	e.Origin = origin
+	if origin == data.ORInitialList {
+		switch {
+		case r.dropInitialList:
+			return nil
+		case r.initialListOut != nil:
+			r.initialListOut <- e
+			return nil
+		}
+	}
+	r.ch <- e
+	return nil
+}
+
+// sendSyncComplete sends a data.ETSyncComplete marker on the output channel and the initial list channel.
+func (r *Reader) sendSyncComplete() error {
+	e, err := data.NewEntry(data.NewSyncComplete(readerName, time.Now()))
+	if err != nil {
+		return err
+	}
+	if r.initialListOut != nil {
+		r.initialListOut <- e
+	}
+	r.ch <- e
+	return nil
+}
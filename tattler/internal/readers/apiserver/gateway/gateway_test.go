@@ -0,0 +1,347 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newFactory() dynamicinformer.DynamicSharedInformerFactory {
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Group: data.GatewayGroup, Version: "v1", Resource: "gateways"}:        "GatewayList",
+			{Group: data.GatewayGroup, Version: "v1", Resource: "httproutes"}:      "HTTPRouteList",
+			{Group: data.GatewayGroup, Version: "v1beta1", Resource: "gateways"}:   "GatewayList",
+			{Group: data.GatewayGroup, Version: "v1beta1", Resource: "httproutes"}: "HTTPRouteList",
+		},
+	)
+	return dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+}
+
+func newDiscovery(resources ...*metav1.APIResourceList) *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+}
+
+func gatewayObj(kind string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(data.GatewayGroup + "/v1")
+	u.SetKind(kind)
+	u.SetNamespace("ns")
+	u.SetName("name")
+	u.SetUID("uid")
+	return u
+}
+
+func withRV(u *unstructured.Unstructured, rv string) *unstructured.Unstructured {
+	u.SetResourceVersion(rv)
+	return u
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		disc          *fakediscovery.FakeDiscovery
+		retrieveTypes RetrieveType
+		wantIndexes   int
+		wantErr       bool
+	}{
+		{
+			name:          "Error: no retrieve types",
+			disc:          newDiscovery(),
+			retrieveTypes: 0,
+			wantErr:       true,
+		},
+		{
+			name:          "CRDs not installed",
+			disc:          newDiscovery(),
+			retrieveTypes: RTGateway | RTHTTPRoute,
+			wantIndexes:   0,
+		},
+		{
+			name: "Only gateways installed",
+			disc: newDiscovery(
+				&metav1.APIResourceList{
+					GroupVersion: data.GatewayGroup + "/v1",
+					APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway"}},
+				},
+			),
+			retrieveTypes: RTGateway | RTHTTPRoute,
+			wantIndexes:   1,
+		},
+		{
+			name: "Falls back to v1beta1",
+			disc: newDiscovery(
+				&metav1.APIResourceList{
+					GroupVersion: data.GatewayGroup + "/v1beta1",
+					APIResources: []metav1.APIResource{
+						{Name: "gateways", Kind: "Gateway"},
+						{Name: "httproutes", Kind: "HTTPRoute"},
+					},
+				},
+			),
+			retrieveTypes: RTGateway | RTHTTPRoute,
+			wantIndexes:   2,
+		},
+		{
+			name: "Only requested types",
+			disc: newDiscovery(
+				&metav1.APIResourceList{
+					GroupVersion: data.GatewayGroup + "/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "gateways", Kind: "Gateway"},
+						{Name: "httproutes", Kind: "HTTPRoute"},
+					},
+				},
+			),
+			retrieveTypes: RTHTTPRoute,
+			wantIndexes:   1,
+		},
+	}
+
+	for _, test := range tests {
+		r, err := New(context.Background(), test.disc, newFactory(), test.retrieveTypes)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestNew(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if len(r.indexes) != test.wantIndexes {
+			t.Errorf("TestNew(%s): got %d indexes, want %d", test.name, len(r.indexes), test.wantIndexes)
+		}
+	}
+}
+
+func TestRunWithoutCRDs(t *testing.T) {
+	t.Parallel()
+
+	r, err := New(context.Background(), newDiscovery(), newFactory(), RTGateway|RTHTTPRoute)
+	if err != nil {
+		t.Fatalf("TestRunWithoutCRDs: got err == %v, want err == nil", err)
+	}
+	if err := r.SetOut(context.Background(), make(chan data.Entry, 1)); err != nil {
+		t.Fatalf("TestRunWithoutCRDs: got err == %v, want err == nil", err)
+	}
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunWithoutCRDs: got err == %v, want err == nil", err)
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("TestRunWithoutCRDs: got err == %v, want err == nil", err)
+	}
+}
+
+func TestAddOrDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		obj       any
+		ct        data.ChangeType
+		wantOT    data.ObjectType
+		wantFlags data.ChangeFlag
+		wantErr   bool
+	}{
+		{
+			name:    "Error: obj is nil",
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name:    "Error: not unstructured",
+			obj:     &corev1.Pod{},
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name:    "Error: unknown kind",
+			obj:     gatewayObj("GRPCRoute"),
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name:   "Gateway add",
+			obj:    gatewayObj("Gateway"),
+			ct:     data.CTAdd,
+			wantOT: data.OTGateway,
+		},
+		{
+			name:   "HTTPRoute delete",
+			obj:    gatewayObj("HTTPRoute"),
+			ct:     data.CTDelete,
+			wantOT: data.OTHTTPRoute,
+		},
+		{
+			name:      "Tombstone delete",
+			obj:       cache.DeletedFinalStateUnknown{Key: "default/gw", Obj: gatewayObj("Gateway")},
+			ct:        data.CTDelete,
+			wantOT:    data.OTGateway,
+			wantFlags: data.CFStaleFinalState,
+		},
+	}
+
+	for _, test := range tests {
+		r := &Reader{ch: make(chan data.Entry, 1)}
+
+		err := r.addOrDelete(test.obj, test.ct, data.ORWatch)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestAddOrDelete(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestAddOrDelete(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		e := <-r.ch
+		if e.Origin != data.ORWatch {
+			t.Errorf("TestAddOrDelete(%s): got Origin == %v, want %v", test.name, e.Origin, data.ORWatch)
+		}
+		i, err := e.Informer()
+		if err != nil {
+			t.Errorf("TestAddOrDelete(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		if i.Type != test.wantOT {
+			t.Errorf("TestAddOrDelete(%s): got ObjectType == %v, want %v", test.name, i.Type, test.wantOT)
+			continue
+		}
+		var c data.Change[*unstructured.Unstructured]
+		switch test.wantOT {
+		case data.OTGateway:
+			c, err = i.Gateway()
+		case data.OTHTTPRoute:
+			c, err = i.HTTPRoute()
+		}
+		if err != nil {
+			t.Errorf("TestAddOrDelete(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		if c.ChangeType != test.ct {
+			t.Errorf("TestAddOrDelete(%s): got ChangeType == %v, want %v", test.name, c.ChangeType, test.ct)
+		}
+		if c.Flags != test.wantFlags {
+			t.Errorf("TestAddOrDelete(%s): got Flags == %v, want %v", test.name, c.Flags, test.wantFlags)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		oldObj, newObj any
+		wantOrigin     data.Origin
+		wantErr        bool
+	}{
+		{
+			name:    "Error: oldObj is nil",
+			newObj:  gatewayObj("Gateway"),
+			wantErr: true,
+		},
+		{
+			name:    "Error: oldObj and newObj are not the same type",
+			oldObj:  &corev1.Pod{},
+			newObj:  gatewayObj("Gateway"),
+			wantErr: true,
+		},
+		{
+			name:       "Gateway update",
+			oldObj:     withRV(gatewayObj("Gateway"), "1"),
+			newObj:     withRV(gatewayObj("Gateway"), "2"),
+			wantOrigin: data.ORWatch,
+		},
+		{
+			name:       "Gateway resync",
+			oldObj:     withRV(gatewayObj("Gateway"), "1"),
+			newObj:     withRV(gatewayObj("Gateway"), "1"),
+			wantOrigin: data.ORResync,
+		},
+	}
+
+	for _, test := range tests {
+		r := &Reader{ch: make(chan data.Entry, 1)}
+
+		err := r.update(test.oldObj, test.newObj)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestUpdate(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		e := <-r.ch
+		if e.Origin != test.wantOrigin {
+			t.Errorf("TestUpdate(%s): got Origin == %v, want %v", test.name, e.Origin, test.wantOrigin)
+		}
+		i, err := e.Informer()
+		if err != nil {
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		c, err := i.Gateway()
+		if err != nil {
+			t.Errorf("TestUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		if c.ChangeType != data.CTUpdate {
+			t.Errorf("TestUpdate(%s): got ChangeType == %v, want CTUpdate", test.name, c.ChangeType)
+		}
+	}
+}
+
+func TestInitialList(t *testing.T) {
+	t.Parallel()
+
+	// Dropped.
+	r := &Reader{ch: make(chan data.Entry, 1), dropInitialList: true}
+	r.addHandler(gatewayObj("Gateway"), true)
+	if len(r.ch) != 0 {
+		t.Errorf("TestInitialList(drop): got entry, want no entry")
+	}
+
+	// Routed, with the marker sent on both channels.
+	r = &Reader{ch: make(chan data.Entry, 2), initialListOut: make(chan data.Entry, 2)}
+	r.addHandler(gatewayObj("Gateway"), true)
+	r.addHandler(gatewayObj("Gateway"), false)
+	if err := r.sendSyncComplete(); err != nil {
+		t.Fatalf("TestInitialList(route): got err == %v, want err == nil", err)
+	}
+	if e := <-r.initialListOut; e.Origin != data.ORInitialList {
+		t.Errorf("TestInitialList(route): got initial list Origin == %v, want %v", e.Origin, data.ORInitialList)
+	}
+	if e := <-r.ch; e.Origin != data.ORWatch {
+		t.Errorf("TestInitialList(route): got output Origin == %v, want %v", e.Origin, data.ORWatch)
+	}
+	for _, ch := range []chan data.Entry{r.initialListOut, r.ch} {
+		if e := <-ch; e.Type != data.ETSyncComplete {
+			t.Errorf("TestInitialList(route): got Type == %v, want %v", e.Type, data.ETSyncComplete)
+		}
+	}
+}
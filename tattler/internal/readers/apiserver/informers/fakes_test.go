@@ -1,24 +1,80 @@
 package informers
 
 import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
 	"time"
 
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/informers/admissionregistration"
+	admissionregistrationv1 "k8s.io/client-go/informers/admissionregistration/v1"
+	"k8s.io/client-go/informers/apps"
+	appsv1 "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/informers/batch"
+	batchv1 "k8s.io/client-go/informers/batch/v1"
 	"k8s.io/client-go/informers/core"
 	v1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/informers/discovery"
+	discoveryv1 "k8s.io/client-go/informers/discovery/v1"
+	"k8s.io/client-go/informers/networking"
+	networkingv1 "k8s.io/client-go/informers/networking/v1"
+	"k8s.io/client-go/informers/rbac"
+	rbacv1 "k8s.io/client-go/informers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
 type fakeInformer struct {
 	informers.SharedInformerFactory
 
-	core *fakeCore
+	core                  *fakeCore
+	apps                  *fakeApps
+	batch                 *fakeBatch
+	discovery             *fakeDiscovery
+	rbac                  *fakeRbac
+	networking            *fakeNetworking
+	admissionregistration *fakeAdmissionregistration
 }
 
 type fakeInformerArgs struct {
 	nodes     *fakeSharedIndexInformer
 	pods      *fakeSharedIndexInformer
 	namespace *fakeSharedIndexInformer
+	services  *fakeSharedIndexInformer
+
+	secrets    *fakeSharedIndexInformer
+	configMaps *fakeSharedIndexInformer
+
+	serviceAccounts *fakeSharedIndexInformer
+
+	deployments  *fakeSharedIndexInformer
+	replicaSets  *fakeSharedIndexInformer
+	statefulSets *fakeSharedIndexInformer
+	daemonSets   *fakeSharedIndexInformer
+
+	jobs     *fakeSharedIndexInformer
+	cronJobs *fakeSharedIndexInformer
+
+	endpointSlices *fakeSharedIndexInformer
+
+	roles               *fakeSharedIndexInformer
+	clusterRoles        *fakeSharedIndexInformer
+	roleBindings        *fakeSharedIndexInformer
+	clusterRoleBindings *fakeSharedIndexInformer
+
+	networkPolicies *fakeSharedIndexInformer
+	ingresses       *fakeSharedIndexInformer
+	ingressClasses  *fakeSharedIndexInformer
+
+	validatingWebhookConfigurations *fakeSharedIndexInformer
+	mutatingWebhookConfigurations   *fakeSharedIndexInformer
+	validatingAdmissionPolicies     *fakeSharedIndexInformer
 }
 
 func NewFakeInformer(args fakeInformerArgs) *fakeInformer {
@@ -34,6 +90,93 @@ func NewFakeInformer(args fakeInformerArgs) *fakeInformer {
 				namespace: &fakeNamespaceInformer{
 					sharedIndexInformer: args.namespace,
 				},
+				services: &fakeServiceInformer{
+					sharedIndexInformer: args.services,
+				},
+				secrets: &fakeSecretInformer{
+					sharedIndexInformer: args.secrets,
+				},
+				configMaps: &fakeConfigMapInformer{
+					sharedIndexInformer: args.configMaps,
+				},
+				serviceAccounts: &fakeServiceAccountInformer{
+					sharedIndexInformer: args.serviceAccounts,
+				},
+			},
+		},
+		apps: &fakeApps{
+			v1: &fakeAppsV1{
+				deployments: &fakeDeploymentInformer{
+					sharedIndexInformer: args.deployments,
+				},
+				replicaSets: &fakeReplicaSetInformer{
+					sharedIndexInformer: args.replicaSets,
+				},
+				statefulSets: &fakeStatefulSetInformer{
+					sharedIndexInformer: args.statefulSets,
+				},
+				daemonSets: &fakeDaemonSetInformer{
+					sharedIndexInformer: args.daemonSets,
+				},
+			},
+		},
+		batch: &fakeBatch{
+			v1: &fakeBatchV1{
+				jobs: &fakeJobInformer{
+					sharedIndexInformer: args.jobs,
+				},
+				cronJobs: &fakeCronJobInformer{
+					sharedIndexInformer: args.cronJobs,
+				},
+			},
+		},
+		discovery: &fakeDiscovery{
+			v1: &fakeDiscoveryV1{
+				endpointSlices: &fakeEndpointSliceInformer{
+					sharedIndexInformer: args.endpointSlices,
+				},
+			},
+		},
+		rbac: &fakeRbac{
+			v1: &fakeRbacV1{
+				roles: &fakeRoleInformer{
+					sharedIndexInformer: args.roles,
+				},
+				clusterRoles: &fakeClusterRoleInformer{
+					sharedIndexInformer: args.clusterRoles,
+				},
+				roleBindings: &fakeRoleBindingInformer{
+					sharedIndexInformer: args.roleBindings,
+				},
+				clusterRoleBindings: &fakeClusterRoleBindingInformer{
+					sharedIndexInformer: args.clusterRoleBindings,
+				},
+			},
+		},
+		networking: &fakeNetworking{
+			v1: &fakeNetworkingV1{
+				networkPolicies: &fakeNetworkPolicyInformer{
+					sharedIndexInformer: args.networkPolicies,
+				},
+				ingresses: &fakeIngressInformer{
+					sharedIndexInformer: args.ingresses,
+				},
+				ingressClasses: &fakeIngressClassInformer{
+					sharedIndexInformer: args.ingressClasses,
+				},
+			},
+		},
+		admissionregistration: &fakeAdmissionregistration{
+			v1: &fakeAdmissionregistrationV1{
+				validatingWebhookConfigurations: &fakeValidatingWebhookConfigurationInformer{
+					sharedIndexInformer: args.validatingWebhookConfigurations,
+				},
+				mutatingWebhookConfigurations: &fakeMutatingWebhookConfigurationInformer{
+					sharedIndexInformer: args.mutatingWebhookConfigurations,
+				},
+				validatingAdmissionPolicies: &fakeValidatingAdmissionPolicyInformer{
+					sharedIndexInformer: args.validatingAdmissionPolicies,
+				},
 			},
 		},
 	}
@@ -43,6 +186,30 @@ func (f *fakeInformer) Core() core.Interface {
 	return f.core
 }
 
+func (f *fakeInformer) Apps() apps.Interface {
+	return f.apps
+}
+
+func (f *fakeInformer) Batch() batch.Interface {
+	return f.batch
+}
+
+func (f *fakeInformer) Discovery() discovery.Interface {
+	return f.discovery
+}
+
+func (f *fakeInformer) Rbac() rbac.Interface {
+	return f.rbac
+}
+
+func (f *fakeInformer) Networking() networking.Interface {
+	return f.networking
+}
+
+func (f *fakeInformer) Admissionregistration() admissionregistration.Interface {
+	return f.admissionregistration
+}
+
 type fakeCore struct {
 	core.Interface
 	v1 *fakeV1
@@ -58,6 +225,12 @@ type fakeV1 struct {
 	nodes     *fakeNodeInformer
 	pods      *fakePodInformer
 	namespace *fakeNamespaceInformer
+	services  *fakeServiceInformer
+
+	secrets    *fakeSecretInformer
+	configMaps *fakeConfigMapInformer
+
+	serviceAccounts *fakeServiceAccountInformer
 }
 
 func (f *fakeV1) Nodes() v1.NodeInformer {
@@ -72,6 +245,22 @@ func (f *fakeV1) Namespaces() v1.NamespaceInformer {
 	return f.namespace
 }
 
+func (f *fakeV1) Services() v1.ServiceInformer {
+	return f.services
+}
+
+func (f *fakeV1) Secrets() v1.SecretInformer {
+	return f.secrets
+}
+
+func (f *fakeV1) ConfigMaps() v1.ConfigMapInformer {
+	return f.configMaps
+}
+
+func (f *fakeV1) ServiceAccounts() v1.ServiceAccountInformer {
+	return f.serviceAccounts
+}
+
 type fakeNodeInformer struct {
 	v1.NodeInformer
 	shared *fakeSharedIndexInformer
@@ -101,11 +290,400 @@ func (f *fakeNamespaceInformer) Informer() cache.SharedIndexInformer {
 	return f.sharedIndexInformer
 }
 
+type fakeServiceInformer struct {
+	v1.ServiceInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeServiceInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeSecretInformer struct {
+	v1.SecretInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeSecretInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeConfigMapInformer struct {
+	v1.ConfigMapInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeConfigMapInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeServiceAccountInformer struct {
+	v1.ServiceAccountInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeServiceAccountInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeApps struct {
+	apps.Interface
+	v1 *fakeAppsV1
+}
+
+func (f *fakeApps) V1() appsv1.Interface {
+	return f.v1
+}
+
+type fakeAppsV1 struct {
+	appsv1.Interface
+
+	deployments  *fakeDeploymentInformer
+	replicaSets  *fakeReplicaSetInformer
+	statefulSets *fakeStatefulSetInformer
+	daemonSets   *fakeDaemonSetInformer
+}
+
+func (f *fakeAppsV1) Deployments() appsv1.DeploymentInformer {
+	return f.deployments
+}
+
+func (f *fakeAppsV1) ReplicaSets() appsv1.ReplicaSetInformer {
+	return f.replicaSets
+}
+
+func (f *fakeAppsV1) StatefulSets() appsv1.StatefulSetInformer {
+	return f.statefulSets
+}
+
+func (f *fakeAppsV1) DaemonSets() appsv1.DaemonSetInformer {
+	return f.daemonSets
+}
+
+type fakeDeploymentInformer struct {
+	appsv1.DeploymentInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeDeploymentInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeReplicaSetInformer struct {
+	appsv1.ReplicaSetInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeReplicaSetInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeStatefulSetInformer struct {
+	appsv1.StatefulSetInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeStatefulSetInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeDaemonSetInformer struct {
+	appsv1.DaemonSetInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeDaemonSetInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeBatch struct {
+	batch.Interface
+	v1 *fakeBatchV1
+}
+
+func (f *fakeBatch) V1() batchv1.Interface {
+	return f.v1
+}
+
+type fakeBatchV1 struct {
+	batchv1.Interface
+
+	jobs     *fakeJobInformer
+	cronJobs *fakeCronJobInformer
+}
+
+func (f *fakeBatchV1) Jobs() batchv1.JobInformer {
+	return f.jobs
+}
+
+func (f *fakeBatchV1) CronJobs() batchv1.CronJobInformer {
+	return f.cronJobs
+}
+
+type fakeJobInformer struct {
+	batchv1.JobInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeJobInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeCronJobInformer struct {
+	batchv1.CronJobInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeCronJobInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeDiscovery struct {
+	discovery.Interface
+	v1 *fakeDiscoveryV1
+}
+
+func (f *fakeDiscovery) V1() discoveryv1.Interface {
+	return f.v1
+}
+
+type fakeDiscoveryV1 struct {
+	discoveryv1.Interface
+
+	endpointSlices *fakeEndpointSliceInformer
+}
+
+func (f *fakeDiscoveryV1) EndpointSlices() discoveryv1.EndpointSliceInformer {
+	return f.endpointSlices
+}
+
+type fakeEndpointSliceInformer struct {
+	discoveryv1.EndpointSliceInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeEndpointSliceInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeRbac struct {
+	rbac.Interface
+	v1 *fakeRbacV1
+}
+
+func (f *fakeRbac) V1() rbacv1.Interface {
+	return f.v1
+}
+
+type fakeRbacV1 struct {
+	rbacv1.Interface
+
+	roles               *fakeRoleInformer
+	clusterRoles        *fakeClusterRoleInformer
+	roleBindings        *fakeRoleBindingInformer
+	clusterRoleBindings *fakeClusterRoleBindingInformer
+}
+
+func (f *fakeRbacV1) Roles() rbacv1.RoleInformer {
+	return f.roles
+}
+
+func (f *fakeRbacV1) ClusterRoles() rbacv1.ClusterRoleInformer {
+	return f.clusterRoles
+}
+
+func (f *fakeRbacV1) RoleBindings() rbacv1.RoleBindingInformer {
+	return f.roleBindings
+}
+
+func (f *fakeRbacV1) ClusterRoleBindings() rbacv1.ClusterRoleBindingInformer {
+	return f.clusterRoleBindings
+}
+
+type fakeRoleInformer struct {
+	rbacv1.RoleInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeRoleInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeClusterRoleInformer struct {
+	rbacv1.ClusterRoleInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeClusterRoleInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeRoleBindingInformer struct {
+	rbacv1.RoleBindingInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeRoleBindingInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeClusterRoleBindingInformer struct {
+	rbacv1.ClusterRoleBindingInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeClusterRoleBindingInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeNetworking struct {
+	networking.Interface
+	v1 *fakeNetworkingV1
+}
+
+func (f *fakeNetworking) V1() networkingv1.Interface {
+	return f.v1
+}
+
+type fakeNetworkingV1 struct {
+	networkingv1.Interface
+
+	networkPolicies *fakeNetworkPolicyInformer
+	ingresses       *fakeIngressInformer
+	ingressClasses  *fakeIngressClassInformer
+}
+
+func (f *fakeNetworkingV1) NetworkPolicies() networkingv1.NetworkPolicyInformer {
+	return f.networkPolicies
+}
+
+func (f *fakeNetworkingV1) Ingresses() networkingv1.IngressInformer {
+	return f.ingresses
+}
+
+func (f *fakeNetworkingV1) IngressClasses() networkingv1.IngressClassInformer {
+	return f.ingressClasses
+}
+
+type fakeNetworkPolicyInformer struct {
+	networkingv1.NetworkPolicyInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeNetworkPolicyInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeIngressInformer struct {
+	networkingv1.IngressInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeIngressInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeIngressClassInformer struct {
+	networkingv1.IngressClassInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeIngressClassInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeAdmissionregistration struct {
+	admissionregistration.Interface
+	v1 *fakeAdmissionregistrationV1
+}
+
+func (f *fakeAdmissionregistration) V1() admissionregistrationv1.Interface {
+	return f.v1
+}
+
+type fakeAdmissionregistrationV1 struct {
+	admissionregistrationv1.Interface
+
+	validatingWebhookConfigurations *fakeValidatingWebhookConfigurationInformer
+	mutatingWebhookConfigurations   *fakeMutatingWebhookConfigurationInformer
+	validatingAdmissionPolicies     *fakeValidatingAdmissionPolicyInformer
+}
+
+func (f *fakeAdmissionregistrationV1) ValidatingWebhookConfigurations() admissionregistrationv1.ValidatingWebhookConfigurationInformer {
+	return f.validatingWebhookConfigurations
+}
+
+func (f *fakeAdmissionregistrationV1) MutatingWebhookConfigurations() admissionregistrationv1.MutatingWebhookConfigurationInformer {
+	return f.mutatingWebhookConfigurations
+}
+
+func (f *fakeAdmissionregistrationV1) ValidatingAdmissionPolicies() admissionregistrationv1.ValidatingAdmissionPolicyInformer {
+	return f.validatingAdmissionPolicies
+}
+
+type fakeValidatingWebhookConfigurationInformer struct {
+	admissionregistrationv1.ValidatingWebhookConfigurationInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeValidatingWebhookConfigurationInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeMutatingWebhookConfigurationInformer struct {
+	admissionregistrationv1.MutatingWebhookConfigurationInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeMutatingWebhookConfigurationInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
+type fakeValidatingAdmissionPolicyInformer struct {
+	admissionregistrationv1.ValidatingAdmissionPolicyInformer
+
+	sharedIndexInformer *fakeSharedIndexInformer
+}
+
+func (f *fakeValidatingAdmissionPolicyInformer) Informer() cache.SharedIndexInformer {
+	return f.sharedIndexInformer
+}
+
 type fakeSharedIndexInformer struct {
 	cache.SharedIndexInformer
 
-	handlers []cache.ResourceEventHandler
-	sendErr  error
+	handlers     []cache.ResourceEventHandler
+	sendErr      error
+	transform    cache.TransformFunc
+	transformErr error
+}
+
+func (f *fakeSharedIndexInformer) SetTransform(fn cache.TransformFunc) error {
+	if f.transformErr != nil {
+		return f.transformErr
+	}
+	f.transform = fn
+	return nil
 }
 
 func (f *fakeSharedIndexInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
@@ -143,3 +721,112 @@ func (t timedInformers) IsStopped() bool {
 	time.Sleep(t.delay)
 	return true
 }
+
+// TestTombstoneDelete drives deletes through the handlers registered on the fake informers, as the informer
+// does when a watch misses a delete and the relist finds the object gone.
+func TestTombstoneDelete(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", UID: "pod"}}
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "token",
+			Namespace:   "default",
+			UID:         "token",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: "builder"},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+
+	tests := []struct {
+		name      string
+		obj       any
+		wantUID   string
+		wantFlags data.ChangeFlag
+		wantErr   bool
+	}{
+		{
+			name:    "Delete",
+			obj:     pod,
+			wantUID: "pod",
+		},
+		{
+			name:      "Tombstone",
+			obj:       cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: pod},
+			wantUID:   "pod",
+			wantFlags: data.CFStaleFinalState,
+		},
+		{
+			name:      "Tombstone keeps type flags",
+			obj:       cache.DeletedFinalStateUnknown{Key: "default/token", Obj: token},
+			wantUID:   "token",
+			wantFlags: data.CFStaleFinalState | data.CFLegacyServiceAccountToken,
+		},
+		{
+			name:    "Error: tombstone without an object",
+			obj:     cache.DeletedFinalStateUnknown{Key: "default/pod"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		pods := &fakeSharedIndexInformer{}
+		c, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: pods}), RTPod, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		if err != nil {
+			t.Fatalf("TestTombstoneDelete(%s): got err == %v, want err == nil", test.name, err)
+		}
+		c.ch = make(chan data.Entry, 1)
+
+		// All informers share the Reader's handlers, so the pod informer's handler sees any type.
+		pods.handlers[0].OnDelete(test.obj)
+
+		if test.wantErr {
+			if len(c.ch) != 0 {
+				t.Errorf("TestTombstoneDelete(%s): got entry, want no entry", test.name)
+			}
+			continue
+		}
+		if len(c.ch) != 1 {
+			t.Errorf("TestTombstoneDelete(%s): got no entry, want entry", test.name)
+			continue
+		}
+
+		i, err := (<-c.ch).Informer()
+		if err != nil {
+			t.Errorf("TestTombstoneDelete(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		var ct data.ChangeType
+		var flags data.ChangeFlag
+		switch i.Type {
+		case data.OTPod:
+			change, err := i.Pod()
+			if err != nil {
+				t.Fatalf("TestTombstoneDelete(%s): got err == %v, want err == nil", test.name, err)
+			}
+			ct, flags = change.ChangeType, change.Flags
+		case data.OTSecret:
+			change, err := i.Secret()
+			if err != nil {
+				t.Fatalf("TestTombstoneDelete(%s): got err == %v, want err == nil", test.name, err)
+			}
+			ct, flags = change.ChangeType, change.Flags
+		}
+
+		if string(i.GetUID()) != test.wantUID {
+			t.Errorf("TestTombstoneDelete(%s): got UID == %s, want %s", test.name, i.GetUID(), test.wantUID)
+		}
+		if ct != data.CTDelete {
+			t.Errorf("TestTombstoneDelete(%s): got ChangeType == %v, want CTDelete", test.name, ct)
+		}
+		if flags != test.wantFlags {
+			t.Errorf("TestTombstoneDelete(%s): got Flags == %#x, want %#x", test.name, flags, test.wantFlags)
+		}
+	}
+
+	// A tombstone is only valid for a delete.
+	c := &Reader{ch: make(chan data.Entry, 1)}
+	if err := c.addOrDelete(cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: pod}, data.CTAdd, data.ORWatch); err == nil {
+		t.Errorf("TestTombstoneDelete(tombstone add): got err == nil, want err != nil")
+	}
+}
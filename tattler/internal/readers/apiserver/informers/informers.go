@@ -28,11 +28,32 @@ Usage:
 
 	// This will create a new reader that will stream node and pod data.
 	// RTNode and RTPod are the types of data to retrieve as bitwise flags.
+	// Workloads can be added with RTDeployment, RTReplicaSet, RTStatefulSet, RTDaemonSet, RTJob and RTCronJob.
+	// Network topology can be added with RTService and RTEndpointSlice.
+	// Admission control can be added with RTValidatingWebhookConfiguration, RTMutatingWebhookConfiguration and
+	// RTValidatingAdmissionPolicy. Changes that make them more permissive have data.ChangeFlags set.
+	// Network policy and ingress can be added with RTNetworkPolicy, RTIngress and RTIngressClass. Gateway API
+	// objects are CRDs and are read by the gateway package.
+	// RBAC can be added with RTRole, RTClusterRole, RTRoleBinding and RTClusterRoleBinding.
+	// Secrets and ConfigMaps can be added with RTSecret and RTConfigMap. Their values are never emitted.
+	// Legacy service account token Secrets have data.CFLegacyServiceAccountToken set.
+	// ServiceAccounts can be added with RTServiceAccount.
 	c, err := New(ctx, informer, retrieveTypes RetrieveType, RTNode | RTPod)
 	if err != nil {
 		// Do something
 	}
 
+	// To watch only some namespaces or labelled objects, let the reader create its informers. Namespaced types
+	// get an informer per namespace, cluster scoped types like nodes only use the label and field selectors.
+	c, err := NewScoped(
+		ctx,
+		clientset,
+		time.Minute*10,
+		RTPod | RTDeployment,
+		WithNamespaces("tenant-a", "tenant-b"),
+		WithLabelSelector("app.kubernetes.io/managed-by=helm"),
+	)
+
 	for entry := range c.Stream() {
 		data, _ := entry.Informer() // Won't get an error because we know the type.
 
@@ -66,24 +87,56 @@ package informers
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log/slog"
 	"reflect"
+	"sync/atomic"
 	"time"
 
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/apiserver/scope"
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
 // Reader reports changes made to data objects on the APIServer via the informers API.
 type Reader struct {
+	// informer is the factory for cluster scoped resources. It is also used for namespaced resources
+	// when namespaced is empty.
 	informer informers.SharedInformerFactory
-	indexes  []cache.SharedIndexInformer
-	handlers cache.ResourceEventHandlerFuncs
-	syncers  []cache.InformerSynced
+	// namespaced are the factories for namespaced resources, one for each namespace being watched.
+	namespaced []informers.SharedInformerFactory
+	scope      scope.Scope
+	transform  cache.TransformFunc
+	indexes    []cache.SharedIndexInformer
+	handlers   cache.ResourceEventHandlerDetailedFuncs
+	syncers    []cache.InformerSynced
+
+	// hashKey is used to key the hashes of Secret and ConfigMap values.
+	hashKey []byte
+
+	// dropInitialList, initialListOut and syncComplete control handling of the initial list.
+	dropInitialList bool
+	initialListOut  chan data.Entry
+	syncComplete    bool
+
+	// suppressResyncs, semanticResyncs and normalizers control handling of resync updates. suppressed
+	// counts the resyncs that were dropped.
+	suppressResyncs bool
+	semanticResyncs bool
+	normalizers     []data.Normalizer
+	suppressed      atomic.Uint64
 
 	ch      chan data.Entry
 	stop    chan struct{}
@@ -102,30 +155,226 @@ func WithLogger(log *slog.Logger) Option {
 	}
 }
 
+// WithHashKey sets the key used for the HMAC of Secret and ConfigMap values. By default a random key is
+// generated by New(), which means hashes can only be compared within the life of a Reader. Set this to
+// compare hashes across restarts or replicas.
+func WithHashKey(key []byte) Option {
+	return func(c *Reader) error {
+		if len(key) == 0 {
+			return fmt.Errorf("hash key cannot be empty")
+		}
+		c.hashKey = key
+		return nil
+	}
+}
+
+// WithNamespaces only watches namespaced resources in namespaces. This creates an informer per namespace for
+// each namespaced type. Cluster scoped resources, such as nodes, are not affected. Requires NewScoped().
+func WithNamespaces(namespaces ...string) Option {
+	return func(c *Reader) error {
+		c.scope.Namespaces = append(c.scope.Namespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithoutNamespaces does not watch namespaced resources in namespaces. Cluster scoped resources, such as
+// nodes, are not affected. This cannot be used with WithNamespaces(). Requires NewScoped().
+func WithoutNamespaces(namespaces ...string) Option {
+	return func(c *Reader) error {
+		c.scope.ExcludeNamespaces = append(c.scope.ExcludeNamespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithLabelSelector only watches resources that match the label selector sel. This applies to all types.
+// Requires NewScoped().
+func WithLabelSelector(sel string) Option {
+	return func(c *Reader) error {
+		c.scope.LabelSelector = sel
+		return nil
+	}
+}
+
+// WithFieldSelector only watches resources that match the field selector sel. This applies to all types, so
+// it must use fields every type supports, such as metadata.name. Requires NewScoped().
+func WithFieldSelector(sel string) Option {
+	return func(c *Reader) error {
+		c.scope.FieldSelector = sel
+		return nil
+	}
+}
+
+// WithoutInitialList drops the adds the informers send for objects that exist when the Reader starts, so
+// only changes made while the Reader is running are emitted. Cannot be used with WithInitialListOut().
+func WithoutInitialList() Option {
+	return func(c *Reader) error {
+		c.dropInitialList = true
+		return nil
+	}
+}
+
+// WithInitialListOut sends the adds the informers send for objects that exist when the Reader starts on
+// out instead of the output channel. out must be read from for Run() to complete. Cannot be used with
+// WithoutInitialList().
+func WithInitialListOut(out chan data.Entry) Option {
+	return func(c *Reader) error {
+		if out == nil {
+			return fmt.Errorf("initial list channel cannot be nil")
+		}
+		c.initialListOut = out
+		return nil
+	}
+}
+
+// WithSyncComplete emits a data.ETSyncComplete marker entry once all informers have sent their initial
+// list. It is sent on the output channel and, if set, the WithInitialListOut() channel.
+func WithSyncComplete() Option {
+	return func(c *Reader) error {
+		c.syncComplete = true
+		return nil
+	}
+}
+
+// WithoutResyncs drops updates that are resyncs, where the object's resourceVersion has not changed.
+// Informers send these for every object on each resync period and on relists that find nothing changed.
+// The number dropped is available from SuppressedResyncs(). Without this, resyncs are emitted with an
+// Origin of data.ORResync.
+func WithoutResyncs() Option {
+	return func(c *Reader) error {
+		c.suppressResyncs = true
+		return nil
+	}
+}
+
+// WithSemanticResyncs also treats updates as resyncs if the old and new objects are semantically equal
+// once their resourceVersion is cleared and normalizers, such as data.IgnoreManagedFields, are applied.
+// This copies both objects on every update.
+func WithSemanticResyncs(normalizers ...data.Normalizer) Option {
+	return func(c *Reader) error {
+		c.semanticResyncs = true
+		c.normalizers = append(c.normalizers, normalizers...)
+		return nil
+	}
+}
+
+// WithTransform installs fn with SetTransform() on every informer the Reader uses, before they are started.
+// fn is applied to objects before they are stored in the cache, so it shrinks both the cache and what flows
+// through the pipeline. See the transform package for a default. As informers from a factory are shared,
+// this also changes the objects seen by anything else using that factory. If an informer has already been
+// started, New() returns an error.
+func WithTransform(fn cache.TransformFunc) Option {
+	return func(c *Reader) error {
+		c.transform = fn
+		return nil
+	}
+}
+
 // RetrieveType is the type of data to retrieve. Uses as a bitwise flag.
 // So, like: RTNode | RTPod, or RTNode, or RTPod.
-type RetrieveType uint8
+type RetrieveType uint32
 
 const (
 	// RTNode retrieves node data.
-	RTNode RetrieveType = 0x0
+	RTNode RetrieveType = 0x1
 	// RTPod retrieves pod data.
-	RTPod RetrieveType = 0x1
+	RTPod RetrieveType = 0x2
 	// RTNamespace retrieves namespace data.
-	RTNamespace RetrieveType = 0x2
+	RTNamespace RetrieveType = 0x4
+	// RTDeployment retrieves apps/v1 deployment data.
+	RTDeployment RetrieveType = 0x8
+	// RTReplicaSet retrieves apps/v1 replica set data.
+	RTReplicaSet RetrieveType = 0x10
+	// RTStatefulSet retrieves apps/v1 stateful set data.
+	RTStatefulSet RetrieveType = 0x20
+	// RTDaemonSet retrieves apps/v1 daemon set data.
+	RTDaemonSet RetrieveType = 0x40
+	// RTJob retrieves batch/v1 job data.
+	RTJob RetrieveType = 0x80
+	// RTCronJob retrieves batch/v1 cron job data.
+	RTCronJob RetrieveType = 0x100
+	// RTService retrieves service data.
+	RTService RetrieveType = 0x200
+	// RTEndpointSlice retrieves discovery.k8s.io/v1 endpoint slice data.
+	RTEndpointSlice RetrieveType = 0x400
+	// RTRole retrieves rbac.authorization.k8s.io/v1 role data.
+	RTRole RetrieveType = 0x800
+	// RTClusterRole retrieves rbac.authorization.k8s.io/v1 cluster role data.
+	RTClusterRole RetrieveType = 0x1000
+	// RTRoleBinding retrieves rbac.authorization.k8s.io/v1 role binding data.
+	RTRoleBinding RetrieveType = 0x2000
+	// RTClusterRoleBinding retrieves rbac.authorization.k8s.io/v1 cluster role binding data.
+	RTClusterRoleBinding RetrieveType = 0x4000
+	// RTSecret retrieves secret metadata. Values are never emitted, see data.SecretMeta.
+	RTSecret RetrieveType = 0x8000
+	// RTConfigMap retrieves config map metadata. Values are never emitted, see data.ConfigMapMeta.
+	RTConfigMap RetrieveType = 0x10000
+	// RTNetworkPolicy retrieves networking.k8s.io/v1 network policy data.
+	RTNetworkPolicy RetrieveType = 0x20000
+	// RTIngress retrieves networking.k8s.io/v1 ingress data.
+	RTIngress RetrieveType = 0x40000
+	// RTIngressClass retrieves networking.k8s.io/v1 ingress class data.
+	RTIngressClass RetrieveType = 0x80000
+	// RTValidatingWebhookConfiguration retrieves admissionregistration.k8s.io/v1 validating webhook configuration data.
+	RTValidatingWebhookConfiguration RetrieveType = 0x100000
+	// RTMutatingWebhookConfiguration retrieves admissionregistration.k8s.io/v1 mutating webhook configuration data.
+	RTMutatingWebhookConfiguration RetrieveType = 0x200000
+	// RTValidatingAdmissionPolicy retrieves admissionregistration.k8s.io/v1 validating admission policy data.
+	RTValidatingAdmissionPolicy RetrieveType = 0x400000
+	// RTServiceAccount retrieves service account data.
+	RTServiceAccount RetrieveType = 0x800000
 )
 
 // New creates a new Changes object. retrieveTypes is a bitwise flag to determine what data to retrieve.
+// informer watches the whole cluster, use NewScoped() to watch only some namespaces or labels.
 func New(ctx context.Context, informer informers.SharedInformerFactory, retrieveTypes RetrieveType, opts ...Option) (*Reader, error) {
 	if informer == nil {
 		return nil, fmt.Errorf("informer is nil")
 	}
 
+	c, err := newReader(opts)
+	if err != nil {
+		return nil, err
+	}
+	if !c.scope.IsZero() {
+		return nil, fmt.Errorf("namespace, label and field selector options require NewScoped()")
+	}
+	c.informer = informer
+
+	if err := c.setup(retrieveTypes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewScoped creates a new Changes object like New(), but creates its own informer factories from clientset
+// so that the WithNamespaces(), WithoutNamespaces(), WithLabelSelector() and WithFieldSelector() options
+// can be applied.
+func NewScoped(ctx context.Context, clientset kubernetes.Interface, resync time.Duration, retrieveTypes RetrieveType, opts ...Option) (*Reader, error) {
+	if clientset == nil {
+		return nil, fmt.Errorf("clientset is nil")
+	}
+
+	c, err := newReader(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.scope.Validate(); err != nil {
+		return nil, err
+	}
+	c.informer, c.namespaced = c.scope.Factories(clientset, resync)
+
+	if err := c.setup(retrieveTypes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newReader creates a Reader with opts applied.
+func newReader(opts []Option) (*Reader, error) {
 	c := &Reader{
-		informer: informer,
-		stop:     make(chan struct{}),
+		stop: make(chan struct{}),
 	}
-	c.handlers = cache.ResourceEventHandlerFuncs{
+	c.handlers = cache.ResourceEventHandlerDetailedFuncs{
 		AddFunc:    c.addHandler,
 		UpdateFunc: c.updateHandler,
 		DeleteFunc: c.deleteHandler,
@@ -137,42 +386,74 @@ func New(ctx context.Context, informer informers.SharedInformerFactory, retrieve
 		}
 	}
 
+	if c.dropInitialList && c.initialListOut != nil {
+		return nil, fmt.Errorf("cannot use both WithoutInitialList() and WithInitialListOut()")
+	}
 	if c.log == nil {
 		c.log = slog.Default()
 	}
-
-	if retrieveTypes&RTNode != RTNode && retrieveTypes&RTPod != RTPod && retrieveTypes&RTNamespace != RTNamespace {
-		return nil, fmt.Errorf("no data types to retrieve")
-	}
-
-	c.syncers = make([]cache.InformerSynced, 0, 2)
-	if retrieveTypes&RTNode == RTNode {
-		s, err := c.nodeInform()
-		if err != nil {
-			return nil, err
+	if c.hashKey == nil {
+		c.hashKey = make([]byte, 32)
+		if _, err := rand.Read(c.hashKey); err != nil {
+			return nil, fmt.Errorf("could not generate hash key: %w", err)
 		}
-		c.syncers = append(c.syncers, s)
 	}
+	return c, nil
+}
 
-	if retrieveTypes&RTPod == RTPod {
-		s, err := c.podInform()
-		if err != nil {
-			return nil, err
+// setup creates the informers for retrieveTypes.
+func (c *Reader) setup(retrieveTypes RetrieveType) error {
+	informs := []struct {
+		rt     RetrieveType
+		inform func() (cache.InformerSynced, error)
+	}{
+		{RTNode, c.nodeInform},
+		{RTPod, c.podInform},
+		{RTNamespace, c.namespaceInform},
+		{RTDeployment, c.deploymentInform},
+		{RTReplicaSet, c.replicaSetInform},
+		{RTStatefulSet, c.statefulSetInform},
+		{RTDaemonSet, c.daemonSetInform},
+		{RTJob, c.jobInform},
+		{RTCronJob, c.cronJobInform},
+		{RTService, c.serviceInform},
+		{RTEndpointSlice, c.endpointSliceInform},
+		{RTRole, c.roleInform},
+		{RTClusterRole, c.clusterRoleInform},
+		{RTRoleBinding, c.roleBindingInform},
+		{RTClusterRoleBinding, c.clusterRoleBindingInform},
+		{RTSecret, c.secretInform},
+		{RTConfigMap, c.configMapInform},
+		{RTNetworkPolicy, c.networkPolicyInform},
+		{RTIngress, c.ingressInform},
+		{RTIngressClass, c.ingressClassInform},
+		{RTValidatingWebhookConfiguration, c.validatingWebhookInform},
+		{RTMutatingWebhookConfiguration, c.mutatingWebhookInform},
+		{RTValidatingAdmissionPolicy, c.validatingAdmissionPolicyInform},
+		{RTServiceAccount, c.serviceAccountInform},
+	}
+
+	c.syncers = make([]cache.InformerSynced, 0, len(informs))
+	for _, i := range informs {
+		if retrieveTypes&i.rt != i.rt {
+			continue
 		}
-		c.syncers = append(c.syncers, s)
-	}
-
-	if retrieveTypes&RTNamespace == RTNamespace {
-		s, err := c.namespaceInform()
+		s, err := i.inform()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		c.syncers = append(c.syncers, s)
 	}
 
-	return c, nil
+	if len(c.syncers) == 0 {
+		return fmt.Errorf("no data types to retrieve")
+	}
+	return nil
 }
 
+// readerName is the name of the Reader returned by Name() and used in data.SyncComplete markers.
+const readerName = "informers"
+
 var closeDelay = 100 * time.Millisecond
 
 // Close closes the Changes object. This will block until all indexes are stopped.
@@ -203,6 +484,9 @@ func (c *Reader) Run(ctx context.Context) error {
 		return fmt.Errorf("cannot call Run if SetOut has not been called(%v)", c.ch)
 	}
 	c.informer.Start(c.stop)
+	for _, f := range c.namespaced {
+		f.Start(c.stop)
+	}
 
 	if !cache.WaitForCacheSync(c.stop, c.syncers...) {
 		c.started = false
@@ -211,9 +495,20 @@ func (c *Reader) Run(ctx context.Context) error {
 	}
 	c.started = true
 
+	if c.syncComplete {
+		if err := c.sendSyncComplete(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Name returns the name of the Reader, which the tattler Runner puts in data.Entry.Reader.
+func (c *Reader) Name() string {
+	return readerName
+}
+
 // SetOut sets the output channel for data to flow out on.
 func (c *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
 	if c.started {
@@ -225,40 +520,208 @@ func (c *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
 
 // nodeInform sets up the node informer.
 func (c *Reader) nodeInform() (cache.InformerSynced, error) {
-	nodeInformer := c.informer.Core().V1().Nodes().Informer()
-	reg, err := nodeInformer.AddEventHandler(c.handlers)
-	if err != nil {
-		return nil, err
-	}
-	c.indexes = append(c.indexes, nodeInformer)
-	return reg.HasSynced, nil
+	return c.inform(c.informer.Core().V1().Nodes().Informer())
 }
 
 // podInform sets up the pod informer.
 func (c *Reader) podInform() (cache.InformerSynced, error) {
-	podInformer := c.informer.Core().V1().Pods().Informer()
-	reg, err := podInformer.AddEventHandler(c.handlers)
-	if err != nil {
-		return nil, err
-	}
-	c.indexes = append(c.indexes, podInformer)
-	return reg.HasSynced, nil
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	})
 }
 
 // namespaceInform sets up the namespace informer.
 func (c *Reader) namespaceInform() (cache.InformerSynced, error) {
-	namespaceInformer := c.informer.Core().V1().Namespaces().Informer()
-	reg, err := namespaceInformer.AddEventHandler(c.handlers)
+	return c.inform(c.informer.Core().V1().Namespaces().Informer())
+}
+
+// deploymentInform sets up the deployment informer.
+func (c *Reader) deploymentInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	})
+}
+
+// replicaSetInform sets up the replica set informer.
+func (c *Reader) replicaSetInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().ReplicaSets().Informer()
+	})
+}
+
+// statefulSetInform sets up the stateful set informer.
+func (c *Reader) statefulSetInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	})
+}
+
+// daemonSetInform sets up the daemon set informer.
+func (c *Reader) daemonSetInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	})
+}
+
+// jobInform sets up the job informer.
+func (c *Reader) jobInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	})
+}
+
+// cronJobInform sets up the cron job informer.
+func (c *Reader) cronJobInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().CronJobs().Informer()
+	})
+}
+
+// serviceInform sets up the service informer.
+func (c *Reader) serviceInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Services().Informer()
+	})
+}
+
+// endpointSliceInform sets up the endpoint slice informer.
+func (c *Reader) endpointSliceInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Discovery().V1().EndpointSlices().Informer()
+	})
+}
+
+// roleInform sets up the role informer.
+func (c *Reader) roleInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Rbac().V1().Roles().Informer()
+	})
+}
+
+// clusterRoleInform sets up the cluster role informer.
+func (c *Reader) clusterRoleInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Rbac().V1().ClusterRoles().Informer())
+}
+
+// roleBindingInform sets up the role binding informer.
+func (c *Reader) roleBindingInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Rbac().V1().RoleBindings().Informer()
+	})
+}
+
+// clusterRoleBindingInform sets up the cluster role binding informer.
+func (c *Reader) clusterRoleBindingInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Rbac().V1().ClusterRoleBindings().Informer())
+}
+
+// secretInform sets up the secret informer.
+func (c *Reader) secretInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Secrets().Informer()
+	})
+}
+
+// configMapInform sets up the config map informer.
+func (c *Reader) configMapInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ConfigMaps().Informer()
+	})
+}
+
+// networkPolicyInform sets up the network policy informer.
+func (c *Reader) networkPolicyInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Networking().V1().NetworkPolicies().Informer()
+	})
+}
+
+// ingressInform sets up the ingress informer.
+func (c *Reader) ingressInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Networking().V1().Ingresses().Informer()
+	})
+}
+
+// ingressClassInform sets up the ingress class informer.
+func (c *Reader) ingressClassInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Networking().V1().IngressClasses().Informer())
+}
+
+// validatingWebhookInform sets up the validating webhook configuration informer.
+func (c *Reader) validatingWebhookInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Admissionregistration().V1().ValidatingWebhookConfigurations().Informer())
+}
+
+// mutatingWebhookInform sets up the mutating webhook configuration informer.
+func (c *Reader) mutatingWebhookInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Admissionregistration().V1().MutatingWebhookConfigurations().Informer())
+}
+
+// validatingAdmissionPolicyInform sets up the validating admission policy informer.
+func (c *Reader) validatingAdmissionPolicyInform() (cache.InformerSynced, error) {
+	return c.inform(c.informer.Admissionregistration().V1().ValidatingAdmissionPolicies().Informer())
+}
+
+// serviceAccountInform sets up the service account informer.
+func (c *Reader) serviceAccountInform() (cache.InformerSynced, error) {
+	return c.informNamespaced(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ServiceAccounts().Informer()
+	})
+}
+
+// informNamespaced registers our handlers with the informer from get() for each namespaced factory. The
+// returned InformerSynced is true once all of them have synced.
+func (c *Reader) informNamespaced(get func(informers.SharedInformerFactory) cache.SharedIndexInformer) (cache.InformerSynced, error) {
+	factories := c.namespaced
+	if len(factories) == 0 {
+		factories = []informers.SharedInformerFactory{c.informer}
+	}
+
+	syncers := make([]cache.InformerSynced, 0, len(factories))
+	for _, f := range factories {
+		s, err := c.inform(get(f))
+		if err != nil {
+			return nil, err
+		}
+		syncers = append(syncers, s)
+	}
+	if len(syncers) == 1 {
+		return syncers[0], nil
+	}
+	return func() bool {
+		for _, s := range syncers {
+			if !s() {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// inform registers our handlers with an informer and records the informer so that Close() can
+// wait for it to stop.
+func (c *Reader) inform(informer cache.SharedIndexInformer) (cache.InformerSynced, error) {
+	if c.transform != nil {
+		if err := informer.SetTransform(c.transform); err != nil {
+			return nil, fmt.Errorf("could not set transform: %w", err)
+		}
+	}
+	reg, err := informer.AddEventHandler(c.handlers)
 	if err != nil {
 		return nil, err
 	}
-	c.indexes = append(c.indexes, namespaceInformer)
+	c.indexes = append(c.indexes, informer)
 	return reg.HasSynced, nil
 }
 
 // addHandler is the event handler for adding data. This is a shim around addOrDelete.
-func (c *Reader) addHandler(obj any) {
-	err := c.addOrDelete(obj, data.CTAdd)
+func (c *Reader) addHandler(obj any, isInInitialList bool) {
+	origin := data.ORWatch
+	if isInInitialList {
+		origin = data.ORInitialList
+	}
+	err := c.addOrDelete(obj, data.CTAdd, origin)
 	if err != nil {
 		c.log.Error(err.Error())
 	}
@@ -273,74 +736,97 @@ func (c *Reader) updateHandler(oldObj any, newObj any) {
 
 // deleteHandler is the event handler for deleting data. This is a shim around addOrDelete.
 func (c *Reader) deleteHandler(obj any) {
-	err := c.addOrDelete(obj, data.CTDelete)
+	err := c.addOrDelete(obj, data.CTDelete, data.ORWatch)
 	if err != nil {
 		c.log.Error(err.Error())
 	}
 }
 
-// addOrDelete handles event types add and delete.
-func (c *Reader) addOrDelete(obj any, ct data.ChangeType) error {
+// addOrDelete handles event types add and delete. origin is set on the emitted entry.
+func (c *Reader) addOrDelete(obj any, ct data.ChangeType, origin data.Origin) error {
 	if obj == nil {
 		return fmt.Errorf("Changes.addOrDelete(): obj cannot be nil")
 	}
 
+	// When a watch misses a delete, the relist finds the object gone and the informer sends a tombstone
+	// holding the last state it had.
+	var flags data.ChangeFlag
+	if t, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if ct != data.CTDelete {
+			return fmt.Errorf("Changes.addOrDelete(): tombstone for %s received for change type %v", t.Key, ct)
+		}
+		if t.Obj == nil {
+			return fmt.Errorf("Changes.addOrDelete(): tombstone for %s has no object", t.Key)
+		}
+		obj = t.Obj
+		flags = data.CFStaleFinalState
+	}
+
 	var d data.Informer
+	var err error
 	switch v := obj.(type) {
 	case *corev1.Node:
-		node := data.Change[*corev1.Node]{ChangeType: ct, ObjectType: data.OTNode}
-		switch ct {
-		case data.CTAdd:
-			node.New = v
-		case data.CTDelete:
-			node.Old = v
-		default:
-			return fmt.Errorf("unsupported change type in Changes.addOrDelete(): %d", ct)
-		}
-		var err error
-		d, err = data.NewInformer(node)
-		if err != nil {
-			return err
-		}
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTNode)
 	case *corev1.Pod:
-		d.Type = data.OTPod
-		pod := data.Change[*corev1.Pod]{ChangeType: ct, ObjectType: data.OTPod}
-		switch ct {
-		case data.CTAdd:
-			pod.New = v
-		case data.CTDelete:
-			pod.Old = v
-		default:
-			return fmt.Errorf("unsupported change type in Changes.addOrDelete(): %d", ct)
-		}
-		var err error
-		d, err = data.NewInformer(pod)
-		if err != nil {
-			return err
-		}
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTPod)
 	case *corev1.Namespace:
-		ns := data.Change[*corev1.Namespace]{ChangeType: ct, ObjectType: data.OTNamespace}
-		switch ct {
-		case data.CTAdd:
-			ns.New = v
-		case data.CTDelete:
-			ns.Old = v
-		}
-		var err error
-		d, err = data.NewInformer(ns)
-		if err != nil {
-			return err
-		}
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTNamespace)
+	case *appsv1.Deployment:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTDeployment)
+	case *appsv1.ReplicaSet:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTReplicaSet)
+	case *appsv1.StatefulSet:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTStatefulSet)
+	case *appsv1.DaemonSet:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTDaemonSet)
+	case *batchv1.Job:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTJob)
+	case *batchv1.CronJob:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTCronJob)
+	case *corev1.Service:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTService)
+	case *discoveryv1.EndpointSlice:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTEndpointSlice)
+	case *rbacv1.Role:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTRole)
+	case *rbacv1.ClusterRole:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTClusterRole)
+	case *rbacv1.RoleBinding:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTRoleBinding)
+	case *rbacv1.ClusterRoleBinding:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTClusterRoleBinding)
+	case *corev1.Secret:
+		d, err = addOrDeleteInformer(data.NewSecretMeta(v, c.hashKey), ct, flags, data.OTSecret, data.SecretFlags)
+	case *corev1.ConfigMap:
+		d, err = addOrDeleteInformer(data.NewConfigMapMeta(v, c.hashKey), ct, flags, data.OTConfigMap)
+	case *networkingv1.NetworkPolicy:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTNetworkPolicy)
+	case *networkingv1.Ingress:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTIngress)
+	case *networkingv1.IngressClass:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTIngressClass)
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTValidatingWebhookConfiguration, data.ValidatingWebhookFlags)
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTMutatingWebhookConfiguration, data.MutatingWebhookFlags)
+	case *admissionregistrationv1.ValidatingAdmissionPolicy:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTValidatingAdmissionPolicy, data.ValidatingAdmissionPolicyFlags)
+	case *corev1.ServiceAccount:
+		d, err = addOrDeleteInformer(v, ct, flags, data.OTServiceAccount, data.ServiceAccountFlags)
 	default:
 		return fmt.Errorf("unknown object type: %T", obj)
 	}
+	if err != nil {
+		return err
+	}
 
 	e, err := data.NewEntry(d)
 	if err != nil {
 		return err
 	}
+	e.Origin = origin
 
-	c.ch <- e
+	c.send(e)
 	return nil
 }
 
@@ -357,55 +843,173 @@ func (c *Reader) update(oldObj any, newObj any) error {
 		return fmt.Errorf("Changes.update(): oldObj(%T) and newObj(%T) are not the same type", oldObj, newObj)
 	}
 
+	origin := c.updateOrigin(oldObj, newObj)
+	if origin == data.ORResync && c.suppressResyncs {
+		c.suppressed.Add(1)
+		return nil
+	}
+
 	var d data.Informer
+	var err error
 	switch v := newObj.(type) {
 	case *corev1.Node:
-		node := data.Change[*corev1.Node]{
-			ChangeType: data.CTUpdate,
-			ObjectType: data.OTNode,
-			New:        v,
-			Old:        oldObj.(*corev1.Node),
-		}
-
-		var err error
-		d, err = data.NewInformer(node)
-		if err != nil {
-			return err
-		}
+		d, err = updateInformer(oldObj.(*corev1.Node), v, data.OTNode)
 	case *corev1.Pod:
-		pod := data.Change[*corev1.Pod]{
-			ChangeType: data.CTUpdate,
-			ObjectType: data.OTPod,
-			New:        v,
-			Old:        oldObj.(*corev1.Pod),
-		}
-		var err error
-		d, err = data.NewInformer(pod)
-		if err != nil {
-			return err
-		}
+		d, err = updateInformer(oldObj.(*corev1.Pod), v, data.OTPod)
 	case *corev1.Namespace:
-		ns := data.Change[*corev1.Namespace]{
-			ChangeType: data.CTUpdate,
-			ObjectType: data.OTNamespace,
-			New:        v,
-			Old:        oldObj.(*corev1.Namespace),
-		}
-
-		var err error
-		d, err = data.NewInformer(ns)
-		if err != nil {
-			return err
-		}
+		d, err = updateInformer(oldObj.(*corev1.Namespace), v, data.OTNamespace)
+	case *appsv1.Deployment:
+		d, err = updateInformer(oldObj.(*appsv1.Deployment), v, data.OTDeployment)
+	case *appsv1.ReplicaSet:
+		d, err = updateInformer(oldObj.(*appsv1.ReplicaSet), v, data.OTReplicaSet)
+	case *appsv1.StatefulSet:
+		d, err = updateInformer(oldObj.(*appsv1.StatefulSet), v, data.OTStatefulSet)
+	case *appsv1.DaemonSet:
+		d, err = updateInformer(oldObj.(*appsv1.DaemonSet), v, data.OTDaemonSet)
+	case *batchv1.Job:
+		d, err = updateInformer(oldObj.(*batchv1.Job), v, data.OTJob)
+	case *batchv1.CronJob:
+		d, err = updateInformer(oldObj.(*batchv1.CronJob), v, data.OTCronJob)
+	case *corev1.Service:
+		d, err = updateInformer(oldObj.(*corev1.Service), v, data.OTService)
+	case *discoveryv1.EndpointSlice:
+		d, err = updateInformer(oldObj.(*discoveryv1.EndpointSlice), v, data.OTEndpointSlice)
+	case *rbacv1.Role:
+		d, err = updateInformer(oldObj.(*rbacv1.Role), v, data.OTRole)
+	case *rbacv1.ClusterRole:
+		d, err = updateInformer(oldObj.(*rbacv1.ClusterRole), v, data.OTClusterRole)
+	case *rbacv1.RoleBinding:
+		d, err = updateInformer(oldObj.(*rbacv1.RoleBinding), v, data.OTRoleBinding)
+	case *rbacv1.ClusterRoleBinding:
+		d, err = updateInformer(oldObj.(*rbacv1.ClusterRoleBinding), v, data.OTClusterRoleBinding)
+	case *corev1.Secret:
+		d, err = updateInformer(
+			data.NewSecretMeta(oldObj.(*corev1.Secret), c.hashKey),
+			data.NewSecretMeta(v, c.hashKey),
+			data.OTSecret,
+			data.SecretFlags,
+		)
+	case *corev1.ConfigMap:
+		d, err = updateInformer(
+			data.NewConfigMapMeta(oldObj.(*corev1.ConfigMap), c.hashKey),
+			data.NewConfigMapMeta(v, c.hashKey),
+			data.OTConfigMap,
+		)
+	case *networkingv1.NetworkPolicy:
+		d, err = updateInformer(oldObj.(*networkingv1.NetworkPolicy), v, data.OTNetworkPolicy)
+	case *networkingv1.Ingress:
+		d, err = updateInformer(oldObj.(*networkingv1.Ingress), v, data.OTIngress)
+	case *networkingv1.IngressClass:
+		d, err = updateInformer(oldObj.(*networkingv1.IngressClass), v, data.OTIngressClass)
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		d, err = updateInformer(oldObj.(*admissionregistrationv1.ValidatingWebhookConfiguration), v, data.OTValidatingWebhookConfiguration, data.ValidatingWebhookFlags)
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		d, err = updateInformer(oldObj.(*admissionregistrationv1.MutatingWebhookConfiguration), v, data.OTMutatingWebhookConfiguration, data.MutatingWebhookFlags)
+	case *admissionregistrationv1.ValidatingAdmissionPolicy:
+		d, err = updateInformer(oldObj.(*admissionregistrationv1.ValidatingAdmissionPolicy), v, data.OTValidatingAdmissionPolicy, data.ValidatingAdmissionPolicyFlags)
+	case *corev1.ServiceAccount:
+		d, err = updateInformer(oldObj.(*corev1.ServiceAccount), v, data.OTServiceAccount, data.ServiceAccountFlags)
 	default:
 		return fmt.Errorf("unknown object type: %T", newObj)
 	}
+	if err != nil {
+		return err
+	}
 
 	e, err := data.NewEntry(d)
 	if err != nil {
 		return err
 	}
+	e.Origin = origin
+
+	c.send(e)
+	return nil
+}
 
+// updateOrigin returns the data.Origin of an update. Updates are resyncs if the resourceVersion did not
+// change or, with WithSemanticResyncs(), the objects are semantically equal.
+func (c *Reader) updateOrigin(oldObj, newObj any) data.Origin {
+	origin := data.UpdateOrigin(oldObj, newObj)
+	if origin == data.ORResync || !c.semanticResyncs {
+		return origin
+	}
+	o, ok := oldObj.(runtime.Object)
+	if !ok {
+		return origin
+	}
+	n, ok := newObj.(runtime.Object)
+	if !ok {
+		return origin
+	}
+	if data.SemanticEqual(o, n, c.normalizers...) {
+		return data.ORResync
+	}
+	return origin
+}
+
+// SuppressedResyncs returns the number of resync updates dropped because of WithoutResyncs().
+func (c *Reader) SuppressedResyncs() uint64 {
+	return c.suppressed.Load()
+}
+
+// sendSyncComplete sends a data.ETSyncComplete marker on the output channel and the initial list channel.
+func (c *Reader) sendSyncComplete() error {
+	e, err := data.NewEntry(data.NewSyncComplete(readerName, time.Now()))
+	if err != nil {
+		return err
+	}
+	if c.initialListOut != nil {
+		c.initialListOut <- e
+	}
 	c.ch <- e
 	return nil
 }
+
+// send sets when e was observed and sends it on the output channel. Entries from the initial list are instead dropped or sent on the
+// initial list channel if WithoutInitialList() or WithInitialListOut() were used.
+func (c *Reader) send(e data.Entry) {
+	e.Observed = time.Now()
+	if e.Origin == data.ORInitialList {
+		switch {
+		case c.dropInitialList:
+			return
+		case c.initialListOut != nil:
+			c.initialListOut <- e
+			return
+		}
+	}
+	c.ch <- e
+}
+
+// addOrDeleteInformer wraps obj in a data.Change of type ct with flags and returns it as a data.Informer.
+// Each annotate func adds its data.ChangeFlags to the change.
+func addOrDeleteInformer[T data.K8Object](obj T, ct data.ChangeType, flags data.ChangeFlag, ot data.ObjectType, annotate ...func(data.Change[T]) data.ChangeFlag) (data.Informer, error) {
+	change := data.Change[T]{ChangeType: ct, ObjectType: ot, Flags: flags}
+	switch ct {
+	case data.CTAdd:
+		change.New = obj
+	case data.CTDelete:
+		change.Old = obj
+	default:
+		return data.Informer{}, fmt.Errorf("unsupported change type in Changes.addOrDelete(): %d", ct)
+	}
+	for _, a := range annotate {
+		change.Flags |= a(change)
+	}
+	return data.NewInformer(change)
+}
+
+// updateInformer wraps oldObj and newObj in an update data.Change and returns it as a data.Informer.
+// Each annotate func adds its data.ChangeFlags to the change.
+func updateInformer[T data.K8Object](oldObj, newObj T, ot data.ObjectType, annotate ...func(data.Change[T]) data.ChangeFlag) (data.Informer, error) {
+	change := data.Change[T]{
+		ChangeType: data.CTUpdate,
+		ObjectType: ot,
+		New:        newObj,
+		Old:        oldObj,
+	}
+	for _, a := range annotate {
+		change.Flags |= a(change)
+	}
+	return data.NewInformer(change)
+}
@@ -2,15 +2,28 @@ package informers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"maps"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/apiserver/transform"
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 
 	"github.com/kylelemons/godebug/pretty"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -53,6 +66,163 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		factory       informers.SharedInformerFactory
+		retrieveTypes RetrieveType
+		wantIndexes   int
+		wantErr       bool
+	}{
+		{
+			name:    "Error: informer is nil",
+			wantErr: true,
+		},
+		{
+			name:    "Error: no retrieve types",
+			factory: NewFakeInformer(fakeInformerArgs{}),
+			wantErr: true,
+		},
+		{
+			name: "Success: node only",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					nodes: &fakeSharedIndexInformer{},
+				},
+			),
+			retrieveTypes: RTNode,
+			wantIndexes:   1,
+		},
+		{
+			name: "Success: workloads",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					deployments:  &fakeSharedIndexInformer{},
+					replicaSets:  &fakeSharedIndexInformer{},
+					statefulSets: &fakeSharedIndexInformer{},
+					daemonSets:   &fakeSharedIndexInformer{},
+				},
+			),
+			retrieveTypes: RTDeployment | RTReplicaSet | RTStatefulSet | RTDaemonSet,
+			wantIndexes:   4,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := New(context.Background(), test.factory, test.retrieveTypes)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestNew(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if len(c.indexes) != test.wantIndexes {
+			t.Errorf("TestNew(%s): got len(indexes) == %d, want %d", test.name, len(c.indexes), test.wantIndexes)
+		}
+		if len(c.syncers) != test.wantIndexes {
+			t.Errorf("TestNew(%s): got len(syncers) == %d, want %d", test.name, len(c.syncers), test.wantIndexes)
+		}
+	}
+}
+
+func TestNewScoped(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		retrieveTypes RetrieveType
+		wantIndexes   int
+		wantErr       bool
+	}{
+		{
+			name:          "Cluster wide",
+			retrieveTypes: RTNode | RTPod,
+			wantIndexes:   2,
+		},
+		{
+			name:          "Namespace allowlist creates an informer per namespace for namespaced types",
+			opts:          []Option{WithNamespaces("a", "b"), WithLabelSelector("app=web")},
+			retrieveTypes: RTNode | RTPod | RTSecret,
+			wantIndexes:   5,
+		},
+		{
+			name:          "Namespace denylist",
+			opts:          []Option{WithoutNamespaces("kube-system")},
+			retrieveTypes: RTPod,
+			wantIndexes:   1,
+		},
+		{
+			name:          "Error: allowlist and denylist",
+			opts:          []Option{WithNamespaces("a"), WithoutNamespaces("b")},
+			retrieveTypes: RTPod,
+			wantErr:       true,
+		},
+		{
+			name:          "Error: bad field selector",
+			opts:          []Option{WithFieldSelector("metadata.name")},
+			retrieveTypes: RTPod,
+			wantErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := NewScoped(context.Background(), fake.NewSimpleClientset(), 0, test.retrieveTypes, test.opts...)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestNewScoped(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestNewScoped(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if len(c.indexes) != test.wantIndexes {
+			t.Errorf("TestNewScoped(%s): got len(indexes) == %d, want %d", test.name, len(c.indexes), test.wantIndexes)
+		}
+	}
+
+	// Scoping options cannot be applied to a factory we did not create.
+	_, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: &fakeSharedIndexInformer{}}), RTPod, WithNamespaces("a"))
+	if err == nil {
+		t.Errorf("TestNewScoped(New with scope): got err == nil, want err != nil")
+	}
+}
+
+func TestWithTransform(t *testing.T) {
+	t.Parallel()
+
+	fn := transform.Default()
+
+	pods := &fakeSharedIndexInformer{}
+	if _, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: pods}), RTPod, WithTransform(fn)); err != nil {
+		t.Fatalf("TestWithTransform(success): got err == %v, want err == nil", err)
+	}
+	if pods.transform == nil {
+		t.Errorf("TestWithTransform(success): got transform == nil, want transform != nil")
+	}
+
+	started := &fakeSharedIndexInformer{transformErr: errors.New("informer has already started")}
+	if _, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: started}), RTPod, WithTransform(fn)); err == nil {
+		t.Errorf("TestWithTransform(started): got err == nil, want err != nil")
+	}
+
+	// Without the option the informer is left alone.
+	plain := &fakeSharedIndexInformer{transformErr: errors.New("should not be called")}
+	if _, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: plain}), RTPod); err != nil {
+		t.Errorf("TestWithTransform(no option): got err == %v, want err == nil", err)
+	}
+}
+
 func TestTypeInform(t *testing.T) {
 	t.Parallel()
 
@@ -125,138 +295,1078 @@ func TestTypeInform(t *testing.T) {
 				},
 			),
 		},
-	}
-
-	for _, test := range tests {
-		c := &Reader{informer: test.factory}
-		c.handlers = cache.ResourceEventHandlerFuncs{
-			AddFunc:    c.addHandler,
-			UpdateFunc: c.updateHandler,
-			DeleteFunc: c.deleteHandler,
-		}
-
-		var hasSynced cache.InformerSynced
-		var err error
-		switch test.call {
-		case "Namespace":
-			hasSynced, err = c.namespaceInform()
-		case "Node":
-			hasSynced, err = c.nodeInform()
-		case "Pod":
-			hasSynced, err = c.podInform()
-		default:
-			panic("unknown call")
-		}
-		switch {
-		case test.wantErr && err == nil:
-			t.Errorf("TestNamespaceInform(%s): got err == nil, want err != nil", test.name)
-			continue
-		case !test.wantErr && err != nil:
-			t.Errorf("TestNamespaceInform(%s): got err == %v, want err == nil", test.name, err)
-			continue
-		case err != nil:
-			continue
-		}
-
-		if !hasSynced() {
-			t.Errorf("TestNamespaceInform(%s): got hasSynced == false, want hasSynced == true", test.name)
-		}
-		if len(c.indexes) == 0 {
-			t.Errorf("TestNamespaceInform(%s): got len(indexes) == 0, want len(indexes) > 0", test.name)
-		}
-	}
-}
-
-func TestAddOrDelete(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		name    string
-		obj     any
-		ct      data.ChangeType
-		want    data.Informer
-		wantErr bool
-	}{
 		{
-			name:    "Error: obj is nil",
-			ct:      data.CTAdd,
+			name: "Error: Deployment EventHandler returns error",
+			call: "Deployment",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					deployments: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
 			wantErr: true,
 		},
 		{
-			name:    "Error: unsupported type",
-			obj:     &corev1.NodeAddress{},
-			ct:      data.CTAdd,
+			name: "Deployment Success",
+			call: "Deployment",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					deployments: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ReplicaSet EventHandler returns error",
+			call: "ReplicaSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					replicaSets: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
 			wantErr: true,
 		},
 		{
-			name: "Node add",
-			obj:  &corev1.Node{},
-			ct:   data.CTAdd,
-			want: data.MustNewInformer(
-				data.Change[*corev1.Node]{
-					ChangeType: data.CTAdd,
-					ObjectType: data.OTNode,
-					New:        &corev1.Node{},
+			name: "ReplicaSet Success",
+			call: "ReplicaSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					replicaSets: &fakeSharedIndexInformer{},
 				},
 			),
 		},
 		{
-			name: "Pod add",
-			obj:  &corev1.Pod{},
-			ct:   data.CTAdd,
-			want: data.MustNewInformer(
-				data.Change[*corev1.Pod]{
-					ChangeType: data.CTAdd,
-					ObjectType: data.OTPod,
-					New:        &corev1.Pod{},
+			name: "Error: StatefulSet EventHandler returns error",
+			call: "StatefulSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					statefulSets: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
 				},
 			),
+			wantErr: true,
 		},
 		{
-			name: "Namespace add",
-			obj:  &corev1.Namespace{},
-			ct:   data.CTAdd,
-			want: data.MustNewInformer(
-				data.Change[*corev1.Namespace]{
-					ChangeType: data.CTAdd,
-					ObjectType: data.OTNamespace,
-					New:        &corev1.Namespace{},
+			name: "StatefulSet Success",
+			call: "StatefulSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					statefulSets: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: DaemonSet EventHandler returns error",
+			call: "DaemonSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					daemonSets: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "DaemonSet Success",
+			call: "DaemonSet",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					daemonSets: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: Job EventHandler returns error",
+			call: "Job",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					jobs: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "Job Success",
+			call: "Job",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					jobs: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: CronJob EventHandler returns error",
+			call: "CronJob",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					cronJobs: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "CronJob Success",
+			call: "CronJob",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					cronJobs: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: Service EventHandler returns error",
+			call: "Service",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					services: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "Service Success",
+			call: "Service",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					services: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: EndpointSlice EventHandler returns error",
+			call: "EndpointSlice",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					endpointSlices: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "EndpointSlice Success",
+			call: "EndpointSlice",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					endpointSlices: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: Role EventHandler returns error",
+			call: "Role",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					roles: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "Role Success",
+			call: "Role",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					roles: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ClusterRole EventHandler returns error",
+			call: "ClusterRole",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					clusterRoles: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ClusterRole Success",
+			call: "ClusterRole",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					clusterRoles: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: RoleBinding EventHandler returns error",
+			call: "RoleBinding",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					roleBindings: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "RoleBinding Success",
+			call: "RoleBinding",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					roleBindings: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ClusterRoleBinding EventHandler returns error",
+			call: "ClusterRoleBinding",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					clusterRoleBindings: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ClusterRoleBinding Success",
+			call: "ClusterRoleBinding",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					clusterRoleBindings: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: Secret EventHandler returns error",
+			call: "Secret",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					secrets: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "Secret Success",
+			call: "Secret",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					secrets: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ConfigMap EventHandler returns error",
+			call: "ConfigMap",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					configMaps: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ConfigMap Success",
+			call: "ConfigMap",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					configMaps: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: NetworkPolicy EventHandler returns error",
+			call: "NetworkPolicy",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					networkPolicies: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "NetworkPolicy Success",
+			call: "NetworkPolicy",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					networkPolicies: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: Ingress EventHandler returns error",
+			call: "Ingress",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					ingresses: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "Ingress Success",
+			call: "Ingress",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					ingresses: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: IngressClass EventHandler returns error",
+			call: "IngressClass",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					ingressClasses: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "IngressClass Success",
+			call: "IngressClass",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					ingressClasses: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ValidatingWebhookConfiguration EventHandler returns error",
+			call: "ValidatingWebhookConfiguration",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					validatingWebhookConfigurations: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ValidatingWebhookConfiguration Success",
+			call: "ValidatingWebhookConfiguration",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					validatingWebhookConfigurations: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: MutatingWebhookConfiguration EventHandler returns error",
+			call: "MutatingWebhookConfiguration",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					mutatingWebhookConfigurations: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "MutatingWebhookConfiguration Success",
+			call: "MutatingWebhookConfiguration",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					mutatingWebhookConfigurations: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ValidatingAdmissionPolicy EventHandler returns error",
+			call: "ValidatingAdmissionPolicy",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					validatingAdmissionPolicies: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ValidatingAdmissionPolicy Success",
+			call: "ValidatingAdmissionPolicy",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					validatingAdmissionPolicies: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+		{
+			name: "Error: ServiceAccount EventHandler returns error",
+			call: "ServiceAccount",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					serviceAccounts: &fakeSharedIndexInformer{
+						sendErr: errors.New("error"),
+					},
+				},
+			),
+			wantErr: true,
+		},
+		{
+			name: "ServiceAccount Success",
+			call: "ServiceAccount",
+			factory: NewFakeInformer(
+				fakeInformerArgs{
+					serviceAccounts: &fakeSharedIndexInformer{},
+				},
+			),
+		},
+	}
+
+	for _, test := range tests {
+		c := &Reader{informer: test.factory}
+		c.handlers = cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc:    c.addHandler,
+			UpdateFunc: c.updateHandler,
+			DeleteFunc: c.deleteHandler,
+		}
+
+		var hasSynced cache.InformerSynced
+		var err error
+		switch test.call {
+		case "Namespace":
+			hasSynced, err = c.namespaceInform()
+		case "Node":
+			hasSynced, err = c.nodeInform()
+		case "Pod":
+			hasSynced, err = c.podInform()
+		case "Deployment":
+			hasSynced, err = c.deploymentInform()
+		case "ReplicaSet":
+			hasSynced, err = c.replicaSetInform()
+		case "StatefulSet":
+			hasSynced, err = c.statefulSetInform()
+		case "DaemonSet":
+			hasSynced, err = c.daemonSetInform()
+		case "Job":
+			hasSynced, err = c.jobInform()
+		case "CronJob":
+			hasSynced, err = c.cronJobInform()
+		case "Service":
+			hasSynced, err = c.serviceInform()
+		case "EndpointSlice":
+			hasSynced, err = c.endpointSliceInform()
+		case "Role":
+			hasSynced, err = c.roleInform()
+		case "ClusterRole":
+			hasSynced, err = c.clusterRoleInform()
+		case "RoleBinding":
+			hasSynced, err = c.roleBindingInform()
+		case "ClusterRoleBinding":
+			hasSynced, err = c.clusterRoleBindingInform()
+		case "Secret":
+			hasSynced, err = c.secretInform()
+		case "ConfigMap":
+			hasSynced, err = c.configMapInform()
+		case "NetworkPolicy":
+			hasSynced, err = c.networkPolicyInform()
+		case "Ingress":
+			hasSynced, err = c.ingressInform()
+		case "IngressClass":
+			hasSynced, err = c.ingressClassInform()
+		case "ValidatingWebhookConfiguration":
+			hasSynced, err = c.validatingWebhookInform()
+		case "MutatingWebhookConfiguration":
+			hasSynced, err = c.mutatingWebhookInform()
+		case "ValidatingAdmissionPolicy":
+			hasSynced, err = c.validatingAdmissionPolicyInform()
+		case "ServiceAccount":
+			hasSynced, err = c.serviceAccountInform()
+		default:
+			panic("unknown call")
+		}
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestNamespaceInform(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestNamespaceInform(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if !hasSynced() {
+			t.Errorf("TestNamespaceInform(%s): got hasSynced == false, want hasSynced == true", test.name)
+		}
+		if len(c.indexes) == 0 {
+			t.Errorf("TestNamespaceInform(%s): got len(indexes) == 0, want len(indexes) > 0", test.name)
+		}
+	}
+}
+
+func TestAddOrDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		obj     any
+		ct      data.ChangeType
+		want    data.Informer
+		wantErr bool
+	}{
+		{
+			name:    "Error: obj is nil",
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name:    "Error: unsupported type",
+			obj:     &corev1.NodeAddress{},
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name: "Node add",
+			obj:  &corev1.Node{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Node]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTNode,
+					New:        &corev1.Node{},
+				},
+			),
+		},
+		{
+			name: "Pod add",
+			obj:  &corev1.Pod{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Pod]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTPod,
+					New:        &corev1.Pod{},
+				},
+			),
+		},
+		{
+			name: "Namespace add",
+			obj:  &corev1.Namespace{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Namespace]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTNamespace,
+					New:        &corev1.Namespace{},
+				},
+			),
+		},
+		{
+			name: "Node delete",
+			obj:  &corev1.Node{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Node]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTNode,
+					Old:        &corev1.Node{},
+				},
+			),
+		},
+		{
+			name: "Pod delete",
+			obj:  &corev1.Pod{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Pod]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTPod,
+					Old:        &corev1.Pod{},
+				},
+			),
+		},
+		{
+			name: "Namespace delete",
+			obj:  &corev1.Namespace{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Namespace]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTNamespace,
+					Old:        &corev1.Namespace{},
+				},
+			),
+		},
+		{
+			name: "Deployment add",
+			obj:  &appsv1.Deployment{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.Deployment]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTDeployment,
+					New:        &appsv1.Deployment{},
+				},
+			),
+		},
+		{
+			name: "ReplicaSet add",
+			obj:  &appsv1.ReplicaSet{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.ReplicaSet]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTReplicaSet,
+					New:        &appsv1.ReplicaSet{},
+				},
+			),
+		},
+		{
+			name: "StatefulSet add",
+			obj:  &appsv1.StatefulSet{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.StatefulSet]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTStatefulSet,
+					New:        &appsv1.StatefulSet{},
+				},
+			),
+		},
+		{
+			name: "DaemonSet add",
+			obj:  &appsv1.DaemonSet{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.DaemonSet]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTDaemonSet,
+					New:        &appsv1.DaemonSet{},
+				},
+			),
+		},
+		{
+			name: "Deployment delete",
+			obj:  &appsv1.Deployment{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.Deployment]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTDeployment,
+					Old:        &appsv1.Deployment{},
+				},
+			),
+		},
+		{
+			name: "ReplicaSet delete",
+			obj:  &appsv1.ReplicaSet{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.ReplicaSet]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTReplicaSet,
+					Old:        &appsv1.ReplicaSet{},
+				},
+			),
+		},
+		{
+			name: "StatefulSet delete",
+			obj:  &appsv1.StatefulSet{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.StatefulSet]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTStatefulSet,
+					Old:        &appsv1.StatefulSet{},
+				},
+			),
+		},
+		{
+			name: "DaemonSet delete",
+			obj:  &appsv1.DaemonSet{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*appsv1.DaemonSet]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTDaemonSet,
+					Old:        &appsv1.DaemonSet{},
+				},
+			),
+		},
+		{
+			name: "Job add",
+			obj:  &batchv1.Job{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*batchv1.Job]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTJob,
+					New:        &batchv1.Job{},
+				},
+			),
+		},
+		{
+			name: "CronJob add",
+			obj:  &batchv1.CronJob{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*batchv1.CronJob]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTCronJob,
+					New:        &batchv1.CronJob{},
+				},
+			),
+		},
+		{
+			name: "Job delete",
+			obj:  &batchv1.Job{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*batchv1.Job]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTJob,
+					Old:        &batchv1.Job{},
+				},
+			),
+		},
+		{
+			name: "CronJob delete",
+			obj:  &batchv1.CronJob{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*batchv1.CronJob]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTCronJob,
+					Old:        &batchv1.CronJob{},
+				},
+			),
+		},
+		{
+			name: "Service add",
+			obj:  &corev1.Service{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Service]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTService,
+					New:        &corev1.Service{},
+				},
+			),
+		},
+		{
+			name: "Service delete",
+			obj:  &corev1.Service{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*corev1.Service]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTService,
+					Old:        &corev1.Service{},
+				},
+			),
+		},
+		{
+			name: "EndpointSlice add",
+			obj:  &discoveryv1.EndpointSlice{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*discoveryv1.EndpointSlice]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTEndpointSlice,
+					New:        &discoveryv1.EndpointSlice{},
+				},
+			),
+		},
+		{
+			name: "EndpointSlice delete",
+			obj:  &discoveryv1.EndpointSlice{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*discoveryv1.EndpointSlice]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTEndpointSlice,
+					Old:        &discoveryv1.EndpointSlice{},
+				},
+			),
+		},
+		{
+			name: "Role add",
+			obj:  &rbacv1.Role{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.Role]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTRole,
+					New:        &rbacv1.Role{},
+				},
+			),
+		},
+		{
+			name: "ClusterRole add",
+			obj:  &rbacv1.ClusterRole{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRole]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTClusterRole,
+					New:        &rbacv1.ClusterRole{},
+				},
+			),
+		},
+		{
+			name: "RoleBinding add",
+			obj:  &rbacv1.RoleBinding{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.RoleBinding]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTRoleBinding,
+					New:        &rbacv1.RoleBinding{},
+				},
+			),
+		},
+		{
+			name: "ClusterRoleBinding add",
+			obj:  &rbacv1.ClusterRoleBinding{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRoleBinding]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTClusterRoleBinding,
+					New:        &rbacv1.ClusterRoleBinding{},
+				},
+			),
+		},
+		{
+			name: "Role delete",
+			obj:  &rbacv1.Role{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.Role]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTRole,
+					Old:        &rbacv1.Role{},
+				},
+			),
+		},
+		{
+			name: "ClusterRole delete",
+			obj:  &rbacv1.ClusterRole{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRole]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTClusterRole,
+					Old:        &rbacv1.ClusterRole{},
+				},
+			),
+		},
+		{
+			name: "RoleBinding delete",
+			obj:  &rbacv1.RoleBinding{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.RoleBinding]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTRoleBinding,
+					Old:        &rbacv1.RoleBinding{},
+				},
+			),
+		},
+		{
+			name: "ClusterRoleBinding delete",
+			obj:  &rbacv1.ClusterRoleBinding{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRoleBinding]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTClusterRoleBinding,
+					Old:        &rbacv1.ClusterRoleBinding{},
+				},
+			),
+		},
+		{
+			name: "NetworkPolicy add",
+			obj:  &networkingv1.NetworkPolicy{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.NetworkPolicy]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTNetworkPolicy,
+					New:        &networkingv1.NetworkPolicy{},
+				},
+			),
+		},
+		{
+			name: "Ingress add",
+			obj:  &networkingv1.Ingress{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.Ingress]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTIngress,
+					New:        &networkingv1.Ingress{},
+				},
+			),
+		},
+		{
+			name: "IngressClass add",
+			obj:  &networkingv1.IngressClass{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.IngressClass]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTIngressClass,
+					New:        &networkingv1.IngressClass{},
+				},
+			),
+		},
+		{
+			name: "NetworkPolicy delete",
+			obj:  &networkingv1.NetworkPolicy{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.NetworkPolicy]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTNetworkPolicy,
+					Old:        &networkingv1.NetworkPolicy{},
+				},
+			),
+		},
+		{
+			name: "Ingress delete",
+			obj:  &networkingv1.Ingress{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.Ingress]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTIngress,
+					Old:        &networkingv1.Ingress{},
+				},
+			),
+		},
+		{
+			name: "IngressClass delete",
+			obj:  &networkingv1.IngressClass{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.IngressClass]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTIngressClass,
+					Old:        &networkingv1.IngressClass{},
+				},
+			),
+		},
+		{
+			name: "ValidatingWebhookConfiguration add",
+			obj:  &admissionregistrationv1.ValidatingWebhookConfiguration{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTValidatingWebhookConfiguration,
+					New:        &admissionregistrationv1.ValidatingWebhookConfiguration{},
+				},
+			),
+		},
+		{
+			name: "MutatingWebhookConfiguration add",
+			obj:  &admissionregistrationv1.MutatingWebhookConfiguration{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.MutatingWebhookConfiguration]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTMutatingWebhookConfiguration,
+					New:        &admissionregistrationv1.MutatingWebhookConfiguration{},
+				},
+			),
+		},
+		{
+			name: "ValidatingAdmissionPolicy add",
+			obj:  &admissionregistrationv1.ValidatingAdmissionPolicy{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTValidatingAdmissionPolicy,
+					New:        &admissionregistrationv1.ValidatingAdmissionPolicy{},
+				},
+			),
+		},
+		{
+			name: "ValidatingWebhookConfiguration delete",
+			obj:  &admissionregistrationv1.ValidatingWebhookConfiguration{},
+			ct:   data.CTDelete,
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTValidatingWebhookConfiguration,
+					Old:        &admissionregistrationv1.ValidatingWebhookConfiguration{},
 				},
 			),
 		},
 		{
-			name: "Node delete",
-			obj:  &corev1.Node{},
+			name: "MutatingWebhookConfiguration delete",
+			obj:  &admissionregistrationv1.MutatingWebhookConfiguration{},
 			ct:   data.CTDelete,
 			want: data.MustNewInformer(
-				data.Change[*corev1.Node]{
+				data.Change[*admissionregistrationv1.MutatingWebhookConfiguration]{
 					ChangeType: data.CTDelete,
-					ObjectType: data.OTNode,
-					Old:        &corev1.Node{},
+					ObjectType: data.OTMutatingWebhookConfiguration,
+					Old:        &admissionregistrationv1.MutatingWebhookConfiguration{},
 				},
 			),
 		},
 		{
-			name: "Pod delete",
-			obj:  &corev1.Pod{},
+			name: "ValidatingAdmissionPolicy delete",
+			obj:  &admissionregistrationv1.ValidatingAdmissionPolicy{},
 			ct:   data.CTDelete,
 			want: data.MustNewInformer(
-				data.Change[*corev1.Pod]{
+				data.Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{
 					ChangeType: data.CTDelete,
-					ObjectType: data.OTPod,
-					Old:        &corev1.Pod{},
+					ObjectType: data.OTValidatingAdmissionPolicy,
+					Old:        &admissionregistrationv1.ValidatingAdmissionPolicy{},
+					Flags:      data.CFRulesPermissive,
 				},
 			),
 		},
 		{
-			name: "Namespace delete",
-			obj:  &corev1.Namespace{},
+			name: "ServiceAccount add",
+			obj:  &corev1.ServiceAccount{},
+			ct:   data.CTAdd,
+			want: data.MustNewInformer(
+				data.Change[*corev1.ServiceAccount]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTServiceAccount,
+					New:        &corev1.ServiceAccount{},
+				},
+			),
+		},
+		{
+			name: "ServiceAccount delete",
+			obj:  &corev1.ServiceAccount{},
 			ct:   data.CTDelete,
 			want: data.MustNewInformer(
-				data.Change[*corev1.Namespace]{
+				data.Change[*corev1.ServiceAccount]{
 					ChangeType: data.CTDelete,
-					ObjectType: data.OTNamespace,
-					Old:        &corev1.Namespace{},
+					ObjectType: data.OTServiceAccount,
+					Old:        &corev1.ServiceAccount{},
 				},
 			),
 		},
@@ -265,7 +1375,7 @@ func TestAddOrDelete(t *testing.T) {
 	for _, test := range tests {
 		c := &Reader{ch: make(chan data.Entry, 1)}
 
-		err := c.addOrDelete(test.obj, test.ct)
+		err := c.addOrDelete(test.obj, test.ct, data.ORWatch)
 		switch {
 		case test.wantErr && err == nil:
 			t.Errorf("TestAddOrDelete(%s): got err == nil, want err != nil", test.name)
@@ -358,6 +1468,253 @@ func TestUpdate(t *testing.T) {
 				},
 			),
 		},
+		{
+			name:   "Deployment update",
+			oldObj: &appsv1.Deployment{},
+			newObj: &appsv1.Deployment{},
+			want: data.MustNewInformer(
+				data.Change[*appsv1.Deployment]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTDeployment,
+					New:        &appsv1.Deployment{},
+					Old:        &appsv1.Deployment{},
+				},
+			),
+		},
+		{
+			name:   "ReplicaSet update",
+			oldObj: &appsv1.ReplicaSet{},
+			newObj: &appsv1.ReplicaSet{},
+			want: data.MustNewInformer(
+				data.Change[*appsv1.ReplicaSet]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTReplicaSet,
+					New:        &appsv1.ReplicaSet{},
+					Old:        &appsv1.ReplicaSet{},
+				},
+			),
+		},
+		{
+			name:   "StatefulSet update",
+			oldObj: &appsv1.StatefulSet{},
+			newObj: &appsv1.StatefulSet{},
+			want: data.MustNewInformer(
+				data.Change[*appsv1.StatefulSet]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTStatefulSet,
+					New:        &appsv1.StatefulSet{},
+					Old:        &appsv1.StatefulSet{},
+				},
+			),
+		},
+		{
+			name:   "DaemonSet update",
+			oldObj: &appsv1.DaemonSet{},
+			newObj: &appsv1.DaemonSet{},
+			want: data.MustNewInformer(
+				data.Change[*appsv1.DaemonSet]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTDaemonSet,
+					New:        &appsv1.DaemonSet{},
+					Old:        &appsv1.DaemonSet{},
+				},
+			),
+		},
+		{
+			name:   "Job update",
+			oldObj: &batchv1.Job{},
+			newObj: &batchv1.Job{},
+			want: data.MustNewInformer(
+				data.Change[*batchv1.Job]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTJob,
+					New:        &batchv1.Job{},
+					Old:        &batchv1.Job{},
+				},
+			),
+		},
+		{
+			name:   "CronJob update",
+			oldObj: &batchv1.CronJob{},
+			newObj: &batchv1.CronJob{},
+			want: data.MustNewInformer(
+				data.Change[*batchv1.CronJob]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTCronJob,
+					New:        &batchv1.CronJob{},
+					Old:        &batchv1.CronJob{},
+				},
+			),
+		},
+		{
+			name:   "Service update",
+			oldObj: &corev1.Service{},
+			newObj: &corev1.Service{},
+			want: data.MustNewInformer(
+				data.Change[*corev1.Service]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTService,
+					New:        &corev1.Service{},
+					Old:        &corev1.Service{},
+				},
+			),
+		},
+		{
+			name:   "EndpointSlice update",
+			oldObj: &discoveryv1.EndpointSlice{},
+			newObj: &discoveryv1.EndpointSlice{},
+			want: data.MustNewInformer(
+				data.Change[*discoveryv1.EndpointSlice]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTEndpointSlice,
+					New:        &discoveryv1.EndpointSlice{},
+					Old:        &discoveryv1.EndpointSlice{},
+				},
+			),
+		},
+		{
+			name:   "Role update",
+			oldObj: &rbacv1.Role{},
+			newObj: &rbacv1.Role{},
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.Role]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTRole,
+					New:        &rbacv1.Role{},
+					Old:        &rbacv1.Role{},
+				},
+			),
+		},
+		{
+			name:   "ClusterRole update",
+			oldObj: &rbacv1.ClusterRole{},
+			newObj: &rbacv1.ClusterRole{},
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRole]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTClusterRole,
+					New:        &rbacv1.ClusterRole{},
+					Old:        &rbacv1.ClusterRole{},
+				},
+			),
+		},
+		{
+			name:   "RoleBinding update",
+			oldObj: &rbacv1.RoleBinding{},
+			newObj: &rbacv1.RoleBinding{},
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.RoleBinding]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTRoleBinding,
+					New:        &rbacv1.RoleBinding{},
+					Old:        &rbacv1.RoleBinding{},
+				},
+			),
+		},
+		{
+			name:   "ClusterRoleBinding update",
+			oldObj: &rbacv1.ClusterRoleBinding{},
+			newObj: &rbacv1.ClusterRoleBinding{},
+			want: data.MustNewInformer(
+				data.Change[*rbacv1.ClusterRoleBinding]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTClusterRoleBinding,
+					New:        &rbacv1.ClusterRoleBinding{},
+					Old:        &rbacv1.ClusterRoleBinding{},
+				},
+			),
+		},
+		{
+			name:   "NetworkPolicy update",
+			oldObj: &networkingv1.NetworkPolicy{},
+			newObj: &networkingv1.NetworkPolicy{},
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.NetworkPolicy]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTNetworkPolicy,
+					New:        &networkingv1.NetworkPolicy{},
+					Old:        &networkingv1.NetworkPolicy{},
+				},
+			),
+		},
+		{
+			name:   "Ingress update",
+			oldObj: &networkingv1.Ingress{},
+			newObj: &networkingv1.Ingress{},
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.Ingress]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTIngress,
+					New:        &networkingv1.Ingress{},
+					Old:        &networkingv1.Ingress{},
+				},
+			),
+		},
+		{
+			name:   "IngressClass update",
+			oldObj: &networkingv1.IngressClass{},
+			newObj: &networkingv1.IngressClass{},
+			want: data.MustNewInformer(
+				data.Change[*networkingv1.IngressClass]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTIngressClass,
+					New:        &networkingv1.IngressClass{},
+					Old:        &networkingv1.IngressClass{},
+				},
+			),
+		},
+		{
+			name:   "ValidatingWebhookConfiguration update",
+			oldObj: &admissionregistrationv1.ValidatingWebhookConfiguration{},
+			newObj: &admissionregistrationv1.ValidatingWebhookConfiguration{},
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTValidatingWebhookConfiguration,
+					New:        &admissionregistrationv1.ValidatingWebhookConfiguration{},
+					Old:        &admissionregistrationv1.ValidatingWebhookConfiguration{},
+				},
+			),
+		},
+		{
+			name:   "MutatingWebhookConfiguration update",
+			oldObj: &admissionregistrationv1.MutatingWebhookConfiguration{},
+			newObj: &admissionregistrationv1.MutatingWebhookConfiguration{},
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.MutatingWebhookConfiguration]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTMutatingWebhookConfiguration,
+					New:        &admissionregistrationv1.MutatingWebhookConfiguration{},
+					Old:        &admissionregistrationv1.MutatingWebhookConfiguration{},
+				},
+			),
+		},
+		{
+			name:   "ValidatingAdmissionPolicy update",
+			oldObj: &admissionregistrationv1.ValidatingAdmissionPolicy{},
+			newObj: &admissionregistrationv1.ValidatingAdmissionPolicy{},
+			want: data.MustNewInformer(
+				data.Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTValidatingAdmissionPolicy,
+					New:        &admissionregistrationv1.ValidatingAdmissionPolicy{},
+					Old:        &admissionregistrationv1.ValidatingAdmissionPolicy{},
+				},
+			),
+		},
+		{
+			name:   "ServiceAccount update",
+			oldObj: &corev1.ServiceAccount{},
+			newObj: &corev1.ServiceAccount{},
+			want: data.MustNewInformer(
+				data.Change[*corev1.ServiceAccount]{
+					ChangeType: data.CTUpdate,
+					ObjectType: data.OTServiceAccount,
+					New:        &corev1.ServiceAccount{},
+					Old:        &corev1.ServiceAccount{},
+				},
+			),
+		},
 	}
 
 	for _, test := range tests {
@@ -388,3 +1745,309 @@ func TestUpdate(t *testing.T) {
 		}
 	}
 }
+
+// secretValue is a value that must never be seen in an Entry.
+const secretValue = "s3cr3t-v4lue-never-emit"
+
+func TestSecretValuesNeverEmitted(t *testing.T) {
+	t.Parallel()
+
+	lastApplied := map[string]string{
+		data.LastAppliedAnnotation: `{"data":{"password":"` + base64.StdEncoding.EncodeToString([]byte(secretValue)) + `"}}`,
+	}
+	secret := func() *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret", UID: "secret", Annotations: maps.Clone(lastApplied)},
+			Data:       map[string][]byte{"password": []byte(secretValue)},
+			StringData: map[string]string{"token": secretValue},
+		}
+	}
+	configMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cm", UID: "cm", Annotations: maps.Clone(lastApplied)},
+			Data:       map[string]string{"config": secretValue},
+			BinaryData: map[string][]byte{"binary": []byte(secretValue)},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		send     func(c *Reader) error
+		wantKeys []string
+	}{
+		{
+			name:     "Secret add",
+			send:     func(c *Reader) error { return c.addOrDelete(secret(), data.CTAdd, data.ORWatch) },
+			wantKeys: []string{"password", "token"},
+		},
+		{
+			name:     "Secret update",
+			send:     func(c *Reader) error { return c.update(secret(), secret()) },
+			wantKeys: []string{"password", "token"},
+		},
+		{
+			name:     "Secret delete",
+			send:     func(c *Reader) error { return c.addOrDelete(secret(), data.CTDelete, data.ORWatch) },
+			wantKeys: []string{"password", "token"},
+		},
+		{
+			name:     "ConfigMap add",
+			send:     func(c *Reader) error { return c.addOrDelete(configMap(), data.CTAdd, data.ORWatch) },
+			wantKeys: []string{"binary", "config"},
+		},
+		{
+			name:     "ConfigMap update",
+			send:     func(c *Reader) error { return c.update(configMap(), configMap()) },
+			wantKeys: []string{"binary", "config"},
+		},
+		{
+			name:     "ConfigMap delete",
+			send:     func(c *Reader) error { return c.addOrDelete(configMap(), data.CTDelete, data.ORWatch) },
+			wantKeys: []string{"binary", "config"},
+		},
+	}
+
+	for _, test := range tests {
+		c := &Reader{ch: make(chan data.Entry, 1), hashKey: []byte("key")}
+
+		if err := test.send(c); err != nil {
+			t.Errorf("TestSecretValuesNeverEmitted(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+		e := <-c.ch
+		i, err := e.Informer()
+		if err != nil {
+			t.Errorf("TestSecretValuesNeverEmitted(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+
+		var change any
+		var keys []data.KeyInfo
+		switch i.Type {
+		case data.OTSecret:
+			sc, err := i.Secret()
+			if err != nil {
+				t.Errorf("TestSecretValuesNeverEmitted(%s): got err == %v, want err == nil", test.name, err)
+				continue
+			}
+			change = sc
+			keys = i.Object().(*data.SecretMeta).Keys
+		case data.OTConfigMap:
+			cc, err := i.ConfigMap()
+			if err != nil {
+				t.Errorf("TestSecretValuesNeverEmitted(%s): got err == %v, want err == nil", test.name, err)
+				continue
+			}
+			change = cc
+			keys = i.Object().(*data.ConfigMapMeta).Keys
+		default:
+			t.Errorf("TestSecretValuesNeverEmitted(%s): got ObjectType %v", test.name, i.Type)
+			continue
+		}
+
+		b, err := json.Marshal(change)
+		if err != nil {
+			panic(err)
+		}
+		dump := string(b) + pretty.Sprint(change)
+		for _, leak := range []string{secretValue, base64.StdEncoding.EncodeToString([]byte(secretValue))} {
+			if strings.Contains(dump, leak) {
+				t.Errorf("TestSecretValuesNeverEmitted(%s): value leaked into Entry:\n%s", test.name, dump)
+			}
+		}
+
+		var gotKeys []string
+		for _, k := range keys {
+			gotKeys = append(gotKeys, k.Name)
+			if k.Size != len(secretValue) {
+				t.Errorf("TestSecretValuesNeverEmitted(%s): key %s got Size == %d, want %d", test.name, k.Name, k.Size, len(secretValue))
+			}
+			if k.Hash == "" {
+				t.Errorf("TestSecretValuesNeverEmitted(%s): key %s has no Hash", test.name, k.Name)
+			}
+		}
+		if diff := pretty.Compare(test.wantKeys, gotKeys); diff != "" {
+			t.Errorf("TestSecretValuesNeverEmitted(%s): keys -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestLegacyTokenFlag(t *testing.T) {
+	t.Parallel()
+
+	c := &Reader{ch: make(chan data.Entry, 1), hashKey: []byte("key")}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "token",
+			UID:         "token",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: "builder"},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+		Data: map[string][]byte{"token": []byte(secretValue)},
+	}
+
+	if err := c.addOrDelete(secret, data.CTAdd, data.ORWatch); err != nil {
+		t.Fatalf("TestLegacyTokenFlag: got err == %v, want err == nil", err)
+	}
+	i, err := (<-c.ch).Informer()
+	if err != nil {
+		t.Fatalf("TestLegacyTokenFlag: got err == %v, want err == nil", err)
+	}
+	change, err := i.Secret()
+	if err != nil {
+		t.Fatalf("TestLegacyTokenFlag: got err == %v, want err == nil", err)
+	}
+	if !change.Flags.Has(data.CFLegacyServiceAccountToken) {
+		t.Errorf("TestLegacyTokenFlag: got Flags == %#x, want CFLegacyServiceAccountToken set", change.Flags)
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	t.Parallel()
+
+	pod := func(rv string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "pod", ResourceVersion: rv}}
+	}
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		send           func(h cache.ResourceEventHandler)
+		wantOut        []data.Origin
+		wantInitial    []data.Origin
+		wantSuppressed uint64
+	}{
+		{
+			name:    "Initial list",
+			send:    func(h cache.ResourceEventHandler) { h.OnAdd(pod("1"), true) },
+			wantOut: []data.Origin{data.ORInitialList},
+		},
+		{
+			name:    "Watch add",
+			send:    func(h cache.ResourceEventHandler) { h.OnAdd(pod("1"), false) },
+			wantOut: []data.Origin{data.ORWatch},
+		},
+		{
+			name:    "Resync update",
+			send:    func(h cache.ResourceEventHandler) { h.OnUpdate(pod("1"), pod("1")) },
+			wantOut: []data.Origin{data.ORResync},
+		},
+		{
+			name:    "Watch update",
+			send:    func(h cache.ResourceEventHandler) { h.OnUpdate(pod("1"), pod("2")) },
+			wantOut: []data.Origin{data.ORWatch},
+		},
+		{
+			name: "Without initial list",
+			opts: []Option{WithoutInitialList()},
+			send: func(h cache.ResourceEventHandler) {
+				h.OnAdd(pod("1"), true)
+				h.OnDelete(pod("1"))
+			},
+			wantOut: []data.Origin{data.ORWatch},
+		},
+		{
+			name: "Initial list out",
+			opts: []Option{WithInitialListOut(make(chan data.Entry, 10))},
+			send: func(h cache.ResourceEventHandler) {
+				h.OnAdd(pod("1"), true)
+				h.OnUpdate(pod("1"), pod("2"))
+			},
+			wantOut:     []data.Origin{data.ORWatch},
+			wantInitial: []data.Origin{data.ORInitialList},
+		},
+		{
+			name: "Without resyncs",
+			opts: []Option{WithoutResyncs()},
+			send: func(h cache.ResourceEventHandler) {
+				h.OnUpdate(pod("1"), pod("1"))
+				h.OnUpdate(pod("1"), pod("2"))
+				h.OnUpdate(pod("2"), pod("2"))
+			},
+			wantOut:        []data.Origin{data.ORWatch},
+			wantSuppressed: 2,
+		},
+		{
+			name: "Semantic resyncs",
+			opts: []Option{WithSemanticResyncs(data.IgnoreManagedFields)},
+			send: func(h cache.ResourceEventHandler) {
+				managed := pod("2")
+				managed.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}
+				h.OnUpdate(pod("1"), managed)
+
+				labeled := pod("3")
+				labeled.Labels = map[string]string{"app": "web"}
+				h.OnUpdate(pod("2"), labeled)
+			},
+			wantOut: []data.Origin{data.ORResync, data.ORWatch},
+		},
+		{
+			name: "Semantic resyncs suppressed",
+			opts: []Option{WithSemanticResyncs(), WithoutResyncs()},
+			send: func(h cache.ResourceEventHandler) {
+				h.OnUpdate(pod("1"), pod("2"))
+			},
+			wantSuppressed: 1,
+		},
+	}
+
+	for _, test := range tests {
+		pods := &fakeSharedIndexInformer{}
+		c, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: pods}), RTPod, test.opts...)
+		if err != nil {
+			t.Fatalf("TestOrigin(%s): got err == %v, want err == nil", test.name, err)
+		}
+		c.ch = make(chan data.Entry, 10)
+
+		test.send(pods.handlers[0])
+
+		if diff := pretty.Compare(test.wantOut, origins(c.ch)); diff != "" {
+			t.Errorf("TestOrigin(%s): output: -want/+got:\n%s", test.name, diff)
+		}
+		if got := c.SuppressedResyncs(); got != test.wantSuppressed {
+			t.Errorf("TestOrigin(%s): got SuppressedResyncs() == %d, want %d", test.name, got, test.wantSuppressed)
+		}
+		if c.initialListOut != nil {
+			if diff := pretty.Compare(test.wantInitial, origins(c.initialListOut)); diff != "" {
+				t.Errorf("TestOrigin(%s): initial list: -want/+got:\n%s", test.name, diff)
+			}
+		}
+	}
+
+	if _, err := New(context.Background(), NewFakeInformer(fakeInformerArgs{pods: &fakeSharedIndexInformer{}}), RTPod, WithoutInitialList(), WithInitialListOut(make(chan data.Entry))); err == nil {
+		t.Errorf("TestOrigin(drop and route): got err == nil, want err != nil")
+	}
+}
+
+func TestSyncComplete(t *testing.T) {
+	t.Parallel()
+
+	initial := make(chan data.Entry, 1)
+	c := &Reader{ch: make(chan data.Entry, 1), initialListOut: initial}
+	if err := c.sendSyncComplete(); err != nil {
+		t.Fatalf("TestSyncComplete: got err == %v, want err == nil", err)
+	}
+
+	for _, ch := range []chan data.Entry{c.ch, initial} {
+		e := <-ch
+		m, err := e.SyncComplete()
+		if err != nil {
+			t.Fatalf("TestSyncComplete: got err == %v, want err == nil", err)
+		}
+		if m.Name != readerName {
+			t.Errorf("TestSyncComplete: got Name == %s, want %s", m.Name, readerName)
+		}
+		if e.UID() == "" {
+			t.Errorf("TestSyncComplete: got empty UID, want UID")
+		}
+	}
+}
+
+// origins drains ch and returns the Origin of each entry.
+func origins(ch chan data.Entry) []data.Origin {
+	var got []data.Origin
+	for len(ch) > 0 {
+		got = append(got, (<-ch).Origin)
+	}
+	return got
+}
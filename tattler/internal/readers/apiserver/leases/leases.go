@@ -0,0 +1,389 @@
+/*
+Package leases provides a reader that tracks node liveness from the node leases in kube-node-lease.
+
+The kubelet renews its lease every few seconds, so reading the leases directly is mostly churn. Instead the
+Reader derives a data.NodeLiveness for each node, which is healthy, late or expired based on the lease's
+renewTime and leaseDurationSeconds, and only emits it when that state changes. As a lease can become late or
+expire without any change to it, the Reader re-evaluates all leases on an interval.
+
+Entries are data.ETInformer entries with a data.OTNodeLiveness object type.
+
+Usage:
+
+	r, err := leases.New(ctx, clientset, time.Minute*10, leases.WithCheckInterval(5*time.Second))
+	if err != nil {
+		// Do something
+	}
+	tattler.AddReader(ctx, r)
+*/
+package leases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reader reports changes to node liveness derived from node leases.
+type Reader struct {
+	informer informers.SharedInformerFactory
+	index    cache.SharedIndexInformer
+	syncer   cache.InformerSynced
+	interval time.Duration
+
+	// dropInitialList, initialListOut and syncComplete control handling of the initial list.
+	dropInitialList bool
+	initialListOut  chan data.Entry
+	syncComplete    bool
+
+	// mu guards nodes. It is held until a change to nodes has been sent, so that entries are sent in the
+	// order the changes were made and a check cannot send a state an update has already replaced.
+	mu sync.Mutex
+	// nodes is the last NodeLiveness emitted for each lease, keyed by lease name.
+	nodes map[string]*data.NodeLiveness
+	now   func() time.Time
+
+	ch      chan data.Entry
+	stop    chan struct{}
+	started bool
+	log     *slog.Logger
+}
+
+// Option is an option for New().
+type Option func(*Reader) error
+
+// WithLogger sets the logger for the Reader.
+func WithLogger(log *slog.Logger) Option {
+	return func(r *Reader) error {
+		r.log = log
+		return nil
+	}
+}
+
+// WithCheckInterval sets how often all leases are re-evaluated to find nodes that have become late or
+// expired. Defaults to 5 seconds.
+func WithCheckInterval(d time.Duration) Option {
+	return func(r *Reader) error {
+		if d <= 0 {
+			return fmt.Errorf("check interval must be > 0")
+		}
+		r.interval = d
+		return nil
+	}
+}
+
+// WithoutInitialList drops the liveness of the leases that exist when the Reader starts, so only changes
+// made while the Reader is running are emitted. Cannot be used with WithInitialListOut().
+func WithoutInitialList() Option {
+	return func(r *Reader) error {
+		r.dropInitialList = true
+		return nil
+	}
+}
+
+// WithInitialListOut sends the liveness of the leases that exist when the Reader starts on out instead of
+// the output channel. out must be read from for Run() to complete. Cannot be used with
+// WithoutInitialList().
+func WithInitialListOut(out chan data.Entry) Option {
+	return func(r *Reader) error {
+		if out == nil {
+			return fmt.Errorf("initial list channel cannot be nil")
+		}
+		r.initialListOut = out
+		return nil
+	}
+}
+
+// WithSyncComplete emits a data.ETSyncComplete marker entry once the informer has sent its initial list.
+// It is sent on the output channel and, if set, the WithInitialListOut() channel.
+func WithSyncComplete() Option {
+	return func(r *Reader) error {
+		r.syncComplete = true
+		return nil
+	}
+}
+
+// New creates a new Reader that watches leases in the kube-node-lease namespace.
+func New(ctx context.Context, clientset kubernetes.Interface, resync time.Duration, opts ...Option) (*Reader, error) {
+	if clientset == nil {
+		return nil, fmt.Errorf("clientset is nil")
+	}
+
+	r := &Reader{
+		informer: informers.NewSharedInformerFactoryWithOptions(
+			clientset,
+			resync,
+			informers.WithNamespace(corev1.NamespaceNodeLease),
+		),
+		interval: 5 * time.Second,
+		nodes:    map[string]*data.NodeLiveness{},
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		log:      slog.Default(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.dropInitialList && r.initialListOut != nil {
+		return nil, fmt.Errorf("cannot use both WithoutInitialList() and WithInitialListOut()")
+	}
+
+	r.index = r.informer.Coordination().V1().Leases().Informer()
+	reg, err := r.index.AddEventHandler(
+		cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc:    r.addHandler,
+			UpdateFunc: r.updateHandler,
+			DeleteFunc: r.deleteHandler,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.syncer = reg.HasSynced
+
+	return r, nil
+}
+
+var closeDelay = 100 * time.Millisecond
+
+// Close closes the Reader. This will block until the index is stopped.
+// If the context is canceled, it will return the context error.
+func (r *Reader) Close(ctx context.Context) error {
+	close(r.stop)
+	defer close(r.ch)
+
+	for !r.index.IsStopped() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		time.Sleep(closeDelay)
+	}
+	return nil
+}
+
+// readerName is the name of the Reader returned by Name().
+const readerName = "leases"
+
+// Name returns the name of the Reader, which the tattler Runner puts in data.Entry.Reader.
+func (r *Reader) Name() string {
+	return readerName
+}
+
+// SetOut sets the output channel that the reader must output on. Must return an error and be a no-op
+// if Run() has been called.
+func (r *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
+	if r.started {
+		return fmt.Errorf("cannot call SetOut once the Reader has had Start() called")
+	}
+	r.ch = out
+	return nil
+}
+
+// Run starts the Reader processing. You may only call this once if Run() does not return an error.
+func (r *Reader) Run(ctx context.Context) error {
+	if r.started {
+		return fmt.Errorf("cannot call Run once the Reader has already started")
+	}
+	if r.ch == nil {
+		return fmt.Errorf("cannot call Run if SetOut has not been called")
+	}
+	r.informer.Start(r.stop)
+
+	if !cache.WaitForCacheSync(r.stop, r.syncer) {
+		r.stop = make(chan struct{})
+		return fmt.Errorf("failed to sync cache")
+	}
+	r.started = true
+
+	if r.syncComplete {
+		if err := r.sendSyncComplete(); err != nil {
+			return err
+		}
+	}
+
+	go r.checker(r.stop)
+
+	return nil
+}
+
+// checker calls check() every interval until stop is closed.
+func (r *Reader) checker(stop chan struct{}) {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			r.check()
+		}
+	}
+}
+
+// check re-evaluates every known lease and emits those whose state has changed.
+func (r *Reader) check() {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, last := range r.nodes {
+		current := last.Evaluate(now)
+		if current.State == last.State {
+			continue
+		}
+		r.nodes[name] = current
+		c := data.Change[*data.NodeLiveness]{
+			ChangeType: data.CTUpdate,
+			ObjectType: data.OTNodeLiveness,
+			Old:        last,
+			New:        current,
+		}
+		if err := r.send(c, data.ORWatch); err != nil {
+			r.log.Error(err.Error())
+		}
+	}
+}
+
+// addHandler is the event handler for adding data.
+func (r *Reader) addHandler(obj any, isInInitialList bool) {
+	origin := data.ORWatch
+	if isInInitialList {
+		origin = data.ORInitialList
+	}
+	if err := r.add(obj, origin); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+func (r *Reader) updateHandler(oldObj any, newObj any) {
+	if err := r.update(newObj, data.UpdateOrigin(oldObj, newObj)); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// deleteHandler is the event handler for deleting data.
+func (r *Reader) deleteHandler(obj any) {
+	if err := r.delete(obj); err != nil {
+		r.log.Error(err.Error())
+	}
+}
+
+// add records a new lease and emits its liveness with origin.
+func (r *Reader) add(obj any, origin data.Origin) error {
+	lease, ok := obj.(*coordinationv1.Lease)
+	if !ok || lease == nil {
+		return fmt.Errorf("leases.Reader.add(): unknown object type: %T", obj)
+	}
+
+	nl := data.NewNodeLiveness(lease, r.now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nodes[lease.Name] = nl
+	return r.send(data.Change[*data.NodeLiveness]{ChangeType: data.CTAdd, ObjectType: data.OTNodeLiveness, New: nl}, origin)
+}
+
+// update records a renewed lease. Liveness is only emitted if the state changed, with origin.
+func (r *Reader) update(newObj any, origin data.Origin) error {
+	lease, ok := newObj.(*coordinationv1.Lease)
+	if !ok || lease == nil {
+		return fmt.Errorf("leases.Reader.update(): unknown object type: %T", newObj)
+	}
+
+	nl := data.NewNodeLiveness(lease, r.now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last, ok := r.nodes[lease.Name]
+	r.nodes[lease.Name] = nl
+
+	if !ok {
+		return r.send(data.Change[*data.NodeLiveness]{ChangeType: data.CTAdd, ObjectType: data.OTNodeLiveness, New: nl}, origin)
+	}
+	if last.State == nl.State {
+		return nil
+	}
+	return r.send(data.Change[*data.NodeLiveness]{ChangeType: data.CTUpdate, ObjectType: data.OTNodeLiveness, Old: last, New: nl}, origin)
+}
+
+// delete forgets a lease and emits the delete of its liveness. A tombstone, sent when the watch missed the
+// delete, is emitted with data.CFStaleFinalState.
+func (r *Reader) delete(obj any) error {
+	var flags data.ChangeFlag
+	if t, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = t.Obj
+		flags = data.CFStaleFinalState
+	}
+
+	lease, ok := obj.(*coordinationv1.Lease)
+	if !ok || lease == nil {
+		return fmt.Errorf("leases.Reader.delete(): unknown object type: %T", obj)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last, ok := r.nodes[lease.Name]
+	delete(r.nodes, lease.Name)
+
+	if !ok {
+		last = data.NewNodeLiveness(lease, r.now())
+	}
+	return r.send(data.Change[*data.NodeLiveness]{ChangeType: data.CTDelete, ObjectType: data.OTNodeLiveness, Old: last, Flags: flags}, data.ORWatch)
+}
+
+// send wraps change in an Entry with origin and sends it on the output channel. Entries from the initial
+// list are instead dropped or sent on the initial list channel if WithoutInitialList() or
+// WithInitialListOut() were used.
+func (r *Reader) send(change data.Change[*data.NodeLiveness], origin data.Origin) error {
+	d, err := data.NewInformer(change)
+	if err != nil {
+		return err
+	}
+	e, err := data.NewEntry(d)
+	if err != nil {
+		return err
+	}
+	e.Origin = origin
+	if origin == data.ORInitialList {
+		switch {
+		case r.dropInitialList:
+			return nil
+		case r.initialListOut != nil:
+			r.initialListOut <- e
+			return nil
+		}
+	}
+	r.ch <- e
+	return nil
+}
+
+// sendSyncComplete sends a data.ETSyncComplete marker on the output channel and the initial list channel.
+func (r *Reader) sendSyncComplete() error {
+	e, err := data.NewEntry(data.NewSyncComplete(readerName, time.Now()))
+	if err != nil {
+		return err
+	}
+	if r.initialListOut != nil {
+		r.initialListOut <- e
+	}
+	r.ch <- e
+	return nil
+}
@@ -0,0 +1,250 @@
+package leases
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestReader(now *time.Time) *Reader {
+	return &Reader{
+		ch:    make(chan data.Entry, 10),
+		nodes: map[string]*data.NodeLiveness{},
+		now:   func() time.Time { return *now },
+	}
+}
+
+func lease(name string, renew time.Time) *coordinationv1.Lease {
+	d := int32(40)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceNodeLease, Name: name, UID: types.UID("uid-" + name)},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &name,
+			LeaseDurationSeconds: &d,
+			RenewTime:            &metav1.MicroTime{Time: renew},
+		},
+	}
+}
+
+func recv(t *testing.T, r *Reader) data.Change[*data.NodeLiveness] {
+	t.Helper()
+	select {
+	case e := <-r.ch:
+		i, err := e.Informer()
+		if err != nil {
+			t.Fatalf("got err == %v, want err == nil", err)
+		}
+		c, err := i.NodeLiveness()
+		if err != nil {
+			t.Fatalf("got err == %v, want err == nil", err)
+		}
+		return c
+	default:
+		t.Fatalf("got no entry, want entry")
+	}
+	return data.Change[*data.NodeLiveness]{}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(context.Background(), nil, 0); err == nil {
+		t.Errorf("TestNew(nil clientset): got err == nil, want err != nil")
+	}
+	if _, err := New(context.Background(), fake.NewSimpleClientset(), 0, WithCheckInterval(0)); err == nil {
+		t.Errorf("TestNew(bad interval): got err == nil, want err != nil")
+	}
+	if _, err := New(context.Background(), fake.NewSimpleClientset(), 0); err != nil {
+		t.Errorf("TestNew(success): got err == %v, want err == nil", err)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	r := newTestReader(&now)
+
+	if err := r.add(&corev1.Pod{}, data.ORWatch); err == nil {
+		t.Errorf("TestLiveness(bad type): got err == nil, want err != nil")
+	}
+
+	// A new lease is emitted as an add.
+	if err := r.add(lease("node", now), data.ORWatch); err != nil {
+		t.Fatalf("TestLiveness(add): got err == %v, want err == nil", err)
+	}
+	if c := recv(t, r); c.ChangeType != data.CTAdd || c.New.State != data.LSHealthy || c.New.Name != "node" {
+		t.Errorf("TestLiveness(add): got %v %v %s, want CTAdd Healthy node", c.ChangeType, c.New.State, c.New.Name)
+	}
+
+	// Renewals that stay healthy are not emitted.
+	now = now.Add(10 * time.Second)
+	if err := r.update(lease("node", now), data.ORWatch); err != nil {
+		t.Fatalf("TestLiveness(renew): got err == %v, want err == nil", err)
+	}
+	if len(r.ch) != 0 {
+		t.Errorf("TestLiveness(renew): got entry, want no entry")
+	}
+
+	// No renewal for over half the duration is late.
+	now = now.Add(25 * time.Second)
+	r.check()
+	if c := recv(t, r); c.ChangeType != data.CTUpdate || c.Old.State != data.LSHealthy || c.New.State != data.LSLate {
+		t.Errorf("TestLiveness(late): got %v %v->%v, want CTUpdate Healthy->Late", c.ChangeType, c.Old.State, c.New.State)
+	}
+	r.check()
+	if len(r.ch) != 0 {
+		t.Errorf("TestLiveness(still late): got entry, want no entry")
+	}
+
+	// Past the duration is expired.
+	now = now.Add(20 * time.Second)
+	r.check()
+	if c := recv(t, r); c.New.State != data.LSExpired {
+		t.Errorf("TestLiveness(expired): got %v, want Expired", c.New.State)
+	}
+
+	// A renewal brings it back.
+	if err := r.update(lease("node", now), data.ORWatch); err != nil {
+		t.Fatalf("TestLiveness(recovered): got err == %v, want err == nil", err)
+	}
+	if c := recv(t, r); c.Old.State != data.LSExpired || c.New.State != data.LSHealthy {
+		t.Errorf("TestLiveness(recovered): got %v->%v, want Expired->Healthy", c.Old.State, c.New.State)
+	}
+
+	// Delete emits the last known liveness.
+	if err := r.delete(lease("node", now)); err != nil {
+		t.Fatalf("TestLiveness(delete): got err == %v, want err == nil", err)
+	}
+	if c := recv(t, r); c.ChangeType != data.CTDelete || c.Old.State != data.LSHealthy {
+		t.Errorf("TestLiveness(delete): got %v %v, want CTDelete Healthy", c.ChangeType, c.Old.State)
+	}
+	if len(r.nodes) != 0 {
+		t.Errorf("TestLiveness(delete): got %d nodes, want 0", len(r.nodes))
+	}
+
+	// A tombstone is a delete whose final state may be stale.
+	if err := r.add(lease("other", now), data.ORWatch); err != nil {
+		t.Fatalf("TestLiveness(tombstone): got err == %v, want err == nil", err)
+	}
+	recv(t, r)
+	if err := r.delete(cache.DeletedFinalStateUnknown{Key: "kube-node-lease/other", Obj: lease("other", now)}); err != nil {
+		t.Fatalf("TestLiveness(tombstone): got err == %v, want err == nil", err)
+	}
+	if c := recv(t, r); c.ChangeType != data.CTDelete || !c.Flags.Has(data.CFStaleFinalState) {
+		t.Errorf("TestLiveness(tombstone): got %v %v, want CTDelete with CFStaleFinalState", c.ChangeType, c.Flags)
+	}
+}
+
+func TestInitialList(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	// Dropped.
+	r := newTestReader(&now)
+	r.dropInitialList = true
+	r.addHandler(lease("node", now), true)
+	if len(r.ch) != 0 {
+		t.Errorf("TestInitialList(drop): got entry, want no entry")
+	}
+
+	// Routed, with the marker sent on both channels.
+	r = newTestReader(&now)
+	r.initialListOut = make(chan data.Entry, 2)
+	r.addHandler(lease("node", now), true)
+	r.addHandler(lease("other", now), false)
+	if err := r.sendSyncComplete(); err != nil {
+		t.Fatalf("TestInitialList(route): got err == %v, want err == nil", err)
+	}
+	if e := <-r.initialListOut; e.Origin != data.ORInitialList {
+		t.Errorf("TestInitialList(route): got initial list Origin == %v, want %v", e.Origin, data.ORInitialList)
+	}
+	if e := <-r.ch; e.Origin != data.ORWatch {
+		t.Errorf("TestInitialList(route): got output Origin == %v, want %v", e.Origin, data.ORWatch)
+	}
+	for _, ch := range []chan data.Entry{r.initialListOut, r.ch} {
+		if e := <-ch; e.Type != data.ETSyncComplete {
+			t.Errorf("TestInitialList(route): got Type == %v, want %v", e.Type, data.ETSyncComplete)
+		}
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	t.Parallel()
+
+	// A check finds every lease late and, part way through sending, the leases are renewed. Each lease's
+	// entries must follow on from each other, never the check's stale Late after the update's Healthy.
+	renewed := time.Now()
+	late := renewed.Add(-25 * time.Second)
+	r := newTestReader(&renewed)
+	r.ch = make(chan data.Entry)
+	state := map[string]data.LivenessState{}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		r.nodes[name] = data.NewNodeLiveness(lease(name, late), late)
+		state[name] = data.LSHealthy
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		r.check()
+	}()
+	got := []data.Entry{<-r.ch}
+	go func() {
+		defer wg.Done()
+		for name := range state {
+			if err := r.update(lease(name, renewed), data.ORWatch); err != nil {
+				t.Errorf("TestCheckOrder: got err == %v, want err == nil", err)
+			}
+		}
+	}()
+	// Let the update reach its first send, which queues behind the check's next one.
+	time.Sleep(50 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for recvd := false; !recvd; {
+		select {
+		case e := <-r.ch:
+			got = append(got, e)
+		case <-done:
+			recvd = true
+		}
+	}
+
+	for _, e := range got {
+		i, err := e.Informer()
+		if err != nil {
+			t.Fatalf("TestCheckOrder: got err == %v, want err == nil", err)
+		}
+		c, err := i.NodeLiveness()
+		if err != nil {
+			t.Fatalf("TestCheckOrder: got err == %v, want err == nil", err)
+		}
+		if c.Old.State != state[c.New.Name] {
+			t.Errorf("TestCheckOrder(%s): got %v->%v after %v, want it to follow on", c.New.Name, c.Old.State, c.New.State, state[c.New.Name])
+		}
+		state[c.New.Name] = c.New.State
+	}
+	for name, s := range state {
+		if s != data.LSHealthy {
+			t.Errorf("TestCheckOrder(%s): got final state %v, want %v", name, s, data.LSHealthy)
+		}
+	}
+}
@@ -6,21 +6,45 @@ import (
 	"log"
 	"log/slog"
 	"reflect"
+	"sync/atomic"
 	"time"
 
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/apiserver/scope"
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
+// Reader reads the storage family, PersistentVolumes, PersistentVolumeClaims and StorageClasses, from the
+// Kubernetes API server. All are emitted as data.ETPersistentVolume entries.
 type Reader struct {
-	informer cache.SharedIndexInformer
-	ch       chan data.Entry
+	informers     []cache.SharedIndexInformer
+	retrieveTypes RetrieveType
+	scope         scope.Scope
+	transform     cache.TransformFunc
+	ch            chan data.Entry
+
+	// synced report when the handlers have been sent the initial list of each informer, which
+	// must happen before a data.SyncComplete marker is sent.
+	synced []cache.InformerSynced
+
+	// dropInitialList, initialListOut and syncComplete control handling of the initial list.
+	dropInitialList bool
+	initialListOut  chan data.Entry
+	syncComplete    bool
+
+	// suppressResyncs, semanticResyncs and normalizers control handling of resync updates. suppressed
+	// counts the resyncs that were dropped.
+	suppressResyncs bool
+	semanticResyncs bool
+	normalizers     []data.Normalizer
+	suppressed      atomic.Uint64
 
 	started bool
 	stop    chan struct{}
@@ -39,11 +63,134 @@ func WithLogger(log *slog.Logger) Option {
 	}
 }
 
-// New creates a new Reader that reads PersistentVolumes from the Kubernetes API server.
+// RetrieveType is the type of storage data to retrieve. Uses as a bitwise flag.
+type RetrieveType uint8
+
+const (
+	// RTPersistentVolume retrieves persistent volume data.
+	RTPersistentVolume RetrieveType = 0x1
+	// RTPersistentVolumeClaim retrieves persistent volume claim data.
+	RTPersistentVolumeClaim RetrieveType = 0x2
+	// RTStorageClass retrieves storage class data.
+	RTStorageClass RetrieveType = 0x4
+)
+
+// WithRetrieveTypes sets the storage data to retrieve. Defaults to RTPersistentVolume.
+func WithRetrieveTypes(rt RetrieveType) Option {
+	return func(r *Reader) error {
+		if rt == 0 {
+			return fmt.Errorf("no storage types to retrieve")
+		}
+		r.retrieveTypes = rt
+		return nil
+	}
+}
+
+// WithNamespaces only watches PersistentVolumeClaims in namespaces. This creates an informer per namespace.
+// PersistentVolumes and StorageClasses are cluster scoped and are not affected.
+func WithNamespaces(namespaces ...string) Option {
+	return func(r *Reader) error {
+		r.scope.Namespaces = append(r.scope.Namespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithoutNamespaces does not watch PersistentVolumeClaims in namespaces. PersistentVolumes and StorageClasses
+// are cluster scoped and are not affected. This cannot be used with WithNamespaces().
+func WithoutNamespaces(namespaces ...string) Option {
+	return func(r *Reader) error {
+		r.scope.ExcludeNamespaces = append(r.scope.ExcludeNamespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithLabelSelector only watches storage objects that match the label selector sel.
+func WithLabelSelector(sel string) Option {
+	return func(r *Reader) error {
+		r.scope.LabelSelector = sel
+		return nil
+	}
+}
+
+// WithFieldSelector only watches storage objects that match the field selector sel. This applies to all
+// retrieved types, so it must use fields every type supports, such as metadata.name.
+func WithFieldSelector(sel string) Option {
+	return func(r *Reader) error {
+		r.scope.FieldSelector = sel
+		return nil
+	}
+}
+
+// WithoutInitialList drops the adds the informers send for objects that exist when the Reader starts.
+// Cannot be used with WithInitialListOut().
+func WithoutInitialList() Option {
+	return func(r *Reader) error {
+		r.dropInitialList = true
+		return nil
+	}
+}
+
+// WithInitialListOut sends the adds the informers send for objects that exist when the Reader starts on
+// out instead of the output channel. out must be read from for Run() to complete. Cannot be used with
+// WithoutInitialList().
+func WithInitialListOut(out chan data.Entry) Option {
+	return func(r *Reader) error {
+		if out == nil {
+			return fmt.Errorf("initial list channel cannot be nil")
+		}
+		r.initialListOut = out
+		return nil
+	}
+}
+
+// WithSyncComplete emits a data.ETSyncComplete marker entry once all informers have sent their initial
+// list. It is sent on the output channel and, if set, the WithInitialListOut() channel.
+func WithSyncComplete() Option {
+	return func(r *Reader) error {
+		r.syncComplete = true
+		return nil
+	}
+}
+
+// WithoutResyncs drops updates that are resyncs, where the object's resourceVersion has not changed.
+// Informers send these for every object on each resync period and on relists that find nothing changed.
+// The number dropped is available from SuppressedResyncs(). Without this, resyncs are emitted with an
+// Origin of data.ORResync.
+func WithoutResyncs() Option {
+	return func(r *Reader) error {
+		r.suppressResyncs = true
+		return nil
+	}
+}
+
+// WithSemanticResyncs also treats updates as resyncs if the old and new objects are semantically equal
+// once their resourceVersion is cleared and normalizers, such as data.IgnoreManagedFields, are applied.
+// This copies both objects on every update.
+func WithSemanticResyncs(normalizers ...data.Normalizer) Option {
+	return func(r *Reader) error {
+		r.semanticResyncs = true
+		r.normalizers = append(r.normalizers, normalizers...)
+		return nil
+	}
+}
+
+// WithTransform installs fn with SetTransform() on every informer, which is applied to objects before they
+// are stored in the cache. See the transform package for a default.
+func WithTransform(fn cache.TransformFunc) Option {
+	return func(r *Reader) error {
+		r.transform = fn
+		return nil
+	}
+}
+
+// New creates a new Reader that reads PersistentVolumes from the Kubernetes API server. Use WithRetrieveTypes()
+// to also read PersistentVolumeClaims and StorageClasses. Use WithNamespaces(), WithoutNamespaces(),
+// WithLabelSelector() and WithFieldSelector() to limit what is watched.
 func New(ctx context.Context, clientset *kubernetes.Clientset, resync time.Duration, options ...Option) (*Reader, error) {
 	r := &Reader{
-		stop: make(chan struct{}),
-		log:  slog.Default(),
+		retrieveTypes: RTPersistentVolume,
+		stop:          make(chan struct{}),
+		log:           slog.Default(),
 	}
 
 	pvs, err := clientset.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
@@ -58,29 +205,79 @@ func New(ctx context.Context, clientset *kubernetes.Clientset, resync time.Durat
 		}
 	}
 
-	r.informer = cache.NewSharedIndexInformer(
-		cache.NewListWatchFromClient(
-			clientset.CoreV1().RESTClient(),
-			"persistentvolumes",
-			metav1.NamespaceAll,
-			fields.Everything(),
-		),
-		&v1.PersistentVolume{},
-		resync,
-		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
-	)
-
-	r.informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    r.addHandler,
-			UpdateFunc: r.updateHandler,
-			DeleteFunc: r.deleteHandler,
+	handlers := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc:    r.addHandler,
+		UpdateFunc: r.updateHandler,
+		DeleteFunc: r.deleteHandler,
+	}
+
+	if err := r.scope.Validate(); err != nil {
+		return nil, err
+	}
+	if r.dropInitialList && r.initialListOut != nil {
+		return nil, fmt.Errorf("cannot use both WithoutInitialList() and WithInitialListOut()")
+	}
+
+	type inform struct {
+		rt       RetrieveType
+		lw       *cache.ListWatch
+		objType  runtime.Object
+		indexers cache.Indexers
+	}
+
+	core := clientset.CoreV1().RESTClient()
+	informs := []inform{
+		{
+			RTPersistentVolume,
+			cache.NewFilteredListWatchFromClient(core, "persistentvolumes", metav1.NamespaceAll, r.scope.ClusterTweak()),
+			&v1.PersistentVolume{},
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		},
+		{
+			RTStorageClass,
+			cache.NewFilteredListWatchFromClient(clientset.StorageV1().RESTClient(), "storageclasses", metav1.NamespaceAll, r.scope.ClusterTweak()),
+			&storagev1.StorageClass{},
+			cache.Indexers{},
 		},
-	)
+	}
+	// The APIServer can only list claims in one namespace or all of them, so an allowlist needs an
+	// informer per namespace.
+	for _, ns := range r.scope.NamespacedNamespaces() {
+		informs = append(
+			informs,
+			inform{
+				RTPersistentVolumeClaim,
+				cache.NewFilteredListWatchFromClient(core, "persistentvolumeclaims", ns, r.scope.NamespacedTweak()),
+				&v1.PersistentVolumeClaim{},
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+			},
+		)
+	}
+
+	for _, i := range informs {
+		if r.retrieveTypes&i.rt != i.rt {
+			continue
+		}
+		informer := cache.NewSharedIndexInformer(i.lw, i.objType, resync, i.indexers)
+		if r.transform != nil {
+			if err := informer.SetTransform(r.transform); err != nil {
+				return nil, err
+			}
+		}
+		reg, err := informer.AddEventHandler(handlers)
+		if err != nil {
+			return nil, err
+		}
+		r.informers = append(r.informers, informer)
+		r.synced = append(r.synced, reg.HasSynced)
+	}
 
 	return r, nil
 }
 
+// readerName is the name of the Reader returned by Name() and used in data.SyncComplete markers.
+const readerName = "persistentvolumes"
+
 var closeDelay = 100 * time.Millisecond
 
 // Close closes the Changes object. This will block until all indexes are stopped.
@@ -94,16 +291,20 @@ start:
 		return ctx.Err()
 	}
 
-	for {
-		if !c.informer.IsStopped() {
+	for _, informer := range c.informers {
+		if !informer.IsStopped() {
 			time.Sleep(closeDelay)
 			goto start
 		}
-		break
 	}
 	return nil
 }
 
+// Name returns the name of the Reader, which the tattler Runner puts in data.Entry.Reader.
+func (r *Reader) Name() string {
+	return readerName
+}
+
 // SetOut sets the output channel that the reader must output on. Must return an error and be a no-op
 // if Run() has been called.
 func (r *Reader) SetOut(ctx context.Context, out chan data.Entry) error {
@@ -125,10 +326,12 @@ func (r *Reader) Run(ctx context.Context) error {
 	}
 	r.started = true
 
-	go r.informer.Run(r.stop)
+	for _, informer := range r.informers {
+		go informer.Run(r.stop)
+	}
 
 	log.Println("called")
-	if !cache.WaitForCacheSync(r.stop, r.informer.HasSynced) {
+	if !cache.WaitForCacheSync(r.stop, r.synced...) {
 		r.started = false
 		r.stop = make(chan struct{})
 		return fmt.Errorf("failed to sync cache")
@@ -136,13 +339,22 @@ func (r *Reader) Run(ctx context.Context) error {
 
 	log.Println("Started")
 
+	if r.syncComplete {
+		if err := r.sendSyncComplete(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // addHandler is the event handler for adding data. This is a shim around addOrDelete.
-func (c *Reader) addHandler(obj any) {
+func (c *Reader) addHandler(obj any, isInInitialList bool) {
 	log.Println("addHandler")
-	err := c.addOrDelete(obj, data.CTAdd)
+	origin := data.ORWatch
+	if isInInitialList {
+		origin = data.ORInitialList
+	}
+	err := c.addOrDelete(obj, data.CTAdd, origin)
 	if err != nil {
 		c.log.Error(err.Error())
 	}
@@ -159,46 +371,56 @@ func (c *Reader) updateHandler(oldObj any, newObj any) {
 // deleteHandler is the event handler for deleting data. This is a shim around addOrDelete.
 func (c *Reader) deleteHandler(obj any) {
 	log.Println("deleteHandler")
-	err := c.addOrDelete(obj, data.CTDelete)
+	err := c.addOrDelete(obj, data.CTDelete, data.ORWatch)
 	if err != nil {
 		c.log.Error(err.Error())
 	}
 }
 
-// addOrDelete handles event types add and delete.
-func (c *Reader) addOrDelete(obj any, ct data.ChangeType) error {
+// addOrDelete handles event types add and delete. origin is set on the emitted entry.
+func (c *Reader) addOrDelete(obj any, ct data.ChangeType, origin data.Origin) error {
 	if obj == nil {
 		return fmt.Errorf("persistentvolumes.Reader.addOrDelete(): obj cannot be nil")
 	}
 
+	// When a watch misses a delete, the relist finds the object gone and the informer sends a tombstone
+	// holding the last state it had.
+	var flags data.ChangeFlag
+	if t, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if ct != data.CTDelete {
+			return fmt.Errorf("persistentvolumes.Reader.addOrDelete(): tombstone for %s received for change type %v", t.Key, ct)
+		}
+		if t.Obj == nil {
+			return fmt.Errorf("persistentvolumes.Reader.addOrDelete(): tombstone for %s has no object", t.Key)
+		}
+		obj = t.Obj
+		flags = data.CFStaleFinalState
+	}
+
 	var d data.PersistentVolume
+	var err error
 	switch v := obj.(type) {
 	case *v1.PersistentVolume:
 		log.Println("its a pv")
-		pvc := data.Change[*v1.PersistentVolume]{ChangeType: ct, ObjectType: data.OTPersistentVolume}
-		switch ct {
-		case data.CTAdd:
-			pvc.New = v
-		case data.CTDelete:
-			pvc.Old = v
-		default:
-			return fmt.Errorf("unsupported change type in persistentvolumes.Reader.addOrDelete(): %d", ct)
-		}
-		var err error
-		d, err = data.NewPersistentVolume(pvc)
-		if err != nil {
-			return err
-		}
+		d, err = addOrDeleteStorage(v, ct, flags, data.OTPersistentVolume)
+	case *v1.PersistentVolumeClaim:
+		d, err = addOrDeleteStorage(v, ct, flags, data.OTPersistentVolumeClaim)
+	case *storagev1.StorageClass:
+		d, err = addOrDeleteStorage(v, ct, flags, data.OTStorageClass)
 	default:
 		return fmt.Errorf("persistent volumnes: unknown object type: %T", obj)
 	}
+	if err != nil {
+		return err
+	}
 
 	e, err := data.NewEntry(d)
 	if err != nil {
 		return err
 	}
+	e.Origin = origin
 
-	c.ch <- e
+	c.send(e)
 	return nil
 }
 
@@ -215,32 +437,115 @@ func (c *Reader) update(oldObj any, newObj any) error {
 		return fmt.Errorf("persistentvolumes.Reader.update(): oldObj(%T) and newObj(%T) are not the same type", oldObj, newObj)
 	}
 
+	origin := c.updateOrigin(oldObj, newObj)
+	if origin == data.ORResync && c.suppressResyncs {
+		c.suppressed.Add(1)
+		return nil
+	}
+
 	var d data.PersistentVolume
+	var err error
 	switch v := newObj.(type) {
 	case *v1.PersistentVolume:
 		log.Println("happened")
-		pvc := data.Change[*v1.PersistentVolume]{
-			ChangeType: data.CTUpdate,
-			ObjectType: data.OTPersistentVolume,
-			New:        v,
-			Old:        oldObj.(*v1.PersistentVolume),
-		}
-
-		var err error
-		d, err = data.NewPersistentVolume(pvc)
-		if err != nil {
-			panic("wtf")
-			return err
-		}
+		d, err = updateStorage(oldObj.(*v1.PersistentVolume), v, data.OTPersistentVolume)
+	case *v1.PersistentVolumeClaim:
+		d, err = updateStorage(oldObj.(*v1.PersistentVolumeClaim), v, data.OTPersistentVolumeClaim)
+	case *storagev1.StorageClass:
+		d, err = updateStorage(oldObj.(*storagev1.StorageClass), v, data.OTStorageClass)
 	default:
 		return fmt.Errorf("unknown object type: %T", newObj)
 	}
+	if err != nil {
+		return err
+	}
 
 	e, err := data.NewEntry(d)
 	if err != nil {
 		return err
 	}
+	e.Origin = origin
+
+	c.send(e)
+	return nil
+}
+
+// updateOrigin returns the data.Origin of an update. Updates are resyncs if the resourceVersion did not
+// change or, with WithSemanticResyncs(), the objects are semantically equal.
+func (c *Reader) updateOrigin(oldObj, newObj any) data.Origin {
+	origin := data.UpdateOrigin(oldObj, newObj)
+	if origin == data.ORResync || !c.semanticResyncs {
+		return origin
+	}
+	o, ok := oldObj.(runtime.Object)
+	if !ok {
+		return origin
+	}
+	n, ok := newObj.(runtime.Object)
+	if !ok {
+		return origin
+	}
+	if data.SemanticEqual(o, n, c.normalizers...) {
+		return data.ORResync
+	}
+	return origin
+}
+
+// SuppressedResyncs returns the number of resync updates dropped because of WithoutResyncs().
+func (c *Reader) SuppressedResyncs() uint64 {
+	return c.suppressed.Load()
+}
 
+// sendSyncComplete sends a data.ETSyncComplete marker on the output channel and the initial list channel.
+func (c *Reader) sendSyncComplete() error {
+	e, err := data.NewEntry(data.NewSyncComplete(readerName, time.Now()))
+	if err != nil {
+		return err
+	}
+	if c.initialListOut != nil {
+		c.initialListOut <- e
+	}
 	c.ch <- e
 	return nil
 }
+
+// send sets when e was observed and sends it on the output channel. Entries from the initial list are instead dropped or sent on the
+// initial list channel if WithoutInitialList() or WithInitialListOut() were used.
+func (c *Reader) send(e data.Entry) {
+	e.Observed = time.Now()
+	if e.Origin == data.ORInitialList {
+		switch {
+		case c.dropInitialList:
+			return
+		case c.initialListOut != nil:
+			c.initialListOut <- e
+			return
+		}
+	}
+	c.ch <- e
+}
+
+// addOrDeleteStorage wraps obj in a data.Change of type ct with flags and returns it as a data.PersistentVolume.
+func addOrDeleteStorage[T data.K8Object](obj T, ct data.ChangeType, flags data.ChangeFlag, ot data.ObjectType) (data.PersistentVolume, error) {
+	change := data.Change[T]{ChangeType: ct, ObjectType: ot, Flags: flags}
+	switch ct {
+	case data.CTAdd:
+		change.New = obj
+	case data.CTDelete:
+		change.Old = obj
+	default:
+		return data.PersistentVolume{}, fmt.Errorf("unsupported change type in persistentvolumes.Reader.addOrDelete(): %d", ct)
+	}
+	return data.NewPersistentVolume(change)
+}
+
+// updateStorage wraps oldObj and newObj in an update data.Change and returns it as a data.PersistentVolume.
+func updateStorage[T data.K8Object](oldObj, newObj T, ot data.ObjectType) (data.PersistentVolume, error) {
+	change := data.Change[T]{
+		ChangeType: data.CTUpdate,
+		ObjectType: ot,
+		New:        newObj,
+		Old:        oldObj,
+	}
+	return data.NewPersistentVolume(change)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/kylelemons/godebug/pretty"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -20,9 +21,15 @@ func TestClose(t *testing.T) {
 	c := &Reader{
 		ch:   make(chan data.Entry, 1),
 		stop: stop,
-		informer: timedInformers{
-			ch:    stop,
-			delay: 1 * time.Second,
+		informers: []cache.SharedIndexInformer{
+			timedInformers{
+				ch:    stop,
+				delay: 1 * time.Second,
+			},
+			timedInformers{
+				ch:    stop,
+				delay: 1 * time.Second,
+			},
 		},
 	}
 
@@ -30,8 +37,9 @@ func TestClose(t *testing.T) {
 	c.Close(context.Background())
 
 	sum := time.Duration(0)
-	ti := c.informer.(timedInformers)
-	sum += ti.delay
+	for _, informer := range c.informers {
+		sum += informer.(timedInformers).delay
+	}
 
 	since := time.Since(now)
 	if time.Since(now) < sum {
@@ -84,12 +92,55 @@ func TestAddOrDelete(t *testing.T) {
 				},
 			),
 		},
+		{
+			name: "PersistentVolumeClaim add",
+			obj:  &corev1.PersistentVolumeClaim{},
+			ct:   data.CTAdd,
+			want: data.MustNewPersistentVolume(
+				data.Change[*corev1.PersistentVolumeClaim]{
+					ChangeType: data.CTAdd,
+					ObjectType: data.OTPersistentVolumeClaim,
+					New:        &corev1.PersistentVolumeClaim{},
+				},
+			),
+		},
+		{
+			name:    "Error: tombstone add",
+			obj:     cache.DeletedFinalStateUnknown{Key: "pv", Obj: &corev1.PersistentVolume{}},
+			ct:      data.CTAdd,
+			wantErr: true,
+		},
+		{
+			name: "PersistentVolume tombstone delete",
+			obj:  cache.DeletedFinalStateUnknown{Key: "pv", Obj: &corev1.PersistentVolume{}},
+			ct:   data.CTDelete,
+			want: data.MustNewPersistentVolume(
+				data.Change[*corev1.PersistentVolume]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTPersistentVolume,
+					Old:        &corev1.PersistentVolume{},
+					Flags:      data.CFStaleFinalState,
+				},
+			),
+		},
+		{
+			name: "StorageClass delete",
+			obj:  &storagev1.StorageClass{},
+			ct:   data.CTDelete,
+			want: data.MustNewPersistentVolume(
+				data.Change[*storagev1.StorageClass]{
+					ChangeType: data.CTDelete,
+					ObjectType: data.OTStorageClass,
+					Old:        &storagev1.StorageClass{},
+				},
+			),
+		},
 	}
 
 	for _, test := range tests {
 		c := &Reader{ch: make(chan data.Entry, 1)}
 
-		err := c.addOrDelete(test.obj, test.ct)
+		err := c.addOrDelete(test.obj, test.ct, data.ORWatch)
 		switch {
 		case test.wantErr && err == nil:
 			t.Errorf("TestAddOrDelete(%s): got err == nil, want err != nil", test.name)
@@ -157,6 +208,28 @@ func TestUpdate(t *testing.T) {
 				Old:        &corev1.PersistentVolume{},
 			}),
 		},
+		{
+			name:   "PersistentVolumeClaim update",
+			oldObj: &corev1.PersistentVolumeClaim{},
+			newObj: &corev1.PersistentVolumeClaim{},
+			want: data.MustNewPersistentVolume(data.Change[*corev1.PersistentVolumeClaim]{
+				ChangeType: data.CTUpdate,
+				ObjectType: data.OTPersistentVolumeClaim,
+				New:        &corev1.PersistentVolumeClaim{},
+				Old:        &corev1.PersistentVolumeClaim{},
+			}),
+		},
+		{
+			name:   "StorageClass update",
+			oldObj: &storagev1.StorageClass{},
+			newObj: &storagev1.StorageClass{},
+			want: data.MustNewPersistentVolume(data.Change[*storagev1.StorageClass]{
+				ChangeType: data.CTUpdate,
+				ObjectType: data.OTStorageClass,
+				New:        &storagev1.StorageClass{},
+				Old:        &storagev1.StorageClass{},
+			}),
+		},
 	}
 
 	for _, test := range tests {
@@ -207,3 +280,35 @@ func (t timedInformers) IsStopped() bool {
 	time.Sleep(t.delay)
 	return true
 }
+
+func TestInitialList(t *testing.T) {
+	t.Parallel()
+
+	pv := &corev1.PersistentVolume{}
+
+	// Dropped.
+	c := &Reader{ch: make(chan data.Entry, 1), dropInitialList: true}
+	c.addHandler(pv, true)
+	if len(c.ch) != 0 {
+		t.Errorf("TestInitialList(drop): got entry, want no entry")
+	}
+
+	// Routed, with the marker sent on both channels.
+	c = &Reader{ch: make(chan data.Entry, 2), initialListOut: make(chan data.Entry, 2)}
+	c.addHandler(pv, true)
+	c.addHandler(pv, false)
+	if err := c.sendSyncComplete(); err != nil {
+		t.Fatalf("TestInitialList(route): got err == %v, want err == nil", err)
+	}
+	if e := <-c.initialListOut; e.Origin != data.ORInitialList {
+		t.Errorf("TestInitialList(route): got initial list Origin == %v, want %v", e.Origin, data.ORInitialList)
+	}
+	if e := <-c.ch; e.Origin != data.ORWatch {
+		t.Errorf("TestInitialList(route): got output Origin == %v, want %v", e.Origin, data.ORWatch)
+	}
+	for _, ch := range []chan data.Entry{c.initialListOut, c.ch} {
+		if e := <-ch; e.Type != data.ETSyncComplete {
+			t.Errorf("TestInitialList(route): got Type == %v, want %v", e.Type, data.ETSyncComplete)
+		}
+	}
+}
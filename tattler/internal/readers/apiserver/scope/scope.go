@@ -0,0 +1,129 @@
+// Package scope holds the namespace, label and field scoping shared by the APIServer readers.
+//
+// A Scope maps onto the list options of an informer. Namespaced resources get one informer per namespace
+// when there is a namespace allowlist, as the APIServer can only list a single namespace or all of them.
+// A namespace denylist is done with a metadata.namespace field selector. Cluster scoped resources ignore
+// namespaces and only use the label and field selectors.
+package scope
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scope limits what a reader watches.
+type Scope struct {
+	// Namespaces is an allowlist of namespaces to watch. Empty means all namespaces. Each namespace can only
+	// be listed once.
+	Namespaces []string
+	// ExcludeNamespaces is a denylist of namespaces not to watch. This cannot be used with Namespaces.
+	ExcludeNamespaces []string
+	// LabelSelector is a label selector applied to all resources.
+	LabelSelector string
+	// FieldSelector is a field selector applied to all resources. This must be supported by every resource
+	// the reader watches, which in practice limits it to metadata.name and metadata.namespace.
+	FieldSelector string
+}
+
+// IsZero reports if s watches everything.
+func (s Scope) IsZero() bool {
+	return len(s.Namespaces) == 0 && len(s.ExcludeNamespaces) == 0 && s.LabelSelector == "" && s.FieldSelector == ""
+}
+
+// Validate validates the Scope.
+func (s Scope) Validate() error {
+	if len(s.Namespaces) > 0 && len(s.ExcludeNamespaces) > 0 {
+		return fmt.Errorf("cannot have both a namespace allowlist and denylist")
+	}
+	seen := map[string]bool{}
+	for _, ns := range append(append([]string{}, s.Namespaces...), s.ExcludeNamespaces...) {
+		if ns == "" {
+			return fmt.Errorf("namespace cannot be empty")
+		}
+		// A duplicate in the allowlist would create a second informer factory for the namespace.
+		if seen[ns] {
+			return fmt.Errorf("namespace %q is listed more than once", ns)
+		}
+		seen[ns] = true
+	}
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("bad label selector: %w", err)
+	}
+	if _, err := fields.ParseSelector(s.FieldSelector); err != nil {
+		return fmt.Errorf("bad field selector: %w", err)
+	}
+	return nil
+}
+
+// ClusterTweak returns the list options tweak for cluster scoped resources.
+func (s Scope) ClusterTweak() func(*metav1.ListOptions) {
+	return s.tweak(s.FieldSelector)
+}
+
+// NamespacedTweak returns the list options tweak for namespaced resources. This adds the namespace denylist.
+func (s Scope) NamespacedTweak() func(*metav1.ListOptions) {
+	sels := []string{}
+	if s.FieldSelector != "" {
+		sels = append(sels, s.FieldSelector)
+	}
+	for _, ns := range s.ExcludeNamespaces {
+		sels = append(sels, fields.OneTermNotEqualSelector("metadata.namespace", ns).String())
+	}
+	return s.tweak(strings.Join(sels, ","))
+}
+
+func (s Scope) tweak(fieldSelector string) func(*metav1.ListOptions) {
+	return func(o *metav1.ListOptions) {
+		o.LabelSelector = joinSelector(o.LabelSelector, s.LabelSelector)
+		o.FieldSelector = joinSelector(o.FieldSelector, fieldSelector)
+	}
+}
+
+// NamespacedNamespaces returns the namespaces to create an informer for namespaced resources in.
+// This is metav1.NamespaceAll when there is no allowlist.
+func (s Scope) NamespacedNamespaces() []string {
+	if len(s.Namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return s.Namespaces
+}
+
+// Factories returns informer factories for s. cluster is for cluster scoped resources and namespaced has
+// one factory for each namespace in NamespacedNamespaces().
+func (s Scope) Factories(clientset kubernetes.Interface, resync time.Duration) (cluster informers.SharedInformerFactory, namespaced []informers.SharedInformerFactory) {
+	cluster = informers.NewSharedInformerFactoryWithOptions(
+		clientset,
+		resync,
+		informers.WithTweakListOptions(s.ClusterTweak()),
+	)
+	for _, ns := range s.NamespacedNamespaces() {
+		namespaced = append(
+			namespaced,
+			informers.NewSharedInformerFactoryWithOptions(
+				clientset,
+				resync,
+				informers.WithNamespace(ns),
+				informers.WithTweakListOptions(s.NamespacedTweak()),
+			),
+		)
+	}
+	return cluster, namespaced
+}
+
+// joinSelector joins two selectors with a comma, ignoring empty ones.
+func joinSelector(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + "," + b
+}
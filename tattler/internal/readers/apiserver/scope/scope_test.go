@@ -0,0 +1,93 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		scope   Scope
+		wantErr bool
+	}{
+		{name: "Empty"},
+		{name: "Allowlist", scope: Scope{Namespaces: []string{"a", "b"}}},
+		{name: "Denylist with selectors", scope: Scope{ExcludeNamespaces: []string{"a"}, LabelSelector: "app=web", FieldSelector: "metadata.name=x"}},
+		{name: "Error: allowlist and denylist", scope: Scope{Namespaces: []string{"a"}, ExcludeNamespaces: []string{"b"}}, wantErr: true},
+		{name: "Error: empty namespace", scope: Scope{Namespaces: []string{""}}, wantErr: true},
+		{name: "Error: duplicate namespace", scope: Scope{Namespaces: []string{"a", "b", "a"}}, wantErr: true},
+		{name: "Error: duplicate excluded namespace", scope: Scope{ExcludeNamespaces: []string{"a", "a"}}, wantErr: true},
+		{name: "Error: bad label selector", scope: Scope{LabelSelector: "app in (web"}, wantErr: true},
+		{name: "Error: bad field selector", scope: Scope{FieldSelector: "metadata.name"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.scope.Validate()
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestValidate(%s): got err == nil, want err != nil", test.name)
+		case !test.wantErr && err != nil:
+			t.Errorf("TestValidate(%s): got err == %v, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestTweak(t *testing.T) {
+	t.Parallel()
+
+	s := Scope{ExcludeNamespaces: []string{"kube-system", "tenant"}, LabelSelector: "app=web", FieldSelector: "metadata.name!=skip"}
+
+	tests := []struct {
+		name  string
+		tweak func(*metav1.ListOptions)
+		in    metav1.ListOptions
+		want  metav1.ListOptions
+	}{
+		{
+			name:  "Cluster scoped ignores namespaces",
+			tweak: s.ClusterTweak(),
+			want:  metav1.ListOptions{LabelSelector: "app=web", FieldSelector: "metadata.name!=skip"},
+		},
+		{
+			name:  "Namespaced adds denylist",
+			tweak: s.NamespacedTweak(),
+			want: metav1.ListOptions{
+				LabelSelector: "app=web",
+				FieldSelector: "metadata.name!=skip,metadata.namespace!=kube-system,metadata.namespace!=tenant",
+			},
+		},
+		{
+			name:  "Existing selectors are kept",
+			tweak: Scope{LabelSelector: "app=web"}.NamespacedTweak(),
+			in:    metav1.ListOptions{LabelSelector: "tier=front", FieldSelector: "metadata.name=x"},
+			want:  metav1.ListOptions{LabelSelector: "tier=front,app=web", FieldSelector: "metadata.name=x"},
+		},
+	}
+
+	for _, test := range tests {
+		got := test.in
+		test.tweak(&got)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestTweak(%s): -want/+got\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestFactories(t *testing.T) {
+	t.Parallel()
+
+	_, namespaced := Scope{}.Factories(fake.NewSimpleClientset(), 0)
+	if len(namespaced) != 1 {
+		t.Errorf("TestFactories(no allowlist): got %d namespaced factories, want 1", len(namespaced))
+	}
+	_, namespaced = Scope{Namespaces: []string{"a", "b"}}.Factories(fake.NewSimpleClientset(), 0)
+	if len(namespaced) != 2 {
+		t.Errorf("TestFactories(allowlist): got %d namespaced factories, want 2", len(namespaced))
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Package transform provides cache.TransformFunc implementations that shrink objects before they are stored in
+an informer's cache.
+
+Informer caches hold the full object for everything they watch, and those same objects are what flow through
+tattler. Fields like managedFields and the kubectl last-applied-configuration annotation are often larger than
+the rest of the object and are of little use for auditing. Stripping them in a transform cuts memory in both
+the cache and the pipeline.
+
+Usage:
+
+	r, err := informers.New(ctx, factory, informers.RTPod, informers.WithTransform(transform.Default()))
+	if err != nil {
+		// Do something
+	}
+
+	// Also drop node image lists and a large annotation our deployment tool writes.
+	fn := transform.Strip(transform.DefaultFields|transform.FNodeImages, "deploy.example.com/manifest")
+*/
+package transform
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// LastAppliedAnnotation is the annotation kubectl apply stores the last applied object in.
+const LastAppliedAnnotation = corev1.LastAppliedConfigAnnotation
+
+// Field is a field to strip from objects. Uses as a bitwise flag.
+type Field uint32
+
+const (
+	// FManagedFields strips metadata.managedFields from all objects.
+	FManagedFields Field = 0x1
+	// FLastApplied strips the kubectl last-applied-configuration annotation from all objects.
+	FLastApplied Field = 0x2
+	// FNodeImages strips status.images from nodes. This lists every image on the node and is rewritten by
+	// the kubelet as images are pulled and garbage collected.
+	FNodeImages Field = 0x4
+)
+
+// DefaultFields are the fields stripped by Default().
+const DefaultFields = FManagedFields | FLastApplied
+
+// Default returns a cache.TransformFunc that strips DefaultFields.
+func Default() cache.TransformFunc {
+	return Strip(DefaultFields)
+}
+
+// Strip returns a cache.TransformFunc that strips fields and any annotations with keys in annotations.
+// Objects are modified in place, which is allowed as the informer owns the object it passes to a transform.
+func Strip(fields Field, annotations ...string) cache.TransformFunc {
+	if fields&FLastApplied == FLastApplied {
+		annotations = append(annotations, LastAppliedAnnotation)
+	}
+
+	return func(obj any) (any, error) {
+		// A tombstone holds the last object we knew about, which was stored transformed. But a transform
+		// must be idempotent, so transforming it again is safe and covers informers that pass it through.
+		if t, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			inner, err := strip(t.Obj, fields, annotations)
+			if err != nil {
+				return nil, err
+			}
+			t.Obj = inner
+			return t, nil
+		}
+		return strip(obj, fields, annotations)
+	}
+}
+
+// strip removes fields and annotations from obj.
+func strip(obj any, fields Field, annotations []string) (any, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, fmt.Errorf("transform.Strip(): %T is not a Kubernetes object: %w", obj, err)
+	}
+
+	if fields&FManagedFields == FManagedFields {
+		m.SetManagedFields(nil)
+	}
+	if len(annotations) > 0 {
+		if a := m.GetAnnotations(); len(a) > 0 {
+			for _, k := range annotations {
+				delete(a, k)
+			}
+			m.SetAnnotations(a)
+		}
+	}
+	if fields&FNodeImages == FNodeImages {
+		if n, ok := obj.(*corev1.Node); ok {
+			n.Status.Images = nil
+		}
+	}
+	return obj, nil
+}
@@ -0,0 +1,187 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func heavyMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "default",
+		Annotations: map[string]string{
+			LastAppliedAnnotation: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"` + name + `"}}`,
+			"keep":                "me",
+			"big":                 "annotation",
+		},
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply},
+		},
+	}
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		fields      Field
+		annotations []string
+		obj         any
+		want        any
+		wantErr     bool
+	}{
+		{
+			name:    "Error: not a Kubernetes object",
+			fields:  DefaultFields,
+			obj:     "pod",
+			wantErr: true,
+		},
+		{
+			name:   "Default fields",
+			fields: DefaultFields,
+			obj:    &corev1.Pod{ObjectMeta: heavyMeta("pod")},
+			want: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pod",
+					Namespace:   "default",
+					Annotations: map[string]string{"keep": "me", "big": "annotation"},
+				},
+			},
+		},
+		{
+			name:        "Extra annotations and node images",
+			fields:      FManagedFields | FNodeImages,
+			annotations: []string{"big"},
+			obj: &corev1.Node{
+				ObjectMeta: heavyMeta("node"),
+				Status:     corev1.NodeStatus{Images: []corev1.ContainerImage{{Names: []string{"image"}}}},
+			},
+			want: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "node",
+					Namespace: "default",
+					Annotations: map[string]string{
+						LastAppliedAnnotation: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"node"}}`,
+						"keep":                "me",
+					},
+				},
+			},
+		},
+		{
+			name:   "Tombstone",
+			fields: FManagedFields,
+			obj:    cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", ManagedFields: []metav1.ManagedFieldsEntry{{}}}}},
+			want:   cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Strip(test.fields, test.annotations...)(test.obj)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestStrip(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestStrip(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestStrip(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+// syntheticPod returns a pod shaped like one created by kubectl apply of a Deployment, with the
+// managedFields the API server would add.
+func syntheticPod(i int) *corev1.Pod {
+	name := fmt.Sprintf("web-%d", i)
+	fieldsV1 := []byte(`{"f:metadata":{"f:labels":{".":{},"f:app":{},"f:pod-template-hash":{}},"f:ownerReferences":{".":{},"k:{\"uid\":\"1\"}":{}}},` +
+		`"f:spec":{"f:containers":{"k:{\"name\":\"web\"}":{".":{},"f:image":{},"f:imagePullPolicy":{},"f:name":{},"f:ports":{".":{},"k:{\"containerPort\":80,\"protocol\":\"TCP\"}":{".":{},"f:containerPort":{},"f:protocol":{}}},"f:resources":{},"f:terminationMessagePath":{},"f:terminationMessagePolicy":{}}},` +
+		`"f:dnsPolicy":{},"f:enableServiceLinks":{},"f:restartPolicy":{},"f:schedulerName":{},"f:securityContext":{},"f:terminationGracePeriodSeconds":{}}}`)
+	statusV1 := []byte(`{"f:status":{"f:conditions":{"k:{\"type\":\"ContainersReady\"}":{".":{},"f:lastProbeTime":{},"f:lastTransitionTime":{},"f:status":{},"f:type":{}},` +
+		`"k:{\"type\":\"Initialized\"}":{".":{},"f:lastProbeTime":{},"f:lastTransitionTime":{},"f:status":{},"f:type":{}},"k:{\"type\":\"Ready\"}":{".":{},"f:lastProbeTime":{},"f:lastTransitionTime":{},"f:status":{},"f:type":{}}},` +
+		`"f:containerStatuses":{},"f:hostIP":{},"f:phase":{},"f:podIP":{},"f:podIPs":{".":{},"k:{\"ip\":\"10.0.0.1\"}":{".":{},"f:ip":{}}},"f:startTime":{}}}`)
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web", "pod-template-hash": "abc123"},
+			Annotations: map[string]string{
+				LastAppliedAnnotation: `{"apiVersion":"v1","kind":"Pod","metadata":{"labels":{"app":"web"},"name":"` + name +
+					`","namespace":"default"},"spec":{"containers":[{"image":"nginx:1.25","name":"web","ports":[{"containerPort":80}]}]}}`,
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: append([]byte{}, fieldsV1...)}},
+				{Manager: "kubelet", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: append([]byte{}, statusV1...)}, Subresource: "status"},
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "nginx:1.25", Ports: []corev1.ContainerPort{{ContainerPort: 80}}}},
+		},
+	}
+}
+
+// BenchmarkStrip measures the memory held by a synthetic list of 100K pods, as an informer cache would hold
+// it, with and without the default transform. It reports the retained heap and the encoded size of a pod,
+// which is what flows through the pipeline.
+func BenchmarkStrip(b *testing.B) {
+	const pods = 100_000
+
+	benchmarks := []struct {
+		name      string
+		transform cache.TransformFunc
+	}{
+		{name: "None"},
+		{name: "Default", transform: Default()},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var retained int64
+			var encoded int
+			for i := 0; i < b.N; i++ {
+				before := heapAlloc()
+
+				list := make([]any, pods)
+				for p := range list {
+					list[p] = syntheticPod(p)
+					if bm.transform != nil {
+						var err error
+						if list[p], err = bm.transform(list[p]); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+
+				retained += heapAlloc() - before
+				j, err := json.Marshal(list[0])
+				if err != nil {
+					b.Fatal(err)
+				}
+				encoded = len(j)
+				runtime.KeepAlive(list)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N)/(1<<20), "heap-MB")
+			b.ReportMetric(float64(encoded), "json-bytes/pod")
+		})
+	}
+}
+
+func heapAlloc() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
@@ -0,0 +1,232 @@
+package data
+
+import (
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// admissionMatch is the part of a webhook or policy that decides what it is called for and what happens
+// when it cannot be called.
+type admissionMatch struct {
+	failurePolicy     *admissionregistrationv1.FailurePolicyType
+	namespaceSelector *metav1.LabelSelector
+	rules             []admissionregistrationv1.RuleWithOperations
+	excludeRules      []admissionregistrationv1.RuleWithOperations
+}
+
+// ValidatingWebhookFlags returns the ChangeFlags for a change to a ValidatingWebhookConfiguration.
+// Webhooks are matched by name. A removed webhook, including from a delete, sets CFRulesPermissive.
+func ValidatingWebhookFlags(c Change[*admissionregistrationv1.ValidatingWebhookConfiguration]) ChangeFlag {
+	if c.ChangeType == CTAdd {
+		return 0
+	}
+
+	oldHooks := map[string]admissionMatch{}
+	for _, w := range c.Old.Webhooks {
+		oldHooks[w.Name] = admissionMatch{failurePolicy: w.FailurePolicy, namespaceSelector: w.NamespaceSelector, rules: w.Rules}
+	}
+	newHooks := map[string]admissionMatch{}
+	if c.New != nil {
+		for _, w := range c.New.Webhooks {
+			newHooks[w.Name] = admissionMatch{failurePolicy: w.FailurePolicy, namespaceSelector: w.NamespaceSelector, rules: w.Rules}
+		}
+	}
+	return webhookFlags(oldHooks, newHooks)
+}
+
+// MutatingWebhookFlags returns the ChangeFlags for a change to a MutatingWebhookConfiguration.
+// Webhooks are matched by name. A removed webhook, including from a delete, sets CFRulesPermissive.
+func MutatingWebhookFlags(c Change[*admissionregistrationv1.MutatingWebhookConfiguration]) ChangeFlag {
+	if c.ChangeType == CTAdd {
+		return 0
+	}
+
+	oldHooks := map[string]admissionMatch{}
+	for _, w := range c.Old.Webhooks {
+		oldHooks[w.Name] = admissionMatch{failurePolicy: w.FailurePolicy, namespaceSelector: w.NamespaceSelector, rules: w.Rules}
+	}
+	newHooks := map[string]admissionMatch{}
+	if c.New != nil {
+		for _, w := range c.New.Webhooks {
+			newHooks[w.Name] = admissionMatch{failurePolicy: w.FailurePolicy, namespaceSelector: w.NamespaceSelector, rules: w.Rules}
+		}
+	}
+	return webhookFlags(oldHooks, newHooks)
+}
+
+// ValidatingAdmissionPolicyFlags returns the ChangeFlags for a change to a ValidatingAdmissionPolicy.
+// A delete sets CFRulesPermissive.
+func ValidatingAdmissionPolicyFlags(c Change[*admissionregistrationv1.ValidatingAdmissionPolicy]) ChangeFlag {
+	switch c.ChangeType {
+	case CTAdd:
+		return 0
+	case CTDelete:
+		return CFRulesPermissive
+	}
+	return matchFlags(policyMatch(c.Old), policyMatch(c.New))
+}
+
+// policyMatch returns the admissionMatch for a ValidatingAdmissionPolicy. Resource names are ignored,
+// a rule limited to names is treated as the same rule without the limit.
+func policyMatch(p *admissionregistrationv1.ValidatingAdmissionPolicy) admissionMatch {
+	m := admissionMatch{failurePolicy: p.Spec.FailurePolicy}
+	mc := p.Spec.MatchConstraints
+	if mc == nil {
+		return m
+	}
+	m.namespaceSelector = mc.NamespaceSelector
+	for _, r := range mc.ResourceRules {
+		m.rules = append(m.rules, r.RuleWithOperations)
+	}
+	for _, r := range mc.ExcludeResourceRules {
+		m.excludeRules = append(m.excludeRules, r.RuleWithOperations)
+	}
+	return m
+}
+
+// webhookFlags compares webhooks by name.
+func webhookFlags(oldHooks, newHooks map[string]admissionMatch) ChangeFlag {
+	var flags ChangeFlag
+	for name, o := range oldHooks {
+		n, ok := newHooks[name]
+		if !ok {
+			flags |= CFRulesPermissive
+			continue
+		}
+		flags |= matchFlags(o, n)
+	}
+	return flags
+}
+
+// matchFlags compares two versions of the same webhook or policy.
+func matchFlags(o, n admissionMatch) ChangeFlag {
+	var flags ChangeFlag
+	if failsClosed(o.failurePolicy) && !failsClosed(n.failurePolicy) {
+		flags |= CFFailurePolicyPermissive
+	}
+	if selectorNarrowed(o.namespaceSelector, n.namespaceSelector) {
+		flags |= CFNamespaceSelectorPermissive
+	}
+	if !covered(admissionRuleKeys(o.rules), admissionRuleKeys(n.rules)) {
+		flags |= CFRulesPermissive
+	}
+	if !covered(admissionRuleKeys(n.excludeRules), admissionRuleKeys(o.excludeRules)) {
+		flags |= CFRulesPermissive
+	}
+	return flags
+}
+
+// failsClosed reports if a failure policy rejects requests when the webhook or policy fails. The
+// default for admissionregistration.k8s.io/v1 is Fail.
+func failsClosed(fp *admissionregistrationv1.FailurePolicyType) bool {
+	return fp == nil || *fp == admissionregistrationv1.Fail
+}
+
+// selectorNarrowed reports if n may match fewer namespaces than o. We do not know the namespaces, so any
+// requirement in n that is not in o is treated as narrowing. Removing requirements only widens a selector.
+func selectorNarrowed(o, n *metav1.LabelSelector) bool {
+	return !subset(selectorKeys(n), selectorKeys(o))
+}
+
+// selectorKeys returns a key for each requirement of sel. A nil or empty selector has none and matches
+// everything.
+func selectorKeys(sel *metav1.LabelSelector) map[string]bool {
+	keys := map[string]bool{}
+	if sel == nil {
+		return keys
+	}
+	for k, v := range sel.MatchLabels {
+		keys[k+"\x00In\x00"+v] = true
+	}
+	for _, e := range sel.MatchExpressions {
+		keys[e.Key+"\x00"+string(e.Operator)+"\x00"+strings.Join(e.Values, ",")] = true
+	}
+	return keys
+}
+
+// admissionKey is one operation, group, version, resource and scope that a rule matches. Any of them may
+// be a wildcard.
+type admissionKey struct {
+	op, group, version, resource string
+	scope                        admissionregistrationv1.ScopeType
+}
+
+// admissionRuleKeys expands rules into a key per operation, group, version, resource and scope.
+func admissionRuleKeys(rules []admissionregistrationv1.RuleWithOperations) []admissionKey {
+	var keys []admissionKey
+	for _, r := range rules {
+		scope := admissionregistrationv1.AllScopes
+		if r.Scope != nil {
+			scope = *r.Scope
+		}
+		for _, op := range r.Operations {
+			for _, g := range r.APIGroups {
+				for _, v := range r.APIVersions {
+					for _, res := range r.Resources {
+						keys = append(keys, admissionKey{op: string(op), group: g, version: v, resource: res, scope: scope})
+					}
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// covered reports if everything a key in a matches is matched by a key in b. Keys are not merged, so a key
+// in a that is only matched by several keys in b together is not covered.
+func covered(a, b []admissionKey) bool {
+	for _, ak := range a {
+		found := false
+		for _, bk := range b {
+			if bk.covers(ak) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// covers reports if k matches everything o matches.
+func (k admissionKey) covers(o admissionKey) bool {
+	return wildcard(k.op, o.op) &&
+		wildcard(k.group, o.group) &&
+		wildcard(k.version, o.version) &&
+		wildcard(string(k.scope), string(o.scope)) &&
+		resourceCovers(k.resource, o.resource)
+}
+
+// wildcard reports if k matches everything o matches, where "*" matches anything.
+func wildcard(k, o string) bool {
+	return k == "*" || k == o
+}
+
+// resourceCovers reports if the resource k matches everything the resource o matches. "*" is every
+// resource but no subresources, "pods/*" is every subresource of pods, "*/scale" is the scale subresource
+// of every resource and "*/*" is every resource and subresource.
+func resourceCovers(k, o string) bool {
+	if k == "*/*" {
+		return true
+	}
+	kRes, kSub, kHasSub := strings.Cut(k, "/")
+	oRes, oSub, oHasSub := strings.Cut(o, "/")
+	if kHasSub != oHasSub {
+		return false
+	}
+	return wildcard(kRes, oRes) && wildcard(kSub, oSub)
+}
+
+// subset reports if every key in a is in b.
+func subset(a, b map[string]bool) bool {
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package data
+
+import (
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func webhook(name string, fp admissionregistrationv1.FailurePolicyType, sel *metav1.LabelSelector, ops ...admissionregistrationv1.OperationType) admissionregistrationv1.ValidatingWebhook {
+	return admissionregistrationv1.ValidatingWebhook{
+		Name:              name,
+		FailurePolicy:     &fp,
+		NamespaceSelector: sel,
+		Rules: []admissionregistrationv1.RuleWithOperations{
+			{
+				Operations: ops,
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{""},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"pods"},
+				},
+			},
+		},
+	}
+}
+
+func validatingConfig(hooks ...admissionregistrationv1.ValidatingWebhook) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{Webhooks: hooks}
+}
+
+func TestValidatingWebhookFlags(t *testing.T) {
+	t.Parallel()
+
+	fail, ignore := admissionregistrationv1.Fail, admissionregistrationv1.Ignore
+	create, update := admissionregistrationv1.Create, admissionregistrationv1.Update
+	team := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+
+	base := validatingConfig(webhook("a", fail, nil, create, update))
+
+	tests := []struct {
+		name   string
+		change Change[*admissionregistrationv1.ValidatingWebhookConfiguration]
+		want   ChangeFlag
+	}{
+		{
+			name:   "Add",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{ChangeType: CTAdd, New: base},
+		},
+		{
+			name:   "No change",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{ChangeType: CTUpdate, Old: base, New: base},
+		},
+		{
+			name: "Fail to Ignore",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        base,
+				New:        validatingConfig(webhook("a", ignore, nil, create, update)),
+			},
+			want: CFFailurePolicyPermissive,
+		},
+		{
+			name: "Ignore to Fail",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        validatingConfig(webhook("a", ignore, nil, create, update)),
+				New:        base,
+			},
+		},
+		{
+			name: "Namespace selector narrowed",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        base,
+				New:        validatingConfig(webhook("a", fail, team, create, update)),
+			},
+			want: CFNamespaceSelectorPermissive,
+		},
+		{
+			name: "Namespace selector widened",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        validatingConfig(webhook("a", fail, team, create, update)),
+				New:        base,
+			},
+		},
+		{
+			name: "Operation removed",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        base,
+				New:        validatingConfig(webhook("a", fail, nil, create)),
+			},
+			want: CFRulesPermissive,
+		},
+		{
+			name: "Webhook removed and another made permissive",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{
+				ChangeType: CTUpdate,
+				Old:        validatingConfig(webhook("a", fail, nil, create), webhook("b", fail, nil, create)),
+				New:        validatingConfig(webhook("b", ignore, team, create)),
+			},
+			want: CFRulesPermissive | CFFailurePolicyPermissive | CFNamespaceSelectorPermissive,
+		},
+		{
+			name:   "Delete",
+			change: Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{ChangeType: CTDelete, Old: base},
+			want:   CFRulesPermissive,
+		},
+	}
+
+	for _, test := range tests {
+		got := ValidatingWebhookFlags(test.change)
+		if got != test.want {
+			t.Errorf("TestValidatingWebhookFlags(%s): got %#x, want %#x", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAdmissionRuleWildcards(t *testing.T) {
+	t.Parallel()
+
+	cluster := admissionregistrationv1.ClusterScope
+	hook := func(scope *admissionregistrationv1.ScopeType, op admissionregistrationv1.OperationType, group string, resources ...string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		h := webhook("a", admissionregistrationv1.Fail, nil, op)
+		h.Rules[0].APIGroups = []string{group}
+		h.Rules[0].Resources = resources
+		h.Rules[0].Scope = scope
+		return validatingConfig(h)
+	}
+	create := admissionregistrationv1.Create
+
+	tests := []struct {
+		name     string
+		old, new *admissionregistrationv1.ValidatingWebhookConfiguration
+		want     ChangeFlag
+	}{
+		{name: "Resource to *", old: hook(nil, create, "", "pods"), new: hook(nil, create, "", "*")},
+		{name: "* to resource", old: hook(nil, create, "", "*"), new: hook(nil, create, "", "pods"), want: CFRulesPermissive},
+		{name: "Operation to *", old: hook(nil, create, "", "pods"), new: hook(nil, admissionregistrationv1.OperationAll, "", "pods")},
+		{name: "* to operation", old: hook(nil, admissionregistrationv1.OperationAll, "", "pods"), new: hook(nil, create, "", "pods"), want: CFRulesPermissive},
+		{name: "Group to *", old: hook(nil, create, "apps", "deployments"), new: hook(nil, create, "*", "deployments")},
+		{name: "Scope to *", old: hook(&cluster, create, "", "nodes"), new: hook(nil, create, "", "nodes")},
+		{name: "* to scope", old: hook(nil, create, "", "nodes"), new: hook(&cluster, create, "", "nodes"), want: CFRulesPermissive},
+		{name: "Subresource to resource/*", old: hook(nil, create, "", "pods/exec"), new: hook(nil, create, "", "pods/*")},
+		{name: "Subresource to */subresource", old: hook(nil, create, "", "pods/exec"), new: hook(nil, create, "", "*/exec")},
+		{name: "Anything to */*", old: hook(nil, create, "", "pods", "pods/exec", "*"), new: hook(nil, create, "", "*/*")},
+		{name: "Subresource to *", old: hook(nil, create, "", "pods/exec"), new: hook(nil, create, "", "*"), want: CFRulesPermissive},
+		{name: "resource/* to resource", old: hook(nil, create, "", "pods/*"), new: hook(nil, create, "", "pods"), want: CFRulesPermissive},
+		{name: "*/subresource to resource/subresource", old: hook(nil, create, "", "*/scale"), new: hook(nil, create, "", "deployments/scale"), want: CFRulesPermissive},
+	}
+
+	for _, test := range tests {
+		change := Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{ChangeType: CTUpdate, Old: test.old, New: test.new}
+		if got := ValidatingWebhookFlags(change); got != test.want {
+			t.Errorf("TestAdmissionRuleWildcards(%s): got %#x, want %#x", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidatingAdmissionPolicyFlags(t *testing.T) {
+	t.Parallel()
+
+	ignore := admissionregistrationv1.Ignore
+	rule := admissionregistrationv1.NamedRuleWithOperations{
+		RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"apps"},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"deployments"},
+			},
+		},
+	}
+	wildRule := *rule.DeepCopy()
+	wildRule.Resources = []string{"*"}
+	policy := func(fp *admissionregistrationv1.FailurePolicyType, exclude ...admissionregistrationv1.NamedRuleWithOperations) *admissionregistrationv1.ValidatingAdmissionPolicy {
+		return &admissionregistrationv1.ValidatingAdmissionPolicy{
+			Spec: admissionregistrationv1.ValidatingAdmissionPolicySpec{
+				FailurePolicy: fp,
+				MatchConstraints: &admissionregistrationv1.MatchResources{
+					ResourceRules:        []admissionregistrationv1.NamedRuleWithOperations{rule},
+					ExcludeResourceRules: exclude,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change Change[*admissionregistrationv1.ValidatingAdmissionPolicy]
+		want   ChangeFlag
+	}{
+		{
+			name:   "Default Fail to Ignore",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{ChangeType: CTUpdate, Old: policy(nil), New: policy(&ignore)},
+			want:   CFFailurePolicyPermissive,
+		},
+		{
+			name:   "Exclude rule added",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{ChangeType: CTUpdate, Old: policy(nil), New: policy(nil, rule)},
+			want:   CFRulesPermissive,
+		},
+		{
+			name:   "Exclude rule removed",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{ChangeType: CTUpdate, Old: policy(nil, rule), New: policy(nil)},
+		},
+		{
+			name: "Exclude rule narrowed from *",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{
+				ChangeType: CTUpdate,
+				Old:        policy(nil, wildRule),
+				New:        policy(nil, rule),
+			},
+		},
+		{
+			name: "Exclude rule widened to *",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{
+				ChangeType: CTUpdate,
+				Old:        policy(nil, rule),
+				New:        policy(nil, wildRule),
+			},
+			want: CFRulesPermissive,
+		},
+		{
+			name:   "Delete",
+			change: Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{ChangeType: CTDelete, Old: policy(nil)},
+			want:   CFRulesPermissive,
+		},
+	}
+
+	for _, test := range tests {
+		got := ValidatingAdmissionPolicyFlags(test.change)
+		if got != test.want {
+			t.Errorf("TestValidatingAdmissionPolicyFlags(%s): got %#x, want %#x", test.name, got, test.want)
+		}
+	}
+}
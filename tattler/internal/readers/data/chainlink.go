@@ -0,0 +1,83 @@
+package data
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ChainLink is an entry added to each Batches when a hash chain is kept over them, see the chain package.
+// It records the Batches' place in the chain and the hash of its other entries. Name is the Chain and UID
+// is the Chain and Seq. This implements SourceData.
+type ChainLink struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Chain identifies the chain. A new chain is started each time the pipeline starts.
+	Chain string `json:"chain"`
+	// Seq is the number of the Batches in the chain, starting at 1.
+	Seq uint64 `json:"seq"`
+	// Time is when the link was made.
+	Time time.Time `json:"time"`
+	// Entries is the number of other entries in the Batches that the hash covers.
+	Entries int `json:"entries"`
+	// Prev is the Hash of the previous link, empty for the first.
+	Prev []byte `json:"prev,omitempty"`
+	// Hash is the hash over the link and the Batches' other entries.
+	Hash []byte `json:"hash"`
+	// Signature is set if the link is a checkpoint. It is an ed25519 signature over Hash.
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// NewChainLink creates a new ChainLink for seq in chain. The caller sets the rest of the fields.
+func NewChainLink(chain string, seq uint64, now time.Time) *ChainLink {
+	return &ChainLink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: chain,
+			UID:  types.UID(chain + "." + strconv.FormatUint(seq, 10)),
+		},
+		Chain: chain,
+		Seq:   seq,
+		Time:  now,
+	}
+}
+
+// Object implements SourceData.Object().
+func (c *ChainLink) Object() runtime.Object {
+	return c
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *ChainLink) DeepCopyObject() runtime.Object {
+	if c == nil {
+		return nil
+	}
+	n := *c
+	c.ObjectMeta.DeepCopyInto(&n.ObjectMeta)
+	n.Prev = append([]byte(nil), c.Prev...)
+	n.Hash = append([]byte(nil), c.Hash...)
+	n.Signature = append([]byte(nil), c.Signature...)
+	return &n
+}
+
+// encodeChainLink is the Encoder registered for *ChainLink.
+func encodeChainLink(d SourceData) ([]byte, error) {
+	c, ok := d.(*ChainLink)
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	return json.Marshal(c)
+}
+
+// decodeChainLink is the Decoder registered for *ChainLink.
+func decodeChainLink(b []byte) (SourceData, error) {
+	c := &ChainLink{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
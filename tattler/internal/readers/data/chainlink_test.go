@@ -0,0 +1,39 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestChainLinkEntry(t *testing.T) {
+	t.Parallel()
+
+	link := NewChainLink("chain", 2, time.Unix(0, 1234).UTC())
+	link.Entries = 3
+	link.Prev = []byte{1}
+	link.Hash = []byte{2}
+	link.Signature = []byte{3}
+
+	e := MustNewEntry(link)
+	if e.Type != ETChainLink || e.UID() != "chain.2" {
+		t.Errorf("TestChainLinkEntry: got Type == %v, UID == %q, want %v, %q", e.Type, e.UID(), ETChainLink, "chain.2")
+	}
+	if _, err := e.SyncComplete(); err == nil {
+		t.Errorf("TestChainLinkEntry: got SyncComplete() err == nil, want err != nil")
+	}
+
+	reg, _ := ETChainLink.Registration()
+	b, err := reg.Encode(link)
+	if err != nil {
+		t.Fatalf("TestChainLinkEntry: got err == %s, want err == nil", err)
+	}
+	d, err := reg.Decode(b)
+	if err != nil {
+		t.Fatalf("TestChainLinkEntry: got err == %s, want err == nil", err)
+	}
+	if diff := pretty.Compare(link, d); diff != "" {
+		t.Errorf("TestChainLinkEntry: -want/+got:\n%s", diff)
+	}
+}
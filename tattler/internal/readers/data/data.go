@@ -4,11 +4,23 @@
 package data
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -27,15 +39,21 @@ type SourceData interface {
 	Object() runtime.Object
 }
 
-//go:generate stringer -type=EntryType -linecomment
-
+// EntryType is the type of the entry. ETPersistentVolume is the storage family and holds persistent
+// volumes, persistent volume claims and storage classes. ETSyncComplete is a marker a reader emits once it
+// has sent its initial list. ETChainLink is added to each Batches when a hash chain is kept, and is the
+// largest value so that it orders after the entries it covers. Types defined outside this package get their
+// EntryType from Register().
 type EntryType uint8
 
 const (
 	//
-	ETUnknown          EntryType = 0 // Unknown
-	ETInformer         EntryType = 1 // Informer
-	ETPersistentVolume EntryType = 2 // PersistentVolumes
+	ETUnknown          EntryType = 0   // Unknown
+	ETInformer         EntryType = 1   // Informer
+	ETPersistentVolume EntryType = 2   // PersistentVolumes
+	ETEvent            EntryType = 3   // Events
+	ETSyncComplete     EntryType = 4   // SyncComplete
+	ETChainLink        EntryType = 255 // ChainLink
 )
 
 // Entry is a data entry.
@@ -44,23 +62,36 @@ type Entry struct {
 	// data holds the data.
 	data SourceData
 
+	// Observed is when the reader observed the change. If the reader does not set it, the Runner sets it
+	// when it receives the entry.
+	Observed time.Time
+	// Reader is the name of the reader that output the entry. This is set by the Runner.
+	Reader string
+	// Cluster identifies the cluster the entry came from. This is set by the Runner.
+	Cluster string
+	// Seq is the sequence number of the entry from its reader, starting at 1. This is set by the Runner
+	// and increases by one for each entry the reader outputs, so gaps after batching are entries that were
+	// replaced by a later entry for the same object.
+	Seq uint64
+
 	// Type is the type of the entry.
 	Type EntryType
+	// Origin is what caused the reader to emit the entry. Readers that do not track this leave it ORUnknown.
+	Origin Origin
 }
 
-// NewEntry creates a new Entry. ObjectMeta is implemented by Informer.
+// NewEntry creates a new Entry. The type of data must have been registered with Register(), which the
+// types in this package are.
 func NewEntry(data SourceData) (Entry, error) {
 	if data == nil {
 		return Entry{}, ErrInvalidType
 	}
 
-	switch data.(type) {
-	case Informer:
-		return Entry{data: data, Type: ETInformer}, nil
-	case PersistentVolume:
-		return Entry{data: data, Type: ETPersistentVolume}, nil
+	et, ok := registered.entryType(data)
+	if !ok {
+		return Entry{}, ErrInvalidType
 	}
-	return Entry{}, ErrInvalidType
+	return Entry{data: data, Type: et}, nil
 }
 
 // MustNewEntry creates a new Entry. It panics if an error occurs.
@@ -80,6 +111,12 @@ func (e Entry) UID() types.UID {
 	return e.data.GetUID()
 }
 
+// Data returns the SourceData held by the Entry. Use this to get types registered with Register(), the
+// types in this package have their own accessors.
+func (e Entry) Data() SourceData {
+	return e.data
+}
+
 // Object returns the data as a runtime.Object. This is always for the latest change.
 func (e Entry) Object() runtime.Object {
 	return e.data.Object()
@@ -115,6 +152,46 @@ func (e Entry) PersistentVolume() (PersistentVolume, error) {
 	return v, nil
 }
 
+// Event returns the entry data as an Event. An error is returned if the type is not Event.
+func (e Entry) Event() (Event, error) {
+	if e.Type != ETEvent {
+		return Event{}, ErrInvalidType
+	}
+	if e.data == nil {
+		return Event{}, ErrInvalidType
+	}
+	v, ok := e.data.(Event)
+	if !ok {
+		return Event{}, ErrInvalidType
+	}
+	return v, nil
+}
+
+// SyncComplete returns the entry data as a SyncComplete marker. An error is returned if the type is not
+// SyncComplete.
+func (e Entry) SyncComplete() (*SyncComplete, error) {
+	if e.Type != ETSyncComplete {
+		return nil, ErrInvalidType
+	}
+	v, ok := e.data.(*SyncComplete)
+	if !ok || v == nil {
+		return nil, ErrInvalidType
+	}
+	return v, nil
+}
+
+// ChainLink returns the entry data as a ChainLink. An error is returned if the type is not ChainLink.
+func (e Entry) ChainLink() (*ChainLink, error) {
+	if e.Type != ETChainLink {
+		return nil, ErrInvalidType
+	}
+	v, ok := e.data.(*ChainLink)
+	if !ok || v == nil {
+		return nil, ErrInvalidType
+	}
+	return v, nil
+}
+
 //go:generate stringer -type=ObjectType -linecomment
 
 // ObjectType is the type of the object held in a type.
@@ -131,6 +208,64 @@ const (
 	OTNamespace ObjectType = 3 // Namespace
 	// OTPersistentVolume indicates the data is a persistent volume.
 	OTPersistentVolume ObjectType = 4 // PersistentVolume
+	// OTDeployment indicates the data is an apps/v1 deployment.
+	OTDeployment ObjectType = 5 // Deployment
+	// OTReplicaSet indicates the data is an apps/v1 replica set.
+	OTReplicaSet ObjectType = 6 // ReplicaSet
+	// OTStatefulSet indicates the data is an apps/v1 stateful set.
+	OTStatefulSet ObjectType = 7 // StatefulSet
+	// OTDaemonSet indicates the data is an apps/v1 daemon set.
+	OTDaemonSet ObjectType = 8 // DaemonSet
+	// OTJob indicates the data is a batch/v1 job.
+	OTJob ObjectType = 9 // Job
+	// OTCronJob indicates the data is a batch/v1 cron job.
+	OTCronJob ObjectType = 10 // CronJob
+	// OTService indicates the data is a service.
+	OTService ObjectType = 11 // Service
+	// OTEndpointSlice indicates the data is a discovery.k8s.io/v1 endpoint slice.
+	OTEndpointSlice ObjectType = 12 // EndpointSlice
+	// OTEvent indicates the data is a core/v1 event.
+	OTEvent ObjectType = 13 // Event
+	// OTEventsV1Event indicates the data is an events.k8s.io/v1 event.
+	OTEventsV1Event ObjectType = 14 // EventsV1Event
+	// OTRole indicates the data is a rbac.authorization.k8s.io/v1 role.
+	OTRole ObjectType = 15 // Role
+	// OTClusterRole indicates the data is a rbac.authorization.k8s.io/v1 cluster role.
+	OTClusterRole ObjectType = 16 // ClusterRole
+	// OTRoleBinding indicates the data is a rbac.authorization.k8s.io/v1 role binding.
+	OTRoleBinding ObjectType = 17 // RoleBinding
+	// OTClusterRoleBinding indicates the data is a rbac.authorization.k8s.io/v1 cluster role binding.
+	OTClusterRoleBinding ObjectType = 18 // ClusterRoleBinding
+	// OTSecret indicates the data is a SecretMeta.
+	OTSecret ObjectType = 19 // Secret
+	// OTConfigMap indicates the data is a ConfigMapMeta.
+	OTConfigMap ObjectType = 20 // ConfigMap
+	// OTPersistentVolumeClaim indicates the data is a persistent volume claim.
+	OTPersistentVolumeClaim ObjectType = 21 // PersistentVolumeClaim
+	// OTStorageClass indicates the data is a storage.k8s.io/v1 storage class.
+	OTStorageClass ObjectType = 22 // StorageClass
+	// OTNetworkPolicy indicates the data is a networking.k8s.io/v1 network policy.
+	OTNetworkPolicy ObjectType = 23 // NetworkPolicy
+	// OTIngress indicates the data is a networking.k8s.io/v1 ingress.
+	OTIngress ObjectType = 24 // Ingress
+	// OTIngressClass indicates the data is a networking.k8s.io/v1 ingress class.
+	OTIngressClass ObjectType = 25 // IngressClass
+	// OTGateway indicates the data is a gateway.networking.k8s.io gateway. This is an *unstructured.Unstructured,
+	// as the Gateway API is a CRD.
+	OTGateway ObjectType = 26 // Gateway
+	// OTHTTPRoute indicates the data is a gateway.networking.k8s.io http route. This is an *unstructured.Unstructured,
+	// as the Gateway API is a CRD.
+	OTHTTPRoute ObjectType = 27 // HTTPRoute
+	// OTValidatingWebhookConfiguration indicates the data is an admissionregistration.k8s.io/v1 validating webhook configuration.
+	OTValidatingWebhookConfiguration ObjectType = 28 // ValidatingWebhookConfiguration
+	// OTMutatingWebhookConfiguration indicates the data is an admissionregistration.k8s.io/v1 mutating webhook configuration.
+	OTMutatingWebhookConfiguration ObjectType = 29 // MutatingWebhookConfiguration
+	// OTValidatingAdmissionPolicy indicates the data is an admissionregistration.k8s.io/v1 validating admission policy.
+	OTValidatingAdmissionPolicy ObjectType = 30 // ValidatingAdmissionPolicy
+	// OTServiceAccount indicates the data is a service account.
+	OTServiceAccount ObjectType = 31 // ServiceAccount
+	// OTNodeLiveness indicates the data is a NodeLiveness derived from a node lease.
+	OTNodeLiveness ObjectType = 32 // NodeLiveness
 )
 
 // Informer is data from an APIServer informer. This implementes SourceData.
@@ -145,10 +280,25 @@ type Informer struct {
 // NewInformer creates a new Informer. Data must be a Change type.
 func NewInformer[T K8Object](change Change[T]) (Informer, error) {
 	switch change.ObjectType {
-	case OTNode, OTPod, OTNamespace:
+	case OTNode, OTPod, OTNamespace,
+		OTDeployment, OTReplicaSet, OTStatefulSet, OTDaemonSet,
+		OTJob, OTCronJob,
+		OTService, OTEndpointSlice,
+		OTRole, OTClusterRole, OTRoleBinding, OTClusterRoleBinding,
+		OTSecret, OTConfigMap,
+		OTNetworkPolicy, OTIngress, OTIngressClass,
+		OTGateway, OTHTTPRoute,
+		OTValidatingWebhookConfiguration, OTMutatingWebhookConfiguration, OTValidatingAdmissionPolicy,
+		OTServiceAccount, OTNodeLiveness:
 	default:
 		return Informer{}, ErrInvalidType
 	}
+	// Secrets and ConfigMaps must never carry their values, they must be converted to SecretMeta
+	// and ConfigMapMeta first.
+	switch any(change).(type) {
+	case Change[*corev1.Secret], Change[*corev1.ConfigMap]:
+		return Informer{}, ErrInvalidType
+	}
 	if err := change.Validate(); err != nil {
 		return Informer{}, err
 	}
@@ -157,7 +307,7 @@ func NewInformer[T K8Object](change Change[T]) (Informer, error) {
 		return Informer{}, err
 	}
 
-	return Informer{data: change, uid: uid, Type: change.ObjectType}, nil
+	return Informer{data: change.withDiffCache(), uid: uid, Type: change.ObjectType}, nil
 }
 
 // MustNewInformer creates a new Informer. It panics if an error occurs.
@@ -193,10 +343,130 @@ func (i Informer) Object() runtime.Object {
 			return v.Old
 		}
 		return v.New
+	case Change[*appsv1.Deployment]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*appsv1.ReplicaSet]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*appsv1.StatefulSet]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*appsv1.DaemonSet]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*batchv1.Job]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*batchv1.CronJob]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*corev1.Service]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*discoveryv1.EndpointSlice]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*rbacv1.Role]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*rbacv1.ClusterRole]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*rbacv1.RoleBinding]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*rbacv1.ClusterRoleBinding]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*SecretMeta]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*ConfigMapMeta]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*networkingv1.NetworkPolicy]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*networkingv1.Ingress]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*networkingv1.IngressClass]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*NodeLiveness]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*unstructured.Unstructured]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*admissionregistrationv1.ValidatingWebhookConfiguration]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*admissionregistrationv1.MutatingWebhookConfiguration]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*admissionregistrationv1.ValidatingAdmissionPolicy]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*corev1.ServiceAccount]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
 	}
 	return nil
 }
 
+// Change returns the change whatever its ObjectType. Use the typed accessors to get the objects as their types.
+func (i Informer) Change() (AnyChange, error) {
+	return anyChange(i.data)
+}
+
 // Node returns the data for a Node type change. An error is returned if the type is not Node.
 func (i Informer) Node() (Change[*corev1.Node], error) {
 	if i.data == nil {
@@ -238,7 +508,342 @@ func (i Informer) Namespace() (Change[*corev1.Namespace], error) {
 	return v, nil
 }
 
+// Deployment returns the data as a deployment type change. An error is returned if the type is not Deployment.
+func (i Informer) Deployment() (Change[*appsv1.Deployment], error) {
+	if i.data == nil {
+		return Change[*appsv1.Deployment]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*appsv1.Deployment])
+	if !ok {
+		return Change[*appsv1.Deployment]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ReplicaSet returns the data as a replica set type change. An error is returned if the type is not ReplicaSet.
+func (i Informer) ReplicaSet() (Change[*appsv1.ReplicaSet], error) {
+	if i.data == nil {
+		return Change[*appsv1.ReplicaSet]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*appsv1.ReplicaSet])
+	if !ok {
+		return Change[*appsv1.ReplicaSet]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// StatefulSet returns the data as a stateful set type change. An error is returned if the type is not StatefulSet.
+func (i Informer) StatefulSet() (Change[*appsv1.StatefulSet], error) {
+	if i.data == nil {
+		return Change[*appsv1.StatefulSet]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*appsv1.StatefulSet])
+	if !ok {
+		return Change[*appsv1.StatefulSet]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// DaemonSet returns the data as a daemon set type change. An error is returned if the type is not DaemonSet.
+func (i Informer) DaemonSet() (Change[*appsv1.DaemonSet], error) {
+	if i.data == nil {
+		return Change[*appsv1.DaemonSet]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*appsv1.DaemonSet])
+	if !ok {
+		return Change[*appsv1.DaemonSet]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Job returns the data as a batch/v1 job type change. An error is returned if the type is not Job.
+func (i Informer) Job() (Change[*batchv1.Job], error) {
+	if i.data == nil {
+		return Change[*batchv1.Job]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*batchv1.Job])
+	if !ok {
+		return Change[*batchv1.Job]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// CronJob returns the data as a batch/v1 cron job type change. An error is returned if the type is not CronJob.
+func (i Informer) CronJob() (Change[*batchv1.CronJob], error) {
+	if i.data == nil {
+		return Change[*batchv1.CronJob]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*batchv1.CronJob])
+	if !ok {
+		return Change[*batchv1.CronJob]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Service returns the data as a service type change. An error is returned if the type is not Service.
+func (i Informer) Service() (Change[*corev1.Service], error) {
+	if i.data == nil {
+		return Change[*corev1.Service]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*corev1.Service])
+	if !ok {
+		return Change[*corev1.Service]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// EndpointSlice returns the data as a discovery.k8s.io/v1 endpoint slice type change. An error is returned if the type is not EndpointSlice.
+func (i Informer) EndpointSlice() (Change[*discoveryv1.EndpointSlice], error) {
+	if i.data == nil {
+		return Change[*discoveryv1.EndpointSlice]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*discoveryv1.EndpointSlice])
+	if !ok {
+		return Change[*discoveryv1.EndpointSlice]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Role returns the data as a rbac.authorization.k8s.io/v1 role type change. An error is returned if the type is not Role.
+func (i Informer) Role() (Change[*rbacv1.Role], error) {
+	if i.data == nil {
+		return Change[*rbacv1.Role]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*rbacv1.Role])
+	if !ok {
+		return Change[*rbacv1.Role]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ClusterRole returns the data as a rbac.authorization.k8s.io/v1 cluster role type change. An error is returned if the type is not ClusterRole.
+func (i Informer) ClusterRole() (Change[*rbacv1.ClusterRole], error) {
+	if i.data == nil {
+		return Change[*rbacv1.ClusterRole]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*rbacv1.ClusterRole])
+	if !ok {
+		return Change[*rbacv1.ClusterRole]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// RoleBinding returns the data as a rbac.authorization.k8s.io/v1 role binding type change. An error is returned if the type is not RoleBinding.
+func (i Informer) RoleBinding() (Change[*rbacv1.RoleBinding], error) {
+	if i.data == nil {
+		return Change[*rbacv1.RoleBinding]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*rbacv1.RoleBinding])
+	if !ok {
+		return Change[*rbacv1.RoleBinding]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ClusterRoleBinding returns the data as a rbac.authorization.k8s.io/v1 cluster role binding type change. An error is returned if the type is not ClusterRoleBinding.
+func (i Informer) ClusterRoleBinding() (Change[*rbacv1.ClusterRoleBinding], error) {
+	if i.data == nil {
+		return Change[*rbacv1.ClusterRoleBinding]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*rbacv1.ClusterRoleBinding])
+	if !ok {
+		return Change[*rbacv1.ClusterRoleBinding]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Secret returns the data as a secret type change. An error is returned if the type is not Secret.
+func (i Informer) Secret() (Change[*SecretMeta], error) {
+	if i.data == nil {
+		return Change[*SecretMeta]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*SecretMeta])
+	if !ok {
+		return Change[*SecretMeta]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ConfigMap returns the data as a config map type change. An error is returned if the type is not ConfigMap.
+func (i Informer) ConfigMap() (Change[*ConfigMapMeta], error) {
+	if i.data == nil {
+		return Change[*ConfigMapMeta]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*ConfigMapMeta])
+	if !ok {
+		return Change[*ConfigMapMeta]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// NetworkPolicy returns the data as a networking.k8s.io/v1 network policy type change. An error is returned if the type is not NetworkPolicy.
+func (i Informer) NetworkPolicy() (Change[*networkingv1.NetworkPolicy], error) {
+	if i.data == nil {
+		return Change[*networkingv1.NetworkPolicy]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*networkingv1.NetworkPolicy])
+	if !ok {
+		return Change[*networkingv1.NetworkPolicy]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Ingress returns the data as a networking.k8s.io/v1 ingress type change. An error is returned if the type is not Ingress.
+func (i Informer) Ingress() (Change[*networkingv1.Ingress], error) {
+	if i.data == nil {
+		return Change[*networkingv1.Ingress]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*networkingv1.Ingress])
+	if !ok {
+		return Change[*networkingv1.Ingress]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// IngressClass returns the data as a networking.k8s.io/v1 ingress class type change. An error is returned if the type is not IngressClass.
+func (i Informer) IngressClass() (Change[*networkingv1.IngressClass], error) {
+	if i.data == nil {
+		return Change[*networkingv1.IngressClass]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*networkingv1.IngressClass])
+	if !ok {
+		return Change[*networkingv1.IngressClass]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// NodeLiveness returns the data as a node liveness type change. An error is returned if the type is not
+// NodeLiveness.
+func (i Informer) NodeLiveness() (Change[*NodeLiveness], error) {
+	if i.data == nil {
+		return Change[*NodeLiveness]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*NodeLiveness])
+	if !ok {
+		return Change[*NodeLiveness]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// Gateway returns the data as a Gateway API gateway type change. An error is returned if the type is not Gateway.
+func (i Informer) Gateway() (Change[*unstructured.Unstructured], error) {
+	return i.unstructured(OTGateway)
+}
+
+// HTTPRoute returns the data as a Gateway API http route type change. An error is returned if the type is not
+// HTTPRoute.
+func (i Informer) HTTPRoute() (Change[*unstructured.Unstructured], error) {
+	return i.unstructured(OTHTTPRoute)
+}
+
+// unstructured returns the data for the CRD backed type ot.
+func (i Informer) unstructured(ot ObjectType) (Change[*unstructured.Unstructured], error) {
+	if i.data == nil || i.Type != ot {
+		return Change[*unstructured.Unstructured]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*unstructured.Unstructured])
+	if !ok {
+		return Change[*unstructured.Unstructured]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ValidatingWebhookConfiguration returns the data as an admissionregistration.k8s.io/v1 validating webhook configuration type change. An error is returned if the type is not ValidatingWebhookConfiguration.
+func (i Informer) ValidatingWebhookConfiguration() (Change[*admissionregistrationv1.ValidatingWebhookConfiguration], error) {
+	if i.data == nil {
+		return Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*admissionregistrationv1.ValidatingWebhookConfiguration])
+	if !ok {
+		return Change[*admissionregistrationv1.ValidatingWebhookConfiguration]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// MutatingWebhookConfiguration returns the data as an admissionregistration.k8s.io/v1 mutating webhook configuration type change. An error is returned if the type is not MutatingWebhookConfiguration.
+func (i Informer) MutatingWebhookConfiguration() (Change[*admissionregistrationv1.MutatingWebhookConfiguration], error) {
+	if i.data == nil {
+		return Change[*admissionregistrationv1.MutatingWebhookConfiguration]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*admissionregistrationv1.MutatingWebhookConfiguration])
+	if !ok {
+		return Change[*admissionregistrationv1.MutatingWebhookConfiguration]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ValidatingAdmissionPolicy returns the data as an admissionregistration.k8s.io/v1 validating admission policy type change. An error is returned if the type is not ValidatingAdmissionPolicy.
+func (i Informer) ValidatingAdmissionPolicy() (Change[*admissionregistrationv1.ValidatingAdmissionPolicy], error) {
+	if i.data == nil {
+		return Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*admissionregistrationv1.ValidatingAdmissionPolicy])
+	if !ok {
+		return Change[*admissionregistrationv1.ValidatingAdmissionPolicy]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
+// ServiceAccount returns the data as a service account type change. An error is returned if the type is not ServiceAccount.
+func (i Informer) ServiceAccount() (Change[*corev1.ServiceAccount], error) {
+	if i.data == nil {
+		return Change[*corev1.ServiceAccount]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*corev1.ServiceAccount])
+	if !ok {
+		return Change[*corev1.ServiceAccount]{}, ErrInvalidType
+	}
+
+	return v, nil
+}
+
 // PersistentVolume is data from an custom APIServer informer that gets PersistentVolume information.
+// This includes the rest of the storage family, PersistentVolumeClaims and StorageClasses.
 // This implementes SourceData.
 // Note: This data type is field aligned for better performance.
 type PersistentVolume struct {
@@ -251,7 +856,7 @@ type PersistentVolume struct {
 // NewPersistentVolume creates a new PersistentVolume custom Informer.
 func NewPersistentVolume[T K8Object](change Change[T]) (PersistentVolume, error) {
 	switch change.ObjectType {
-	case OTPersistentVolume:
+	case OTPersistentVolume, OTPersistentVolumeClaim, OTStorageClass:
 	default:
 		return PersistentVolume{}, ErrInvalidType
 	}
@@ -263,7 +868,7 @@ func NewPersistentVolume[T K8Object](change Change[T]) (PersistentVolume, error)
 		return PersistentVolume{}, err
 	}
 
-	return PersistentVolume{data: change, uid: uid, Type: change.ObjectType}, nil
+	return PersistentVolume{data: change.withDiffCache(), uid: uid, Type: change.ObjectType}, nil
 }
 
 // MustNewPersistentVolume creates a new PersistentVolume Informer. It panics if an error occurs.
@@ -289,11 +894,26 @@ func (i PersistentVolume) Object() runtime.Object {
 			return v.Old
 		}
 		return v.New
+	case Change[*corev1.PersistentVolumeClaim]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
+	case Change[*storagev1.StorageClass]:
+		if v.ChangeType == CTDelete {
+			return v.Old
+		}
+		return v.New
 	}
 	return nil
 }
 
-// Node returns the data for a Node type change. An error is returned if the type is not Node.
+// Change returns the change whatever its ObjectType. Use the typed accessors to get the objects as their types.
+func (i PersistentVolume) Change() (AnyChange, error) {
+	return anyChange(i.data)
+}
+
+// PersistentVolume returns the data for a PersistentVolume type change. An error is returned if the type is not PersistentVolume.
 func (i PersistentVolume) PersistentVolume() (Change[*corev1.PersistentVolume], error) {
 	if i.data == nil {
 		return Change[*corev1.PersistentVolume]{}, ErrInvalidType
@@ -306,20 +926,211 @@ func (i PersistentVolume) PersistentVolume() (Change[*corev1.PersistentVolume],
 	return v, nil
 }
 
+// PersistentVolumeClaim returns the data for a PersistentVolumeClaim type change. An error is returned if the type
+// is not PersistentVolumeClaim.
+func (i PersistentVolume) PersistentVolumeClaim() (Change[*corev1.PersistentVolumeClaim], error) {
+	if i.data == nil {
+		return Change[*corev1.PersistentVolumeClaim]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*corev1.PersistentVolumeClaim])
+	if !ok {
+		return Change[*corev1.PersistentVolumeClaim]{}, ErrInvalidType
+	}
+	return v, nil
+}
+
+// StorageClass returns the data for a StorageClass type change. An error is returned if the type is not StorageClass.
+func (i PersistentVolume) StorageClass() (Change[*storagev1.StorageClass], error) {
+	if i.data == nil {
+		return Change[*storagev1.StorageClass]{}, ErrInvalidType
+	}
+
+	v, ok := i.data.(Change[*storagev1.StorageClass])
+	if !ok {
+		return Change[*storagev1.StorageClass]{}, ErrInvalidType
+	}
+	return v, nil
+}
+
+// Event is data from an APIServer events informer. Repeated events, those with the same involved object,
+// reason and message, share a UID so that they are collapsed during batching. This implementes SourceData.
+// Note: This data type is field aligned for better performance.
+type Event struct {
+	data any
+	uid  types.UID
+
+	// FirstTimestamp is the first time an event with this UID was seen in the deduplication window.
+	FirstTimestamp time.Time
+	// LastTimestamp is the last time an event with this UID was seen in the deduplication window.
+	LastTimestamp time.Time
+	// Count is the number of times an event with this UID was seen in the deduplication window.
+	Count int32
+	// Type is the type of the data.
+	Type ObjectType
+}
+
+// NewEvent creates a new Event. Count, FirstTimestamp and LastTimestamp are taken from the event
+// and may be overwritten by the reader when it collapses repeated events.
+func NewEvent[T K8Object](change Change[T]) (Event, error) {
+	switch change.ObjectType {
+	case OTEvent, OTEventsV1Event:
+	default:
+		return Event{}, ErrInvalidType
+	}
+	if err := change.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	ev := Event{data: change.withDiffCache(), Type: change.ObjectType}
+	switch v := any(change.latest()).(type) {
+	case *corev1.Event:
+		ev.uid = eventKey(v.InvolvedObject, v.Reason, v.Message)
+		ev.Count = v.Count
+		ev.FirstTimestamp = firstTime(v.FirstTimestamp.Time, v.EventTime.Time)
+		ev.LastTimestamp = firstTime(v.LastTimestamp.Time, v.EventTime.Time, v.FirstTimestamp.Time)
+	case *eventsv1.Event:
+		ev.uid = eventKey(v.Regarding, v.Reason, v.Note)
+		ev.Count = v.DeprecatedCount
+		ev.FirstTimestamp = firstTime(v.DeprecatedFirstTimestamp.Time, v.EventTime.Time)
+		ev.LastTimestamp = firstTime(v.DeprecatedLastTimestamp.Time, v.EventTime.Time)
+		if v.Series != nil {
+			ev.Count = v.Series.Count
+			ev.LastTimestamp = firstTime(v.Series.LastObservedTime.Time, ev.LastTimestamp)
+		}
+	default:
+		return Event{}, ErrInvalidType
+	}
+	if ev.Count < 1 {
+		ev.Count = 1
+	}
+
+	return ev, nil
+}
+
+// MustNewEvent creates a new Event. It panics if an error occurs.
+func MustNewEvent[T K8Object](change Change[T]) Event {
+	e, err := NewEvent(change)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// GetUID returns the deduplication key of the event. This is not the UID of the underlying object, as
+// repeated events are different objects.
+func (e Event) GetUID() types.UID {
+	return e.uid
+}
+
+// Object returns the data as a runtime.Object. This is always for latest change, in the case that this
+// is an update. This returns nil if the object is of a type we don't understand.
+func (e Event) Object() runtime.Object {
+	switch v := e.data.(type) {
+	case Change[*corev1.Event]:
+		return v.latest()
+	case Change[*eventsv1.Event]:
+		return v.latest()
+	}
+	return nil
+}
+
+// Change returns the change whatever its ObjectType. Use the typed accessors to get the objects as their types.
+func (e Event) Change() (AnyChange, error) {
+	return anyChange(e.data)
+}
+
+// Event returns the data for a core/v1 Event type change. An error is returned if the type is not Event.
+func (e Event) Event() (Change[*corev1.Event], error) {
+	if e.data == nil {
+		return Change[*corev1.Event]{}, ErrInvalidType
+	}
+
+	v, ok := e.data.(Change[*corev1.Event])
+	if !ok {
+		return Change[*corev1.Event]{}, ErrInvalidType
+	}
+	return v, nil
+}
+
+// EventsV1Event returns the data for an events.k8s.io/v1 Event type change. An error is returned if the type
+// is not EventsV1Event.
+func (e Event) EventsV1Event() (Change[*eventsv1.Event], error) {
+	if e.data == nil {
+		return Change[*eventsv1.Event]{}, ErrInvalidType
+	}
+
+	v, ok := e.data.(Change[*eventsv1.Event])
+	if !ok {
+		return Change[*eventsv1.Event]{}, ErrInvalidType
+	}
+	return v, nil
+}
+
+// eventKey returns the deduplication key for an event. core/v1 and events.k8s.io/v1 events for the same
+// occurrence produce the same key.
+func eventKey(ref corev1.ObjectReference, reason, message string) types.UID {
+	h := sha256.New()
+	for _, s := range []string{string(ref.UID), ref.Kind, ref.Namespace, ref.Name, reason, message} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return types.UID(hex.EncodeToString(h.Sum(nil)[:16]))
+}
+
+// firstTime returns the first non-zero time.
+func firstTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+//go:generate stringer -type=ChangeType -linecomment
+
 // ChangeType is the type of change.
 type ChangeType uint8
 
 const (
 	// CTUnknown indicates a bug in the code.
-	CTUnknown ChangeType = 0
+	CTUnknown ChangeType = 0 // Unknown
 	// CTAdd indicates the data was added.
-	CTAdd ChangeType = 1
+	CTAdd ChangeType = 1 // Add
 	// CTUpdate indicates the data was updated.
-	CTUpdate ChangeType = 2
+	CTUpdate ChangeType = 2 // Update
 	// CTDelete indicates the data was deleted.
-	CTDelete ChangeType = 3
+	CTDelete ChangeType = 3 // Delete
+)
+
+// ChangeFlag is an annotation a reader adds to a Change. Uses as a bitwise flag.
+type ChangeFlag uint32
+
+const (
+	// CFFailurePolicyPermissive indicates an admission webhook or policy now ignores failures it
+	// previously rejected on.
+	CFFailurePolicyPermissive ChangeFlag = 0x1
+	// CFNamespaceSelectorPermissive indicates an admission webhook or policy namespace selector may now
+	// match fewer namespaces.
+	CFNamespaceSelectorPermissive ChangeFlag = 0x2
+	// CFRulesPermissive indicates an admission webhook or policy no longer matches some operations or
+	// resources it previously did.
+	CFRulesPermissive ChangeFlag = 0x4
+	// CFAutomountTokenEnabled indicates a ServiceAccount now automounts its token into pods.
+	CFAutomountTokenEnabled ChangeFlag = 0x8
+	// CFLegacyServiceAccountToken indicates a Secret is a legacy, long lived, service account token.
+	CFLegacyServiceAccountToken ChangeFlag = 0x10
+	// CFStaleFinalState indicates a delete was recovered from a tombstone after the watch missed the
+	// delete event. Old is the last state the reader saw, which may be older than the state when deleted.
+	CFStaleFinalState ChangeFlag = 0x20
 )
 
+// Has reports if all flags in flag are set.
+func (f ChangeFlag) Has(flag ChangeFlag) bool {
+	return f&flag == flag
+}
+
 // K8Object is implemented by all Kubernetes objects.
 type K8Object interface {
 	runtime.Object
@@ -335,10 +1146,14 @@ type Change[T K8Object] struct {
 	Old T
 	// New is the new data. This is only valid if Type is Add or Update.
 	New T
+	// diff caches the result of Diff(). It is set when an update is put in an Entry.
+	diff *diffCache
 	// ChangeType is the type of the change.
 	ChangeType ChangeType
 	// ObjectType is the type of the object.
 	ObjectType ObjectType
+	// Flags are annotations about the change added by the reader.
+	Flags ChangeFlag
 }
 
 // NewChange creates a new Change. This function validates the change.
@@ -372,12 +1187,96 @@ func NewChange[T K8Object](newObj, oldObj T, ct ChangeType) (Change[T], error) {
 		ot = OTPod
 	case *corev1.Namespace:
 		ot = OTNamespace
+	case *appsv1.Deployment:
+		ot = OTDeployment
+	case *appsv1.ReplicaSet:
+		ot = OTReplicaSet
+	case *appsv1.StatefulSet:
+		ot = OTStatefulSet
+	case *appsv1.DaemonSet:
+		ot = OTDaemonSet
+	case *batchv1.Job:
+		ot = OTJob
+	case *batchv1.CronJob:
+		ot = OTCronJob
+	case *corev1.Service:
+		ot = OTService
+	case *discoveryv1.EndpointSlice:
+		ot = OTEndpointSlice
+	case *corev1.Event:
+		ot = OTEvent
+	case *eventsv1.Event:
+		ot = OTEventsV1Event
+	case *rbacv1.Role:
+		ot = OTRole
+	case *rbacv1.ClusterRole:
+		ot = OTClusterRole
+	case *rbacv1.RoleBinding:
+		ot = OTRoleBinding
+	case *rbacv1.ClusterRoleBinding:
+		ot = OTClusterRoleBinding
+	case *SecretMeta:
+		ot = OTSecret
+	case *ConfigMapMeta:
+		ot = OTConfigMap
+	case *corev1.PersistentVolume:
+		ot = OTPersistentVolume
+	case *corev1.PersistentVolumeClaim:
+		ot = OTPersistentVolumeClaim
+	case *storagev1.StorageClass:
+		ot = OTStorageClass
+	case *networkingv1.NetworkPolicy:
+		ot = OTNetworkPolicy
+	case *networkingv1.Ingress:
+		ot = OTIngress
+	case *networkingv1.IngressClass:
+		ot = OTIngressClass
+	case *NodeLiveness:
+		ot = OTNodeLiveness
+	case *unstructured.Unstructured:
+		var obj *unstructured.Unstructured
+		if newIsZero {
+			obj = any(oldObj).(*unstructured.Unstructured)
+		} else {
+			obj = any(newObj).(*unstructured.Unstructured)
+		}
+		ot = UnstructuredObjectType(obj)
+		if ot == OTUnknown {
+			return Change[T]{}, fmt.Errorf("unknown object type")
+		}
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		ot = OTValidatingWebhookConfiguration
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		ot = OTMutatingWebhookConfiguration
+	case *admissionregistrationv1.ValidatingAdmissionPolicy:
+		ot = OTValidatingAdmissionPolicy
+	case *corev1.ServiceAccount:
+		ot = OTServiceAccount
 	default:
 		return Change[T]{}, fmt.Errorf("unknown object type")
 	}
 	return Change[T]{Old: oldObj, New: newObj, ChangeType: ct, ObjectType: ot}, nil
 }
 
+// GatewayGroup is the API group of the Gateway API.
+const GatewayGroup = "gateway.networking.k8s.io"
+
+// UnstructuredObjectType returns the ObjectType for a CRD backed object we support. OTUnknown is returned
+// for anything else.
+func UnstructuredObjectType(obj *unstructured.Unstructured) ObjectType {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != GatewayGroup {
+		return OTUnknown
+	}
+	switch gvk.Kind {
+	case "Gateway":
+		return OTGateway
+	case "HTTPRoute":
+		return OTHTTPRoute
+	}
+	return OTUnknown
+}
+
 // MustNewChange creates a new Change. It panics if an error occurs.
 func MustNewChange[T K8Object](newObj, oldObj T, ct ChangeType) Change[T] {
 	c, err := NewChange(newObj, oldObj, ct)
@@ -407,6 +1306,55 @@ func (c Change[T]) Validate() error {
 	return nil
 }
 
+// AnyChange is a Change with its objects as runtime.Object. It is for code that handles every ObjectType the
+// same way, such as encoders. Old and New are nil when not set.
+type AnyChange struct {
+	// Old is the old data. This is only set if ChangeType is CTUpdate or CTDelete.
+	Old runtime.Object
+	// New is the new data. This is only set if ChangeType is CTAdd or CTUpdate.
+	New runtime.Object
+	// ChangeType is the type of the change.
+	ChangeType ChangeType
+	// ObjectType is the type of the object.
+	ObjectType ObjectType
+	// Flags are annotations about the change added by the reader.
+	Flags ChangeFlag
+}
+
+// Any returns c as an AnyChange.
+func (c Change[T]) Any() AnyChange {
+	ac := AnyChange{ChangeType: c.ChangeType, ObjectType: c.ObjectType, Flags: c.Flags}
+	if !reflect.ValueOf(c.Old).IsZero() {
+		ac.Old = c.Old
+	}
+	if !reflect.ValueOf(c.New).IsZero() {
+		ac.New = c.New
+	}
+	return ac
+}
+
+// anyChanger is implemented by every Change[T].
+type anyChanger interface {
+	Any() AnyChange
+}
+
+// anyChange returns d, which must be a Change[T], as an AnyChange.
+func anyChange(d any) (AnyChange, error) {
+	c, ok := d.(anyChanger)
+	if !ok {
+		return AnyChange{}, ErrInvalidType
+	}
+	return c.Any(), nil
+}
+
+// latest returns the latest version of the object. This is Old for a delete and New otherwise.
+func (c Change[T]) latest() T {
+	if c.ChangeType == CTDelete {
+		return c.Old
+	}
+	return c.New
+}
+
 // UID returns the UID of the underlying object being changed.
 func (c Change[T]) UID() (types.UID, error) {
 	switch c.ChangeType {
@@ -0,0 +1,281 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotUpdate is returned when a diff is requested for a Change that is not a CTUpdate.
+var ErrNotUpdate = errors.New("diff is only available for an update")
+
+// DefaultDiffIgnore are paths that change without the object being changed in any meaningful way. Pass
+// these to Change.Diff() to skip changes that are only noise.
+var DefaultDiffIgnore = []string{
+	"/metadata/resourceVersion",
+	"/metadata/managedFields",
+	"/status/conditions/*/lastHeartbeatTime",
+}
+
+// PatchOp is an RFC 6902 JSON Patch operation. Paths are RFC 6901 JSON Pointers.
+type PatchOp struct {
+	// Op is the operation, one of "add", "remove" or "replace".
+	Op string `json:"op"`
+	// Path is the location the operation applies to.
+	Path string `json:"path"`
+	// Value is the JSON encoded value for "add" and "replace". It is empty for "remove".
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Diff is the difference between the Old and New of an update Change.
+type Diff struct {
+	ops []PatchOp
+}
+
+// Empty reports if there are no differences.
+func (d Diff) Empty() bool {
+	return len(d.ops) == 0
+}
+
+// Patch returns the RFC 6902 JSON Patch operations that turn Old into New. If paths were ignored, applying
+// the patch to Old will not produce New. The returned slice must not be modified.
+func (d Diff) Patch() []PatchOp {
+	return d.ops
+}
+
+// JSONPatch returns Patch() encoded as an RFC 6902 JSON Patch document.
+func (d Diff) JSONPatch() ([]byte, error) {
+	if d.ops == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(d.ops)
+}
+
+// Paths returns the JSON Pointer of every changed field, in the order of Patch().
+func (d Diff) Paths() []string {
+	paths := make([]string, 0, len(d.ops))
+	for _, op := range d.ops {
+		paths = append(paths, op.Path)
+	}
+	return paths
+}
+
+// diffCache holds the diff of a Change. It is shared by all copies of the Change, so the diff is computed
+// at most once however many processors ask for it.
+type diffCache struct {
+	once sync.Once
+	ops  []PatchOp
+	err  error
+}
+
+// withDiffCache returns c with a diffCache attached if it is an update.
+func (c Change[T]) withDiffCache() Change[T] {
+	if c.ChangeType == CTUpdate && c.diff == nil {
+		c.diff = &diffCache{}
+	}
+	return c
+}
+
+// Diff returns the difference between Old and New, leaving out any change at or below a path in ignore.
+// Paths in ignore are JSON Pointers where a "*" segment matches any key or index, such as those in
+// DefaultDiffIgnore. The full diff is computed on first use and cached for the Change and all copies of it
+// made after it was put in an Entry. Returns ErrNotUpdate if the Change is not a CTUpdate.
+func (c Change[T]) Diff(ignore ...string) (Diff, error) {
+	if c.ChangeType != CTUpdate {
+		return Diff{}, ErrNotUpdate
+	}
+
+	var ops []PatchOp
+	var err error
+	if c.diff != nil {
+		c.diff.once.Do(func() {
+			c.diff.ops, c.diff.err = diffObjects(c.Old, c.New)
+		})
+		ops, err = c.diff.ops, c.diff.err
+	} else {
+		ops, err = diffObjects(c.Old, c.New)
+	}
+	if err != nil {
+		return Diff{}, err
+	}
+
+	if len(ignore) == 0 {
+		return Diff{ops: ops}, nil
+	}
+	patterns := make([][]string, 0, len(ignore))
+	for _, p := range ignore {
+		patterns = append(patterns, splitPointer(p))
+	}
+	filtered := make([]PatchOp, 0, len(ops))
+	for _, op := range ops {
+		if !ignored(splitPointer(op.Path), patterns) {
+			filtered = append(filtered, op)
+		}
+	}
+	return Diff{ops: filtered}, nil
+}
+
+// diffObjects returns the patch operations that turn oldObj into newObj, comparing their JSON encodings.
+func diffObjects(oldObj, newObj any) ([]PatchOp, error) {
+	o, err := toJSONValue(oldObj)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode old object: %w", err)
+	}
+	n, err := toJSONValue(newObj)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode new object: %w", err)
+	}
+
+	var ops []PatchOp
+	if err := diffValues("", o, n, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// toJSONValue encodes v as JSON and decodes it into the generic JSON types.
+func toJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var out any
+	if err := d.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// diffValues appends to ops the operations that turn o into n at path.
+func diffValues(path string, o, n any, ops *[]PatchOp) error {
+	switch ov := o.(type) {
+	case map[string]any:
+		nv, ok := n.(map[string]any)
+		if !ok {
+			return replaceOp(path, n, ops)
+		}
+		return diffMaps(path, ov, nv, ops)
+	case []any:
+		nv, ok := n.([]any)
+		if !ok {
+			return replaceOp(path, n, ops)
+		}
+		return diffSlices(path, ov, nv, ops)
+	}
+	if reflect.DeepEqual(o, n) {
+		return nil
+	}
+	return replaceOp(path, n, ops)
+}
+
+// diffMaps appends the operations for two JSON objects. Keys are visited in sorted order so the patch is
+// stable.
+func diffMaps(path string, o, n map[string]any, ops *[]PatchOp) error {
+	keys := make([]string, 0, len(o)+len(n))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	for k := range n {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+		ov, inOld := o[k]
+		nv, inNew := n[k]
+		switch {
+		case !inNew:
+			*ops = append(*ops, PatchOp{Op: "remove", Path: p})
+		case !inOld:
+			if err := valueOp("add", p, nv, ops); err != nil {
+				return err
+			}
+		default:
+			if err := diffValues(p, ov, nv, ops); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffSlices appends the operations for two JSON arrays. Elements are compared by index, with elements
+// added to or removed from the end. Removes are done from the last index so earlier indexes stay valid.
+func diffSlices(path string, o, n []any, ops *[]PatchOp) error {
+	common := min(len(o), len(n))
+	for i := 0; i < common; i++ {
+		if err := diffValues(path+"/"+strconv.Itoa(i), o[i], n[i], ops); err != nil {
+			return err
+		}
+	}
+	for i := len(o) - 1; i >= common; i-- {
+		*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(n); i++ {
+		if err := valueOp("add", path+"/"+strconv.Itoa(i), n[i], ops); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceOp(path string, v any, ops *[]PatchOp) error {
+	return valueOp("replace", path, v, ops)
+}
+
+func valueOp(op, path string, v any, ops *[]PatchOp) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	*ops = append(*ops, PatchOp{Op: op, Path: path, Value: b})
+	return nil
+}
+
+// escapePointer escapes a JSON Pointer reference token as described in RFC 6901.
+func escapePointer(s string) string {
+	if !strings.ContainsAny(s, "~/") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// splitPointer splits a JSON Pointer into its escaped reference tokens.
+func splitPointer(p string) []string {
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// ignored reports if path is at or below any of patterns.
+func ignored(path []string, patterns [][]string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) > len(path) {
+			continue
+		}
+		match := true
+		for i, seg := range pattern {
+			if seg != "*" && seg != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
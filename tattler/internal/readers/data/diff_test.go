@@ -0,0 +1,154 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	then := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(then.Add(time.Minute))
+
+	oldPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pod",
+			UID:             "pod",
+			ResourceVersion: "1",
+			Labels:          map[string]string{"app": "web", "example.com/tier": "front"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "nginx:1.25"}, {Name: "sidecar", Image: "envoy"}},
+		},
+	}
+	newPod := oldPod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Labels = map[string]string{"app": "web", "version": "2"}
+	newPod.Spec.Containers = []corev1.Container{{Name: "web", Image: "nginx:1.26"}}
+
+	oldNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "node", ResourceVersion: "1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastHeartbeatTime: then}},
+		},
+	}
+	newNode := oldNode.DeepCopy()
+	newNode.ResourceVersion = "2"
+	newNode.Status.Conditions[0].LastHeartbeatTime = now
+
+	tests := []struct {
+		name      string
+		diff      func(ignore ...string) (Diff, error)
+		ignore    []string
+		wantPaths []string
+		wantOps   []PatchOp
+		wantErr   bool
+	}{
+		{
+			name: "Pod",
+			diff: MustNewChange(newPod, oldPod, CTUpdate).Diff,
+			wantPaths: []string{
+				"/metadata/labels/example.com~1tier",
+				"/metadata/labels/version",
+				"/metadata/resourceVersion",
+				"/spec/containers/0/image",
+				"/spec/containers/1",
+			},
+			wantOps: []PatchOp{
+				{Op: "remove", Path: "/metadata/labels/example.com~1tier"},
+				{Op: "add", Path: "/metadata/labels/version", Value: json.RawMessage(`"2"`)},
+				{Op: "replace", Path: "/metadata/resourceVersion", Value: json.RawMessage(`"2"`)},
+				{Op: "replace", Path: "/spec/containers/0/image", Value: json.RawMessage(`"nginx:1.26"`)},
+				{Op: "remove", Path: "/spec/containers/1"},
+			},
+		},
+		{
+			name:   "Pod with ignore",
+			diff:   MustNewChange(newPod, oldPod, CTUpdate).Diff,
+			ignore: []string{"/metadata/labels", "/metadata/resourceVersion"},
+			wantPaths: []string{
+				"/spec/containers/0/image",
+				"/spec/containers/1",
+			},
+		},
+		{
+			name:      "Node heartbeat is noise",
+			diff:      MustNewChange(newNode, oldNode, CTUpdate).Diff,
+			ignore:    DefaultDiffIgnore,
+			wantPaths: []string{},
+		},
+		{
+			name:    "Error: not an update",
+			diff:    MustNewChange(newPod, nil, CTAdd).Diff,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		d, err := test.diff(test.ignore...)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("TestDiff(%s): got err == nil, want err != nil", test.name)
+			continue
+		case !test.wantErr && err != nil:
+			t.Errorf("TestDiff(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if diff := pretty.Compare(test.wantPaths, d.Paths()); diff != "" {
+			t.Errorf("TestDiff(%s): paths: -want/+got:\n%s", test.name, diff)
+		}
+		if test.wantOps != nil {
+			if diff := pretty.Compare(test.wantOps, d.Patch()); diff != "" {
+				t.Errorf("TestDiff(%s): patch: -want/+got:\n%s", test.name, diff)
+			}
+		}
+		if d.Empty() != (len(test.wantPaths) == 0) {
+			t.Errorf("TestDiff(%s): got Empty() == %v, want %v", test.name, d.Empty(), len(test.wantPaths) == 0)
+		}
+	}
+}
+
+func TestDiffCached(t *testing.T) {
+	t.Parallel()
+
+	oldPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "pod", ResourceVersion: "1"}}
+	newPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "pod", ResourceVersion: "2"}}
+	i := MustNewInformer(MustNewChange(newPod, oldPod, CTUpdate))
+
+	a, err := i.Pod()
+	if err != nil {
+		t.Fatalf("TestDiffCached: got err == %v, want err == nil", err)
+	}
+	b, err := i.Pod()
+	if err != nil {
+		t.Fatalf("TestDiffCached: got err == %v, want err == nil", err)
+	}
+	da, err := a.Diff()
+	if err != nil {
+		t.Fatalf("TestDiffCached: got err == %v, want err == nil", err)
+	}
+	db, err := b.Diff()
+	if err != nil {
+		t.Fatalf("TestDiffCached: got err == %v, want err == nil", err)
+	}
+	if &da.Patch()[0] != &db.Patch()[0] {
+		t.Errorf("TestDiffCached: got separate diffs for copies of the same Change, want one cached diff")
+	}
+
+	j, err := da.JSONPatch()
+	if err != nil {
+		t.Fatalf("TestDiffCached: got err == %v, want err == nil", err)
+	}
+	if want := `[{"op":"replace","path":"/metadata/resourceVersion","value":"2"}]`; string(j) != want {
+		t.Errorf("TestDiffCached: got JSONPatch() == %s, want %s", j, want)
+	}
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// EndpointSlicePods returns the UIDs of the pods that the EndpointSlice in the change points to.
+// This is always for the latest change, so a delete returns the pods the EndpointSlice pointed
+// to before it was removed. Endpoints that do not reference a pod are skipped.
+func EndpointSlicePods(c Change[*discoveryv1.EndpointSlice]) []types.UID {
+	return endpointSlicePods(c.latest())
+}
+
+// EndpointSlicePodChanges returns the UIDs of pods that were added to or removed from the EndpointSlice
+// by an update. For an add all pods are returned in added, for a delete all pods are returned in removed.
+func EndpointSlicePodChanges(c Change[*discoveryv1.EndpointSlice]) (added, removed []types.UID) {
+	switch c.ChangeType {
+	case CTAdd:
+		return endpointSlicePods(c.New), nil
+	case CTDelete:
+		return nil, endpointSlicePods(c.Old)
+	case CTUpdate:
+	default:
+		return nil, nil
+	}
+
+	oldPods := map[types.UID]bool{}
+	for _, uid := range endpointSlicePods(c.Old) {
+		oldPods[uid] = true
+	}
+	for _, uid := range endpointSlicePods(c.New) {
+		if oldPods[uid] {
+			delete(oldPods, uid)
+			continue
+		}
+		added = append(added, uid)
+	}
+	for _, uid := range endpointSlicePods(c.Old) {
+		if oldPods[uid] {
+			removed = append(removed, uid)
+		}
+	}
+	return added, removed
+}
+
+// EndpointSliceService returns the namespace and name of the Service that owns the EndpointSlice in the change.
+// The name is empty if the EndpointSlice is not managed for a Service.
+func EndpointSliceService(c Change[*discoveryv1.EndpointSlice]) types.NamespacedName {
+	es := c.latest()
+	if es == nil {
+		return types.NamespacedName{}
+	}
+	name := es.Labels[discoveryv1.LabelServiceName]
+	if name == "" {
+		return types.NamespacedName{}
+	}
+	return types.NamespacedName{Namespace: es.Namespace, Name: name}
+}
+
+// endpointSlicePods returns the UIDs of the pods referenced by es. Duplicates are removed.
+func endpointSlicePods(es *discoveryv1.EndpointSlice) []types.UID {
+	if es == nil {
+		return nil
+	}
+
+	var uids []types.UID
+	seen := map[types.UID]bool{}
+	for _, ep := range es.Endpoints {
+		ref := ep.TargetRef
+		if ref == nil || ref.Kind != "Pod" || ref.UID == "" {
+			continue
+		}
+		if seen[ref.UID] {
+			continue
+		}
+		seen[ref.UID] = true
+		uids = append(uids, ref.UID)
+	}
+	return uids
+}
@@ -0,0 +1,119 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func endpointSlice(uids ...types.UID) *discoveryv1.EndpointSlice {
+	es := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "svc"},
+		},
+	}
+	for _, uid := range uids {
+		es.Endpoints = append(es.Endpoints, discoveryv1.Endpoint{
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", UID: uid},
+		})
+	}
+	return es
+}
+
+func TestEndpointSlicePods(t *testing.T) {
+	t.Parallel()
+
+	es := endpointSlice("a", "b", "a")
+	es.Endpoints = append(
+		es.Endpoints,
+		discoveryv1.Endpoint{},
+		discoveryv1.Endpoint{TargetRef: &corev1.ObjectReference{Kind: "Node", UID: "node"}},
+	)
+
+	tests := []struct {
+		name   string
+		change Change[*discoveryv1.EndpointSlice]
+		want   []types.UID
+	}{
+		{
+			name:   "Add",
+			change: MustNewChange(es, nil, CTAdd),
+			want:   []types.UID{"a", "b"},
+		},
+		{
+			name:   "Delete uses old",
+			change: MustNewChange(nil, es, CTDelete),
+			want:   []types.UID{"a", "b"},
+		},
+		{
+			name:   "Update uses new",
+			change: MustNewChange(endpointSlice("c"), es, CTUpdate),
+			want:   []types.UID{"c"},
+		},
+	}
+
+	for _, test := range tests {
+		got := EndpointSlicePods(test.change)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestEndpointSlicePods(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestEndpointSlicePodChanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		change      Change[*discoveryv1.EndpointSlice]
+		wantAdded   []types.UID
+		wantRemoved []types.UID
+	}{
+		{
+			name:      "Add",
+			change:    MustNewChange(endpointSlice("a"), nil, CTAdd),
+			wantAdded: []types.UID{"a"},
+		},
+		{
+			name:        "Delete",
+			change:      MustNewChange(nil, endpointSlice("a"), CTDelete),
+			wantRemoved: []types.UID{"a"},
+		},
+		{
+			name:        "Update",
+			change:      MustNewChange(endpointSlice("b", "c"), endpointSlice("a", "b"), CTUpdate),
+			wantAdded:   []types.UID{"c"},
+			wantRemoved: []types.UID{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		added, removed := EndpointSlicePodChanges(test.change)
+		if diff := pretty.Compare(test.wantAdded, added); diff != "" {
+			t.Errorf("TestEndpointSlicePodChanges(%s): added -want/+got:\n%s", test.name, diff)
+		}
+		if diff := pretty.Compare(test.wantRemoved, removed); diff != "" {
+			t.Errorf("TestEndpointSlicePodChanges(%s): removed -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestEndpointSliceService(t *testing.T) {
+	t.Parallel()
+
+	got := EndpointSliceService(MustNewChange(endpointSlice(), nil, CTAdd))
+	want := types.NamespacedName{Namespace: "ns", Name: "svc"}
+	if got != want {
+		t.Errorf("TestEndpointSliceService: got %v, want %v", got, want)
+	}
+
+	got = EndpointSliceService(MustNewChange(&discoveryv1.EndpointSlice{}, nil, CTAdd))
+	if got != (types.NamespacedName{}) {
+		t.Errorf("TestEndpointSliceService(no label): got %v, want empty", got)
+	}
+}
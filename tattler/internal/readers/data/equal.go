@@ -0,0 +1,50 @@
+package data
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Normalizer clears fields of obj that should not count as a change. obj is a copy and may be modified.
+type Normalizer func(obj runtime.Object)
+
+// IgnoreManagedFields is a Normalizer that clears metadata.managedFields.
+func IgnoreManagedFields(obj runtime.Object) {
+	if m, err := meta.Accessor(obj); err == nil {
+		m.SetManagedFields(nil)
+	}
+}
+
+// IgnoreNodeHeartbeats is a Normalizer that clears the lastHeartbeatTime of node conditions, which the
+// kubelet updates even when a condition has not changed.
+func IgnoreNodeHeartbeats(obj runtime.Object) {
+	n, ok := obj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	for i := range n.Status.Conditions {
+		n.Status.Conditions[i].LastHeartbeatTime = metav1.Time{}
+	}
+}
+
+// SemanticEqual reports if oldObj and newObj are equal using equality.Semantic once their resourceVersion
+// is cleared and each Normalizer has been applied. Neither object is modified.
+func SemanticEqual(oldObj, newObj runtime.Object, normalizers ...Normalizer) bool {
+	if oldObj == nil || newObj == nil {
+		return oldObj == nil && newObj == nil
+	}
+
+	o, n := oldObj.DeepCopyObject(), newObj.DeepCopyObject()
+	for _, obj := range []runtime.Object{o, n} {
+		if m, err := meta.Accessor(obj); err == nil {
+			m.SetResourceVersion("")
+		}
+		for _, f := range normalizers {
+			f(obj)
+		}
+	}
+	return equality.Semantic.DeepEqual(o, n)
+}
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSemanticEqual(t *testing.T) {
+	t.Parallel()
+
+	node := func(rv string, heartbeat time.Time, status corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "node",
+				ResourceVersion: rv,
+				ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubelet", Time: &metav1.Time{Time: heartbeat}}},
+			},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastHeartbeatTime: metav1.NewTime(heartbeat)},
+				},
+			},
+		}
+	}
+	then := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := then.Add(time.Minute)
+
+	tests := []struct {
+		name           string
+		oldObj, newObj runtime.Object
+		normalizers    []Normalizer
+		want           bool
+	}{
+		{name: "Only resourceVersion differs", oldObj: node("1", then, corev1.ConditionTrue), newObj: node("2", then, corev1.ConditionTrue), want: true},
+		{name: "Heartbeat without normalizers", oldObj: node("1", then, corev1.ConditionTrue), newObj: node("2", now, corev1.ConditionTrue)},
+		{
+			name:        "Heartbeat with normalizers",
+			oldObj:      node("1", then, corev1.ConditionTrue),
+			newObj:      node("2", now, corev1.ConditionTrue),
+			normalizers: []Normalizer{IgnoreManagedFields, IgnoreNodeHeartbeats},
+			want:        true,
+		},
+		{
+			name:        "Real change with normalizers",
+			oldObj:      node("1", then, corev1.ConditionTrue),
+			newObj:      node("2", now, corev1.ConditionFalse),
+			normalizers: []Normalizer{IgnoreManagedFields, IgnoreNodeHeartbeats},
+		},
+		{name: "Nil", oldObj: nil, newObj: node("1", then, corev1.ConditionTrue)},
+	}
+
+	for _, test := range tests {
+		var oldRV string
+		if test.oldObj != nil {
+			oldRV = test.oldObj.(*corev1.Node).ResourceVersion
+		}
+		if got := SemanticEqual(test.oldObj, test.newObj, test.normalizers...); got != test.want {
+			t.Errorf("TestSemanticEqual(%s): got %v, want %v", test.name, got, test.want)
+		}
+		if test.oldObj != nil && test.oldObj.(*corev1.Node).ResourceVersion != oldRV {
+			t.Errorf("TestSemanticEqual(%s): oldObj was modified", test.name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+
+	ref := corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "pod", UID: "uid"}
+	last := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	core := MustNewEvent(
+		MustNewChange(
+			&corev1.Event{
+				InvolvedObject: ref,
+				Reason:         "BackOff",
+				Message:        "restarting",
+				LastTimestamp:  metav1.NewTime(last),
+			},
+			nil,
+			CTAdd,
+		),
+	)
+	v1 := MustNewEvent(
+		MustNewChange(
+			&eventsv1.Event{
+				Regarding: ref,
+				Reason:    "BackOff",
+				Note:      "restarting",
+				Series:    &eventsv1.EventSeries{Count: 3, LastObservedTime: metav1.NewMicroTime(last)},
+			},
+			nil,
+			CTAdd,
+		),
+	)
+	other := MustNewEvent(
+		MustNewChange(&corev1.Event{InvolvedObject: ref, Reason: "BackOff", Message: "other"}, nil, CTAdd),
+	)
+
+	if core.GetUID() != v1.GetUID() {
+		t.Errorf("TestNewEvent: core/v1 and events.k8s.io/v1 events for the same occurrence got different UIDs")
+	}
+	if core.GetUID() == other.GetUID() {
+		t.Errorf("TestNewEvent: events with different messages got the same UID")
+	}
+	if core.Count != 1 {
+		t.Errorf("TestNewEvent: got core.Count == %d, want 1", core.Count)
+	}
+	if v1.Count != 3 {
+		t.Errorf("TestNewEvent: got v1.Count == %d, want 3", v1.Count)
+	}
+	if !core.LastTimestamp.Equal(last) || !v1.LastTimestamp.Equal(last) {
+		t.Errorf("TestNewEvent: got LastTimestamp %v and %v, want %v", core.LastTimestamp, v1.LastTimestamp, last)
+	}
+
+	if _, err := NewEvent(MustNewChange(&corev1.Pod{}, nil, CTAdd)); err == nil {
+		t.Errorf("TestNewEvent(pod): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+//go:generate stringer -type=JobOutcome -linecomment
+
+// JobOutcome is a change derived from the status conditions of a Job.
+type JobOutcome uint8
+
+const (
+	// JONone indicates the change did not move the Job into a finished state.
+	JONone JobOutcome = 0 // None
+	// JOComplete indicates the Job completed successfully in this change.
+	JOComplete JobOutcome = 1 // Complete
+	// JOFailed indicates the Job failed in this change.
+	JOFailed JobOutcome = 2 // Failed
+)
+
+// JobFinished returns the JobOutcome of a Job change. An outcome other than JONone is only
+// returned when the JobComplete or JobFailed condition became true with this change, so a Job
+// that was already finished before an update will return JONone. Deletes always return JONone.
+func JobFinished(c Change[*batchv1.Job]) JobOutcome {
+	switch c.ChangeType {
+	case CTAdd, CTUpdate:
+	default:
+		return JONone
+	}
+
+	now := jobOutcome(c.New)
+	if now == JONone {
+		return JONone
+	}
+	if c.ChangeType == CTUpdate && jobOutcome(c.Old) == now {
+		return JONone
+	}
+	return now
+}
+
+// jobOutcome returns the terminal outcome recorded in a Job's status conditions.
+func jobOutcome(j *batchv1.Job) JobOutcome {
+	if j == nil {
+		return JONone
+	}
+	for _, cond := range j.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return JOComplete
+		case batchv1.JobFailed:
+			return JOFailed
+		}
+	}
+	return JONone
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestJobFinished(t *testing.T) {
+	t.Parallel()
+
+	running := &batchv1.Job{}
+	complete := &batchv1.Job{
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	failed := &batchv1.Job{
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionFalse},
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		change Change[*batchv1.Job]
+		want   JobOutcome
+	}{
+		{
+			name:   "Add of a running job",
+			change: MustNewChange(running, nil, CTAdd),
+			want:   JONone,
+		},
+		{
+			name:   "Add of a completed job",
+			change: MustNewChange(complete, nil, CTAdd),
+			want:   JOComplete,
+		},
+		{
+			name:   "Update to complete",
+			change: MustNewChange(complete, running, CTUpdate),
+			want:   JOComplete,
+		},
+		{
+			name:   "Update to failed",
+			change: MustNewChange(failed, running, CTUpdate),
+			want:   JOFailed,
+		},
+		{
+			name:   "Update of an already completed job",
+			change: MustNewChange(complete, complete, CTUpdate),
+			want:   JONone,
+		},
+		{
+			name:   "Delete of a completed job",
+			change: MustNewChange(nil, complete, CTDelete),
+			want:   JONone,
+		},
+	}
+
+	for _, test := range tests {
+		if got := JobFinished(test.change); got != test.want {
+			t.Errorf("TestJobFinished(%s): got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+//go:generate stringer -type=LivenessState -linecomment
+
+// LivenessState is the liveness of a node derived from its lease in kube-node-lease.
+type LivenessState uint8
+
+const (
+	// LSUnknown indicates the lease has never been renewed.
+	LSUnknown LivenessState = 0 // Unknown
+	// LSHealthy indicates the lease was renewed within half of its duration. The kubelet renews every
+	// quarter of the duration, so a healthy node has missed at most one renewal.
+	LSHealthy LivenessState = 1 // Healthy
+	// LSLate indicates the lease has not been renewed for over half of its duration, but has not expired.
+	LSLate LivenessState = 2 // Late
+	// LSExpired indicates the lease has not been renewed within its duration.
+	LSExpired LivenessState = 3 // Expired
+)
+
+// DefaultLeaseDuration is the duration used when a lease does not set leaseDurationSeconds. This is the
+// kubelet default.
+const DefaultLeaseDuration = 40 * time.Second
+
+// NodeLiveness is the liveness of a node derived from its lease. Readers emit this instead of the lease
+// itself, and only when State changes. ObjectMeta is from the lease, whose name is the node name.
+type NodeLiveness struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// State is the liveness of the node.
+	State LivenessState
+	// HolderIdentity is the holder of the lease, normally the node name.
+	HolderIdentity string
+	// RenewTime is when the lease was last renewed. It is zero if it has never been renewed.
+	RenewTime time.Time
+	// LeaseDuration is how long the lease is valid for after RenewTime.
+	LeaseDuration time.Duration
+}
+
+// NewNodeLiveness creates a NodeLiveness from lease, evaluated at now. lease is not modified.
+func NewNodeLiveness(lease *coordinationv1.Lease, now time.Time) *NodeLiveness {
+	if lease == nil {
+		return nil
+	}
+
+	nl := &NodeLiveness{
+		ObjectMeta:    *lease.ObjectMeta.DeepCopy(),
+		LeaseDuration: DefaultLeaseDuration,
+	}
+	// Lease churn is what we are removing, so the managed fields are of no use.
+	nl.ManagedFields = nil
+	if lease.Spec.HolderIdentity != nil {
+		nl.HolderIdentity = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.RenewTime != nil {
+		nl.RenewTime = lease.Spec.RenewTime.Time
+	}
+	if lease.Spec.LeaseDurationSeconds != nil && *lease.Spec.LeaseDurationSeconds > 0 {
+		nl.LeaseDuration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	nl.State = Liveness(nl.RenewTime, nl.LeaseDuration, now)
+	return nl
+}
+
+// Evaluate returns a copy of n with State evaluated at now.
+func (n *NodeLiveness) Evaluate(now time.Time) *NodeLiveness {
+	c := n.DeepCopyObject().(*NodeLiveness)
+	c.State = Liveness(c.RenewTime, c.LeaseDuration, now)
+	return c
+}
+
+// DeepCopyObject implements runtime.Object.
+func (n *NodeLiveness) DeepCopyObject() runtime.Object {
+	if n == nil {
+		return nil
+	}
+	c := &NodeLiveness{
+		TypeMeta:       n.TypeMeta,
+		State:          n.State,
+		HolderIdentity: n.HolderIdentity,
+		RenewTime:      n.RenewTime,
+		LeaseDuration:  n.LeaseDuration,
+	}
+	n.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	return c
+}
+
+// Liveness returns the LivenessState of a lease renewed at renewTime that is valid for leaseDuration,
+// evaluated at now.
+func Liveness(renewTime time.Time, leaseDuration time.Duration, now time.Time) LivenessState {
+	if renewTime.IsZero() {
+		return LSUnknown
+	}
+	age := now.Sub(renewTime)
+	switch {
+	case age > leaseDuration:
+		return LSExpired
+	case age > leaseDuration/2:
+		return LSLate
+	}
+	return LSHealthy
+}
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLiveness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	d := 40 * time.Second
+
+	tests := []struct {
+		name  string
+		renew time.Time
+		want  LivenessState
+	}{
+		{name: "Never renewed", want: LSUnknown},
+		{name: "Just renewed", renew: now, want: LSHealthy},
+		{name: "At half duration", renew: now.Add(-20 * time.Second), want: LSHealthy},
+		{name: "Past half duration", renew: now.Add(-21 * time.Second), want: LSLate},
+		{name: "At duration", renew: now.Add(-40 * time.Second), want: LSLate},
+		{name: "Past duration", renew: now.Add(-41 * time.Second), want: LSExpired},
+	}
+
+	for _, test := range tests {
+		if got := Liveness(test.renew, d, now); got != test.want {
+			t.Errorf("TestLiveness(%s): got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NetworkPolicyNamespaces are the namespaces a change to a NetworkPolicy opens or closes traffic with.
+// Each field holds namespace names, sorted.
+//
+// A NetworkPolicy is looked at in isolation. A direction that the policy does not cover, or a policy that
+// does not exist, is treated as allowing all namespaces. Other policies that select the same pods may still
+// allow traffic that this policy closes. IPBlock peers are not namespaces and are ignored.
+type NetworkPolicyNamespaces struct {
+	// IngressOpened are namespaces that can now send traffic to the selected pods.
+	IngressOpened []string
+	// IngressClosed are namespaces that can no longer send traffic to the selected pods.
+	IngressClosed []string
+	// EgressOpened are namespaces that the selected pods can now send traffic to.
+	EgressOpened []string
+	// EgressClosed are namespaces that the selected pods can no longer send traffic to.
+	EgressClosed []string
+}
+
+// NetworkPolicyNamespaceChanges summarizes which namespaces a change to a NetworkPolicy opens or closes.
+// namespaces are the namespaces in the cluster, usually from the namespaces a processor has seen from
+// the informers reader. They are needed to evaluate namespace selectors.
+func NetworkPolicyNamespaceChanges(c Change[*networkingv1.NetworkPolicy], namespaces []*corev1.Namespace) NetworkPolicyNamespaces {
+	oldIn, oldOut := policyNamespaces(c.Old, namespaces)
+	newIn, newOut := policyNamespaces(c.New, namespaces)
+
+	npn := NetworkPolicyNamespaces{}
+	npn.IngressOpened, npn.IngressClosed = namespaceDiff(oldIn, newIn)
+	npn.EgressOpened, npn.EgressClosed = namespaceDiff(oldOut, newOut)
+	return npn
+}
+
+// policyNamespaces returns the namespaces that np allows ingress from and egress to.
+func policyNamespaces(np *networkingv1.NetworkPolicy, namespaces []*corev1.Namespace) (ingress, egress map[string]bool) {
+	all := map[string]bool{}
+	for _, ns := range namespaces {
+		if ns != nil {
+			all[ns.Name] = true
+		}
+	}
+	if np == nil {
+		return all, all
+	}
+
+	hasIngress, hasEgress := policyTypes(np)
+
+	ingress, egress = all, all
+	if hasIngress {
+		ingress = map[string]bool{}
+		for _, rule := range np.Spec.Ingress {
+			peerNamespaces(np.Namespace, rule.From, namespaces, ingress)
+		}
+	}
+	if hasEgress {
+		egress = map[string]bool{}
+		for _, rule := range np.Spec.Egress {
+			peerNamespaces(np.Namespace, rule.To, namespaces, egress)
+		}
+	}
+	return ingress, egress
+}
+
+// policyTypes returns the directions np covers. This follows the APIServer defaulting when PolicyTypes
+// is not set: Ingress is always covered and Egress is covered if there are egress rules.
+func policyTypes(np *networkingv1.NetworkPolicy) (ingress, egress bool) {
+	if len(np.Spec.PolicyTypes) == 0 {
+		return true, len(np.Spec.Egress) > 0
+	}
+	for _, t := range np.Spec.PolicyTypes {
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return ingress, egress
+}
+
+// peerNamespaces adds the namespaces that peers allow to out. A rule without peers allows all namespaces.
+func peerNamespaces(policyNS string, peers []networkingv1.NetworkPolicyPeer, namespaces []*corev1.Namespace, out map[string]bool) {
+	if len(peers) == 0 {
+		for _, ns := range namespaces {
+			if ns != nil {
+				out[ns.Name] = true
+			}
+		}
+		return
+	}
+
+	for _, peer := range peers {
+		switch {
+		case peer.NamespaceSelector != nil:
+			sel, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+			if err != nil {
+				// An invalid selector is rejected by the APIServer, so this should not happen.
+				continue
+			}
+			for _, ns := range namespaces {
+				if ns != nil && sel.Matches(labels.Set(ns.Labels)) {
+					out[ns.Name] = true
+				}
+			}
+		case peer.PodSelector != nil:
+			// A pod selector without a namespace selector selects pods in the policy's namespace.
+			out[policyNS] = true
+		}
+	}
+}
+
+// namespaceDiff returns the sorted names that are in newSet but not oldSet, and in oldSet but not newSet.
+func namespaceDiff(oldSet, newSet map[string]bool) (opened, closed []string) {
+	for ns := range newSet {
+		if !oldSet[ns] {
+			opened = append(opened, ns)
+		}
+	}
+	for ns := range oldSet {
+		if !newSet[ns] {
+			closed = append(closed, ns)
+		}
+	}
+	sort.Strings(opened)
+	sort.Strings(closed)
+	return opened, closed
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNetworkPolicyNamespaceChanges(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "monitoring", Labels: map[string]string{"team": "ops"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "logging", Labels: map[string]string{"team": "ops"}}},
+	}
+
+	denyAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "policy"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	allowOps := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "policy"},
+		Spec: networkingv1.NetworkPolicySpec{
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "ops"}}},
+					},
+				},
+			},
+		},
+	}
+	allowSameNS := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "policy"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		change Change[*networkingv1.NetworkPolicy]
+		want   NetworkPolicyNamespaces
+	}{
+		{
+			name:   "Add deny all ingress",
+			change: Change[*networkingv1.NetworkPolicy]{ChangeType: CTAdd, New: denyAll},
+			want:   NetworkPolicyNamespaces{IngressClosed: []string{"app", "logging", "monitoring"}},
+		},
+		{
+			name:   "Deny all to allow ops",
+			change: Change[*networkingv1.NetworkPolicy]{ChangeType: CTUpdate, Old: denyAll, New: allowOps},
+			want:   NetworkPolicyNamespaces{IngressOpened: []string{"logging", "monitoring"}},
+		},
+		{
+			name:   "Allow ops to same namespace only, with egress",
+			change: Change[*networkingv1.NetworkPolicy]{ChangeType: CTUpdate, Old: allowOps, New: allowSameNS},
+			want: NetworkPolicyNamespaces{
+				IngressOpened: []string{"app"},
+				IngressClosed: []string{"logging", "monitoring"},
+				EgressClosed:  []string{"logging", "monitoring"},
+			},
+		},
+		{
+			name:   "Delete opens everything",
+			change: Change[*networkingv1.NetworkPolicy]{ChangeType: CTDelete, Old: allowSameNS},
+			want: NetworkPolicyNamespaces{
+				IngressOpened: []string{"logging", "monitoring"},
+				EgressOpened:  []string{"logging", "monitoring"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := NetworkPolicyNamespaceChanges(test.change, namespaces)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestNetworkPolicyNamespaceChanges(%s): -want/+got\n%s", test.name, diff)
+		}
+	}
+}
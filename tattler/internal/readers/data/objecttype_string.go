@@ -13,11 +13,39 @@ func _() {
 	_ = x[OTPod-2]
 	_ = x[OTNamespace-3]
 	_ = x[OTPersistentVolume-4]
+	_ = x[OTDeployment-5]
+	_ = x[OTReplicaSet-6]
+	_ = x[OTStatefulSet-7]
+	_ = x[OTDaemonSet-8]
+	_ = x[OTJob-9]
+	_ = x[OTCronJob-10]
+	_ = x[OTService-11]
+	_ = x[OTEndpointSlice-12]
+	_ = x[OTEvent-13]
+	_ = x[OTEventsV1Event-14]
+	_ = x[OTRole-15]
+	_ = x[OTClusterRole-16]
+	_ = x[OTRoleBinding-17]
+	_ = x[OTClusterRoleBinding-18]
+	_ = x[OTSecret-19]
+	_ = x[OTConfigMap-20]
+	_ = x[OTPersistentVolumeClaim-21]
+	_ = x[OTStorageClass-22]
+	_ = x[OTNetworkPolicy-23]
+	_ = x[OTIngress-24]
+	_ = x[OTIngressClass-25]
+	_ = x[OTGateway-26]
+	_ = x[OTHTTPRoute-27]
+	_ = x[OTValidatingWebhookConfiguration-28]
+	_ = x[OTMutatingWebhookConfiguration-29]
+	_ = x[OTValidatingAdmissionPolicy-30]
+	_ = x[OTServiceAccount-31]
+	_ = x[OTNodeLiveness-32]
 }
 
-const _ObjectType_name = "UnknownNodePodNamespacePersistentVolume"
+const _ObjectType_name = "UnknownNodePodNamespacePersistentVolumeDeploymentReplicaSetStatefulSetDaemonSetJobCronJobServiceEndpointSliceEventEventsV1EventRoleClusterRoleRoleBindingClusterRoleBindingSecretConfigMapPersistentVolumeClaimStorageClassNetworkPolicyIngressIngressClassGatewayHTTPRouteValidatingWebhookConfigurationMutatingWebhookConfigurationValidatingAdmissionPolicyServiceAccountNodeLiveness"
 
-var _ObjectType_index = [...]uint8{0, 7, 11, 14, 23, 39}
+var _ObjectType_index = [...]uint16{0, 7, 11, 14, 23, 39, 49, 59, 70, 79, 82, 89, 96, 109, 114, 127, 131, 142, 153, 171, 177, 186, 207, 219, 232, 239, 251, 258, 267, 297, 325, 350, 364, 376}
 
 func (i ObjectType) String() string {
 	if i >= ObjectType(len(_ObjectType_index)-1) {
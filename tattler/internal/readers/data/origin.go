@@ -0,0 +1,100 @@
+package data
+
+import (
+	"encoding/json"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+//go:generate stringer -type=Origin -linecomment
+
+// Origin is what caused a reader to emit an Entry.
+type Origin uint8
+
+const (
+	// ORUnknown indicates the reader does not track origin.
+	ORUnknown Origin = 0 // Unknown
+	// ORInitialList indicates the entry is an add from the reader's initial list of existing objects. This
+	// is the state of the cluster when the reader started, not a creation.
+	ORInitialList Origin = 1 // InitialList
+	// ORResync indicates the entry is an update from an informer resync or relist where the object's
+	// resourceVersion did not change.
+	ORResync Origin = 2 // Resync
+	// ORWatch indicates the entry is from a watch event, a change made while the reader was running.
+	ORWatch Origin = 3 // Watch
+)
+
+// SyncComplete is a marker entry a reader emits once it has sent its initial list. Entries from the reader
+// that come after it are from changes made while it was running. Name is the name of the reader, UID is
+// unique to each marker and CreationTimestamp is when the sync completed. This implements SourceData.
+type SyncComplete struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+}
+
+// NewSyncComplete creates a new SyncComplete for reader at now.
+func NewSyncComplete(reader string, now time.Time) *SyncComplete {
+	return &SyncComplete{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              reader,
+			UID:               types.UID(uuid.NewUUID()),
+			CreationTimestamp: metav1.NewTime(now),
+		},
+	}
+}
+
+// Object implements SourceData.Object().
+func (s *SyncComplete) Object() runtime.Object {
+	return s
+}
+
+// DeepCopyObject implements runtime.Object.
+func (s *SyncComplete) DeepCopyObject() runtime.Object {
+	if s == nil {
+		return nil
+	}
+	c := &SyncComplete{TypeMeta: s.TypeMeta}
+	s.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	return c
+}
+
+// encodeSyncComplete is the Encoder registered for *SyncComplete.
+func encodeSyncComplete(d SourceData) ([]byte, error) {
+	s, ok := d.(*SyncComplete)
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	return json.Marshal(s)
+}
+
+// decodeSyncComplete is the Decoder registered for *SyncComplete.
+func decodeSyncComplete(b []byte) (SourceData, error) {
+	s := &SyncComplete{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpdateOrigin returns the Origin of an update from oldObj to newObj. Informer resyncs, and relists that find
+// an object unchanged, send updates where both have the same resourceVersion, which is ORResync. Anything
+// else is ORWatch. Objects without metadata are ORWatch.
+func UpdateOrigin(oldObj, newObj any) Origin {
+	o, err := meta.Accessor(oldObj)
+	if err != nil {
+		return ORWatch
+	}
+	n, err := meta.Accessor(newObj)
+	if err != nil {
+		return ORWatch
+	}
+	if o.GetResourceVersion() != "" && o.GetResourceVersion() == n.GetResourceVersion() {
+		return ORResync
+	}
+	return ORWatch
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateOrigin(t *testing.T) {
+	t.Parallel()
+
+	pod := func(rv string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{ResourceVersion: rv}}
+	}
+
+	tests := []struct {
+		name           string
+		oldObj, newObj any
+		want           Origin
+	}{
+		{name: "Same resourceVersion", oldObj: pod("1"), newObj: pod("1"), want: ORResync},
+		{name: "Different resourceVersion", oldObj: pod("1"), newObj: pod("2"), want: ORWatch},
+		{name: "No resourceVersion", oldObj: pod(""), newObj: pod(""), want: ORWatch},
+		{name: "Not an object", oldObj: "pod", newObj: pod("1"), want: ORWatch},
+	}
+
+	for _, test := range tests {
+		if got := UpdateOrigin(test.oldObj, test.newObj); got != test.want {
+			t.Errorf("TestUpdateOrigin(%s): got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSyncCompleteEntry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	a := MustNewEntry(NewSyncComplete("informers", now))
+	b := MustNewEntry(NewSyncComplete("informers", now))
+
+	if a.Type != ETSyncComplete {
+		t.Errorf("TestSyncCompleteEntry: got Type == %v, want %v", a.Type, ETSyncComplete)
+	}
+	if a.UID() == "" || a.UID() == b.UID() {
+		t.Errorf("TestSyncCompleteEntry: got UIDs %q and %q, want unique UIDs", a.UID(), b.UID())
+	}
+	m, err := a.SyncComplete()
+	if err != nil {
+		t.Fatalf("TestSyncCompleteEntry: got err == %v, want err == nil", err)
+	}
+	if m.Name != "informers" || !m.CreationTimestamp.Time.Equal(now) {
+		t.Errorf("TestSyncCompleteEntry: got %s at %v, want informers at %v", m.Name, m.CreationTimestamp, now)
+	}
+	if _, err := a.Informer(); err == nil {
+		t.Errorf("TestSyncCompleteEntry: got Informer() err == nil, want err != nil")
+	}
+}
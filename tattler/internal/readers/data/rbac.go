@@ -0,0 +1,219 @@
+package data
+
+import (
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// clusterAdmin is the name of the built-in ClusterRole that grants all permissions.
+const clusterAdmin = "cluster-admin"
+
+// RuleChanges are the rules added and removed by a change to a Role or ClusterRole.
+// Rules are expanded so that each holds a single API group, resource, resource name and verb, or a single
+// non-resource URL and verb. That means a rule that gained a verb only reports the new verb as added.
+type RuleChanges struct {
+	// Added are the rules granted by this change.
+	Added []rbacv1.PolicyRule
+	// Removed are the rules revoked by this change.
+	Removed []rbacv1.PolicyRule
+}
+
+// Escalates reports if any added rule uses a wildcard verb, API group, resource or non-resource URL.
+func (r RuleChanges) Escalates() bool {
+	for _, rule := range r.Added {
+		if isWildcard(rule.Verbs) || isWildcard(rule.APIGroups) || isWildcard(rule.Resources) || isWildcard(rule.NonResourceURLs) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleRuleChanges returns the rules added and removed by a change to a Role. An add reports all rules
+// as added and a delete reports all rules as removed.
+func RoleRuleChanges(c Change[*rbacv1.Role]) RuleChanges {
+	var oldRules, newRules []rbacv1.PolicyRule
+	if c.Old != nil {
+		oldRules = c.Old.Rules
+	}
+	if c.New != nil {
+		newRules = c.New.Rules
+	}
+	return ruleChanges(oldRules, newRules)
+}
+
+// ClusterRoleRuleChanges returns the rules added and removed by a change to a ClusterRole. An add reports
+// all rules as added and a delete reports all rules as removed. Rules are compared as they appear on the
+// object, which for an aggregated ClusterRole are the rules the controller has aggregated into it.
+func ClusterRoleRuleChanges(c Change[*rbacv1.ClusterRole]) RuleChanges {
+	var oldRules, newRules []rbacv1.PolicyRule
+	if c.Old != nil {
+		oldRules = c.Old.Rules
+	}
+	if c.New != nil {
+		newRules = c.New.Rules
+	}
+	return ruleChanges(oldRules, newRules)
+}
+
+// ruleChanges expands both sets of rules and returns the difference.
+func ruleChanges(oldRules, newRules []rbacv1.PolicyRule) RuleChanges {
+	oldSet := map[string]bool{}
+	for _, r := range expandRules(oldRules) {
+		oldSet[ruleKey(r)] = true
+	}
+
+	rc := RuleChanges{}
+	newSet := map[string]bool{}
+	for _, r := range expandRules(newRules) {
+		k := ruleKey(r)
+		newSet[k] = true
+		if !oldSet[k] {
+			rc.Added = append(rc.Added, r)
+		}
+	}
+	for _, r := range expandRules(oldRules) {
+		if !newSet[ruleKey(r)] {
+			rc.Removed = append(rc.Removed, r)
+		}
+	}
+	return rc
+}
+
+// expandRules expands rules into rules holding a single permission each. Duplicates are removed.
+func expandRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	var out []rbacv1.PolicyRule
+	seen := map[string]bool{}
+	add := func(r rbacv1.PolicyRule) {
+		k := ruleKey(r)
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		out = append(out, r)
+	}
+
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			for _, url := range rule.NonResourceURLs {
+				add(rbacv1.PolicyRule{Verbs: []string{verb}, NonResourceURLs: []string{url}})
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					if len(rule.ResourceNames) == 0 {
+						add(rbacv1.PolicyRule{Verbs: []string{verb}, APIGroups: []string{group}, Resources: []string{resource}})
+						continue
+					}
+					for _, name := range rule.ResourceNames {
+						add(
+							rbacv1.PolicyRule{
+								Verbs:         []string{verb},
+								APIGroups:     []string{group},
+								Resources:     []string{resource},
+								ResourceNames: []string{name},
+							},
+						)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// ruleKey returns a key that uniquely identifies an expanded rule.
+func ruleKey(r rbacv1.PolicyRule) string {
+	return strings.Join(
+		[]string{
+			strings.Join(r.Verbs, ","),
+			strings.Join(r.APIGroups, ","),
+			strings.Join(r.Resources, ","),
+			strings.Join(r.ResourceNames, ","),
+			strings.Join(r.NonResourceURLs, ","),
+		},
+		"\x00",
+	)
+}
+
+// isWildcard reports if values holds the "*" wildcard.
+func isWildcard(values []string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll {
+			return true
+		}
+	}
+	return false
+}
+
+// BindingChanges are the subjects added to and removed from a RoleBinding or ClusterRoleBinding.
+type BindingChanges struct {
+	// RoleRef is the role that is bound. This is from the latest change.
+	RoleRef rbacv1.RoleRef
+	// Added are the subjects that were bound to RoleRef by this change.
+	Added []rbacv1.Subject
+	// Removed are the subjects that were unbound from RoleRef by this change.
+	Removed []rbacv1.Subject
+}
+
+// GrantsClusterAdmin reports if this change bound any new subject to the cluster-admin ClusterRole.
+func (b BindingChanges) GrantsClusterAdmin() bool {
+	return b.RoleRef.Kind == "ClusterRole" && b.RoleRef.Name == clusterAdmin && len(b.Added) > 0
+}
+
+// RoleBindingChanges returns the subjects added and removed by a change to a RoleBinding. An add reports
+// all subjects as added and a delete reports all subjects as removed.
+func RoleBindingChanges(c Change[*rbacv1.RoleBinding]) BindingChanges {
+	var oldRef, newRef rbacv1.RoleRef
+	var oldSubjects, newSubjects []rbacv1.Subject
+	if c.Old != nil {
+		oldRef, oldSubjects = c.Old.RoleRef, c.Old.Subjects
+	}
+	if c.New != nil {
+		newRef, newSubjects = c.New.RoleRef, c.New.Subjects
+	}
+	return bindingChanges(c.ChangeType, oldRef, newRef, oldSubjects, newSubjects)
+}
+
+// ClusterRoleBindingChanges returns the subjects added and removed by a change to a ClusterRoleBinding.
+// An add reports all subjects as added and a delete reports all subjects as removed.
+func ClusterRoleBindingChanges(c Change[*rbacv1.ClusterRoleBinding]) BindingChanges {
+	var oldRef, newRef rbacv1.RoleRef
+	var oldSubjects, newSubjects []rbacv1.Subject
+	if c.Old != nil {
+		oldRef, oldSubjects = c.Old.RoleRef, c.Old.Subjects
+	}
+	if c.New != nil {
+		newRef, newSubjects = c.New.RoleRef, c.New.Subjects
+	}
+	return bindingChanges(c.ChangeType, oldRef, newRef, oldSubjects, newSubjects)
+}
+
+// bindingChanges returns the difference between two bindings. RoleRef is immutable in the APIServer, but if
+// it were to change every new subject is reported as added, as they are bound to a different role.
+func bindingChanges(ct ChangeType, oldRef, newRef rbacv1.RoleRef, oldSubjects, newSubjects []rbacv1.Subject) BindingChanges {
+	bc := BindingChanges{RoleRef: newRef}
+	if ct == CTDelete {
+		bc.RoleRef = oldRef
+	}
+	if oldRef != newRef && ct == CTUpdate {
+		return BindingChanges{RoleRef: newRef, Added: newSubjects, Removed: oldSubjects}
+	}
+
+	oldSet := map[rbacv1.Subject]bool{}
+	for _, s := range oldSubjects {
+		oldSet[s] = true
+	}
+	newSet := map[rbacv1.Subject]bool{}
+	for _, s := range newSubjects {
+		newSet[s] = true
+		if !oldSet[s] {
+			bc.Added = append(bc.Added, s)
+		}
+	}
+	for _, s := range oldSubjects {
+		if !newSet[s] {
+			bc.Removed = append(bc.Removed, s)
+		}
+	}
+	return bc
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestRoleRuleChanges(t *testing.T) {
+	t.Parallel()
+
+	role := func(rules ...rbacv1.PolicyRule) *rbacv1.Role {
+		return &rbacv1.Role{Rules: rules}
+	}
+
+	tests := []struct {
+		name          string
+		change        Change[*rbacv1.Role]
+		want          RuleChanges
+		wantEscalates bool
+	}{
+		{
+			name: "Add expands rules",
+			change: MustNewChange(
+				role(rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}),
+				nil,
+				CTAdd,
+			),
+			want: RuleChanges{
+				Added: []rbacv1.PolicyRule{
+					{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+			},
+		},
+		{
+			name: "Update adds a verb and removes a resource name",
+			change: MustNewChange(
+				role(
+					rbacv1.PolicyRule{Verbs: []string{"get", "watch"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				),
+				role(
+					rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"s"}},
+				),
+				CTUpdate,
+			),
+			want: RuleChanges{
+				Added: []rbacv1.PolicyRule{
+					{Verbs: []string{"watch"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+				Removed: []rbacv1.PolicyRule{
+					{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"s"}},
+				},
+			},
+		},
+		{
+			name: "Update adds a wildcard verb",
+			change: MustNewChange(
+				role(rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}}),
+				role(rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}),
+				CTUpdate,
+			),
+			want: RuleChanges{
+				Added: []rbacv1.PolicyRule{
+					{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+				Removed: []rbacv1.PolicyRule{
+					{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+			},
+			wantEscalates: true,
+		},
+		{
+			name: "Delete removes non-resource URLs",
+			change: MustNewChange(
+				nil,
+				role(rbacv1.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}}),
+				CTDelete,
+			),
+			want: RuleChanges{
+				Removed: []rbacv1.PolicyRule{
+					{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := RoleRuleChanges(test.change)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestRoleRuleChanges(%s): -want/+got:\n%s", test.name, diff)
+		}
+		if got.Escalates() != test.wantEscalates {
+			t.Errorf("TestRoleRuleChanges(%s): got Escalates() == %v, want %v", test.name, got.Escalates(), test.wantEscalates)
+		}
+	}
+}
+
+func TestClusterRoleBindingChanges(t *testing.T) {
+	t.Parallel()
+
+	admin := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"}
+	view := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"}
+	alice := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}
+	bob := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "bob"}
+
+	binding := func(ref rbacv1.RoleRef, subjects ...rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{RoleRef: ref, Subjects: subjects}
+	}
+
+	tests := []struct {
+		name      string
+		change    Change[*rbacv1.ClusterRoleBinding]
+		want      BindingChanges
+		wantAdmin bool
+	}{
+		{
+			name:      "New cluster-admin binding",
+			change:    MustNewChange(binding(admin, alice), nil, CTAdd),
+			want:      BindingChanges{RoleRef: admin, Added: []rbacv1.Subject{alice}},
+			wantAdmin: true,
+		},
+		{
+			name:      "Subject added to cluster-admin",
+			change:    MustNewChange(binding(admin, alice, bob), binding(admin, alice), CTUpdate),
+			want:      BindingChanges{RoleRef: admin, Added: []rbacv1.Subject{bob}},
+			wantAdmin: true,
+		},
+		{
+			name:   "Subject removed from cluster-admin",
+			change: MustNewChange(binding(admin, alice), binding(admin, alice, bob), CTUpdate),
+			want:   BindingChanges{RoleRef: admin, Removed: []rbacv1.Subject{bob}},
+		},
+		{
+			name:   "Subject added to view",
+			change: MustNewChange(binding(view, alice, bob), binding(view, alice), CTUpdate),
+			want:   BindingChanges{RoleRef: view, Added: []rbacv1.Subject{bob}},
+		},
+		{
+			name:   "Delete",
+			change: MustNewChange(nil, binding(admin, alice), CTDelete),
+			want:   BindingChanges{RoleRef: admin, Removed: []rbacv1.Subject{alice}},
+		},
+	}
+
+	for _, test := range tests {
+		got := ClusterRoleBindingChanges(test.change)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestClusterRoleBindingChanges(%s): -want/+got:\n%s", test.name, diff)
+		}
+		if got.GrantsClusterAdmin() != test.wantAdmin {
+			t.Errorf("TestClusterRoleBindingChanges(%s): got GrantsClusterAdmin() == %v, want %v", test.name, got.GrantsClusterAdmin(), test.wantAdmin)
+		}
+	}
+}
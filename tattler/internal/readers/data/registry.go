@@ -0,0 +1,170 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Decoder rebuilds a SourceData from the bytes produced by its Encoder.
+type Decoder func(b []byte) (SourceData, error)
+
+// Encoder encodes a SourceData so that it can be rebuilt by its Decoder.
+type Encoder func(d SourceData) ([]byte, error)
+
+// Registration describes a SourceData type. Register one with Register() so that NewEntry() accepts it.
+type Registration struct {
+	// Name is the name of the EntryType, returned by EntryType.String(). It must be unique.
+	Name string
+	// Sample is a value of the SourceData type, normally the zero value. NewEntry() matches data to a
+	// Registration by its Go type, so a type and a pointer to it are different registrations.
+	Sample SourceData
+	// Encode encodes the SourceData for recording. Optional.
+	Encode Encoder
+	// Decode rebuilds the SourceData from the output of Encode. Optional, but required if Encode is set.
+	Decode Decoder
+}
+
+// registry holds the registered SourceData types.
+type registry struct {
+	mu     sync.RWMutex
+	byType map[reflect.Type]EntryType
+	byName map[string]EntryType
+	regs   map[EntryType]Registration
+	next   EntryType
+}
+
+// registered is the registry used by NewEntry(). The types in this package are registered with their
+// fixed EntryType values, other types are given values after them in the order they register.
+var registered = newRegistry()
+
+func newRegistry() *registry {
+	r := &registry{
+		byType: map[reflect.Type]EntryType{},
+		byName: map[string]EntryType{},
+		regs:   map[EntryType]Registration{},
+	}
+	builtins := []struct {
+		et  EntryType
+		reg Registration
+	}{
+		{ETInformer, Registration{Name: "Informer", Sample: Informer{}}},
+		{ETPersistentVolume, Registration{Name: "PersistentVolumes", Sample: PersistentVolume{}}},
+		{ETEvent, Registration{Name: "Events", Sample: Event{}}},
+		{ETSyncComplete, Registration{
+			Name:   "SyncComplete",
+			Sample: &SyncComplete{},
+			Encode: encodeSyncComplete,
+			Decode: decodeSyncComplete,
+		}},
+		{ETChainLink, Registration{
+			Name:   "ChainLink",
+			Sample: &ChainLink{},
+			Encode: encodeChainLink,
+			Decode: decodeChainLink,
+		}},
+	}
+	r.byName["Unknown"] = ETUnknown
+	r.regs[ETUnknown] = Registration{Name: "Unknown"}
+	for _, b := range builtins {
+		if err := r.add(b.et, b.reg); err != nil {
+			panic(err)
+		}
+		// ETChainLink is at the top of the range and register() never hands it out.
+		if b.et != ETChainLink {
+			r.next = max(r.next, b.et+1)
+		}
+	}
+	return r
+}
+
+// Register registers a SourceData type and returns the EntryType that Entries holding it will have. This is
+// normally called from an init() function in the package that defines the type.
+func Register(reg Registration) (EntryType, error) {
+	return registered.register(reg)
+}
+
+// MustRegister is Register() but panics on error.
+func MustRegister(reg Registration) EntryType {
+	et, err := Register(reg)
+	if err != nil {
+		panic(err)
+	}
+	return et
+}
+
+// LookupEntryType returns the EntryType registered as name.
+func LookupEntryType(name string) (EntryType, bool) {
+	registered.mu.RLock()
+	defer registered.mu.RUnlock()
+
+	et, ok := registered.byName[name]
+	return et, ok
+}
+
+// Registration returns the Registration for e.
+func (e EntryType) Registration() (Registration, bool) {
+	registered.mu.RLock()
+	defer registered.mu.RUnlock()
+
+	reg, ok := registered.regs[e]
+	return reg, ok
+}
+
+// String implements fmt.Stringer. This is the registered name.
+func (e EntryType) String() string {
+	if reg, ok := e.Registration(); ok {
+		return reg.Name
+	}
+	return "EntryType(" + strconv.FormatInt(int64(e), 10) + ")"
+}
+
+func (r *registry) register(reg Registration) (EntryType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next == math.MaxUint8 {
+		return ETUnknown, fmt.Errorf("cannot register %q: no EntryType values left", reg.Name)
+	}
+	et := r.next
+	if err := r.add(et, reg); err != nil {
+		return ETUnknown, err
+	}
+	r.next++
+	return et, nil
+}
+
+// add adds reg as et. r.mu must be held.
+func (r *registry) add(et EntryType, reg Registration) error {
+	switch {
+	case reg.Name == "":
+		return fmt.Errorf("registration must have a Name")
+	case reg.Sample == nil:
+		return fmt.Errorf("registration %q must have a Sample", reg.Name)
+	case reg.Encode != nil && reg.Decode == nil:
+		return fmt.Errorf("registration %q has an Encode but no Decode", reg.Name)
+	}
+	t := reflect.TypeOf(reg.Sample)
+	if _, ok := r.byName[reg.Name]; ok {
+		return fmt.Errorf("EntryType name %q is already registered", reg.Name)
+	}
+	if other, ok := r.byType[t]; ok {
+		return fmt.Errorf("type %v is already registered as %q", t, r.regs[other].Name)
+	}
+
+	r.byType[t] = et
+	r.byName[reg.Name] = et
+	r.regs[et] = reg
+	return nil
+}
+
+// entryType returns the EntryType registered for the type of d.
+func (r *registry) entryType(d SourceData) (EntryType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	et, ok := r.byType[reflect.TypeOf(d)]
+	return et, ok
+}
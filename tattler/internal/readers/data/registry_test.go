@@ -0,0 +1,101 @@
+package data
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// customData is a SourceData defined outside of the types NewEntry() knows about.
+type customData struct {
+	pod *corev1.Pod
+}
+
+func (c customData) GetUID() types.UID      { return c.pod.UID }
+func (c customData) Object() runtime.Object { return c.pod }
+
+type unregisteredData struct {
+	customData
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	et, err := Register(Registration{Name: "Custom", Sample: customData{}})
+	if err != nil {
+		t.Fatalf("TestRegister: got err == %v, want err == nil", err)
+	}
+	if et <= ETSyncComplete {
+		t.Errorf("TestRegister: got EntryType %d, want > %d", et, ETSyncComplete)
+	}
+	if et.String() != "Custom" {
+		t.Errorf("TestRegister: got String() == %s, want Custom", et)
+	}
+	if got, ok := LookupEntryType("Custom"); !ok || got != et {
+		t.Errorf("TestRegister: got LookupEntryType() == %d, %v, want %d, true", got, ok, et)
+	}
+
+	d := customData{pod: &corev1.Pod{}}
+	d.pod.UID = "custom"
+	e, err := NewEntry(d)
+	if err != nil {
+		t.Fatalf("TestRegister: got err == %v, want err == nil", err)
+	}
+	if e.Type != et || e.UID() != "custom" {
+		t.Errorf("TestRegister: got Entry{Type: %v, UID: %s}, want Entry{Type: %v, UID: custom}", e.Type, e.UID(), et)
+	}
+	if _, ok := e.Data().(customData); !ok {
+		t.Errorf("TestRegister: got Data() of %T, want customData", e.Data())
+	}
+
+	if _, err := NewEntry(unregisteredData{customData: d}); err == nil {
+		t.Errorf("TestRegister(unregistered): got err == nil, want err != nil")
+	}
+
+	errTests := []struct {
+		name string
+		reg  Registration
+	}{
+		{name: "No name", reg: Registration{Sample: unregisteredData{}}},
+		{name: "No sample", reg: Registration{Name: "NoSample"}},
+		{name: "Duplicate name", reg: Registration{Name: "Informer", Sample: unregisteredData{}}},
+		{name: "Duplicate type", reg: Registration{Name: "Other", Sample: Informer{}}},
+		{
+			name: "Encode without Decode",
+			reg: Registration{
+				Name:   "NoDecode",
+				Sample: unregisteredData{},
+				Encode: func(SourceData) ([]byte, error) { return nil, nil },
+			},
+		},
+	}
+	for _, test := range errTests {
+		if _, err := Register(test.reg); err == nil {
+			t.Errorf("TestRegister(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
+
+func TestEntryTypeString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		et   EntryType
+		want string
+	}{
+		{ETUnknown, "Unknown"},
+		{ETInformer, "Informer"},
+		{ETPersistentVolume, "PersistentVolumes"},
+		{ETEvent, "Events"},
+		{ETSyncComplete, "SyncComplete"},
+		{ETChainLink, "ChainLink"},
+		{EntryType(250), "EntryType(250)"},
+	}
+	for _, test := range tests {
+		if got := test.et.String(); got != test.want {
+			t.Errorf("TestEntryTypeString(%d): got %s, want %s", test.et, got, test.want)
+		}
+	}
+}
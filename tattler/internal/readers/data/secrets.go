@@ -0,0 +1,162 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// LastAppliedAnnotation is set by kubectl apply and holds a full copy of the applied object,
+// which for a Secret or ConfigMap includes its values.
+const LastAppliedAnnotation = corev1.LastAppliedConfigAnnotation
+
+// KeyInfo describes a single key of a Secret or ConfigMap without its value.
+type KeyInfo struct {
+	// Name is the name of the key.
+	Name string
+	// Hash is the hex encoded HMAC-SHA256 of the value, keyed with the reader's hash key. This allows
+	// detecting a value changed without exposing it.
+	Hash string
+	// Size is the size of the value in bytes.
+	Size int
+}
+
+// SecretMeta is a Secret without its values. Secrets are always converted to a SecretMeta by readers
+// and Change[*corev1.Secret] is rejected by NewInformer.
+type SecretMeta struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Type is the type of the Secret.
+	Type corev1.SecretType
+	// Immutable is the immutable field of the Secret.
+	Immutable *bool
+	// Keys describes the keys in Data and StringData, sorted by name.
+	Keys []KeyInfo
+}
+
+// NewSecretMeta creates a SecretMeta from s, hashing values with hashKey. s is not modified.
+func NewSecretMeta(s *corev1.Secret, hashKey []byte) *SecretMeta {
+	if s == nil {
+		return nil
+	}
+
+	m := &SecretMeta{
+		TypeMeta:   s.TypeMeta,
+		ObjectMeta: *metaWithoutValues(&s.ObjectMeta),
+		Type:       s.Type,
+		Immutable:  copyBool(s.Immutable),
+	}
+
+	keys := make(map[string][]byte, len(s.Data)+len(s.StringData))
+	for k, v := range s.Data {
+		keys[k] = v
+	}
+	// StringData is write only and is merged into Data by the APIServer, it should never be set here.
+	for k, v := range s.StringData {
+		keys[k] = []byte(v)
+	}
+	m.Keys = keyInfos(keys, hashKey)
+	return m
+}
+
+// DeepCopyObject implements runtime.Object.
+func (s *SecretMeta) DeepCopyObject() runtime.Object {
+	if s == nil {
+		return nil
+	}
+	n := &SecretMeta{
+		TypeMeta:  s.TypeMeta,
+		Type:      s.Type,
+		Immutable: copyBool(s.Immutable),
+		Keys:      slices.Clone(s.Keys),
+	}
+	s.ObjectMeta.DeepCopyInto(&n.ObjectMeta)
+	return n
+}
+
+// ConfigMapMeta is a ConfigMap without its values. ConfigMaps are always converted to a ConfigMapMeta by
+// readers and Change[*corev1.ConfigMap] is rejected by NewInformer.
+type ConfigMapMeta struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Immutable is the immutable field of the ConfigMap.
+	Immutable *bool
+	// Keys describes the keys in Data and BinaryData, sorted by name.
+	Keys []KeyInfo
+}
+
+// NewConfigMapMeta creates a ConfigMapMeta from cm, hashing values with hashKey. cm is not modified.
+func NewConfigMapMeta(cm *corev1.ConfigMap, hashKey []byte) *ConfigMapMeta {
+	if cm == nil {
+		return nil
+	}
+
+	m := &ConfigMapMeta{
+		TypeMeta:   cm.TypeMeta,
+		ObjectMeta: *metaWithoutValues(&cm.ObjectMeta),
+		Immutable:  copyBool(cm.Immutable),
+	}
+
+	keys := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		keys[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		keys[k] = v
+	}
+	m.Keys = keyInfos(keys, hashKey)
+	return m
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *ConfigMapMeta) DeepCopyObject() runtime.Object {
+	if c == nil {
+		return nil
+	}
+	n := &ConfigMapMeta{
+		TypeMeta:  c.TypeMeta,
+		Immutable: copyBool(c.Immutable),
+		Keys:      slices.Clone(c.Keys),
+	}
+	c.ObjectMeta.DeepCopyInto(&n.ObjectMeta)
+	return n
+}
+
+// metaWithoutValues returns a copy of meta with any annotation that can hold values removed.
+func metaWithoutValues(meta *metav1.ObjectMeta) *metav1.ObjectMeta {
+	n := meta.DeepCopy()
+	delete(n.Annotations, LastAppliedAnnotation)
+	return n
+}
+
+// keyInfos returns the KeyInfo for each key, sorted by name.
+func keyInfos(keys map[string][]byte, hashKey []byte) []KeyInfo {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for k, v := range keys {
+		mac := hmac.New(sha256.New, hashKey)
+		mac.Write(v)
+		infos = append(infos, KeyInfo{Name: k, Hash: hex.EncodeToString(mac.Sum(nil)), Size: len(v)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	n := *b
+	return &n
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	_ K8Object = (*SecretMeta)(nil)
+	_ K8Object = (*ConfigMapMeta)(nil)
+)
+
+func TestNewSecretMeta(t *testing.T) {
+	t.Parallel()
+
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "secret",
+			Annotations: map[string]string{LastAppliedAnnotation: "values", "keep": "me"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"b": []byte("12345"), "a": []byte("1")},
+	}
+
+	m := NewSecretMeta(s, []byte("key"))
+	if _, ok := m.Annotations[LastAppliedAnnotation]; ok {
+		t.Errorf("TestNewSecretMeta: last applied annotation was not removed")
+	}
+	if m.Annotations["keep"] != "me" {
+		t.Errorf("TestNewSecretMeta: other annotations were removed")
+	}
+	if _, ok := s.Annotations[LastAppliedAnnotation]; !ok {
+		t.Errorf("TestNewSecretMeta: the source Secret was modified")
+	}
+	if len(m.Keys) != 2 || m.Keys[0].Name != "a" || m.Keys[1].Name != "b" || m.Keys[1].Size != 5 {
+		t.Errorf("TestNewSecretMeta: got Keys %+v, want a(1) and b(5)", m.Keys)
+	}
+
+	other := NewSecretMeta(s, []byte("other key"))
+	if m.Keys[0].Hash == other.Keys[0].Hash {
+		t.Errorf("TestNewSecretMeta: hashes with different keys are the same")
+	}
+	again := NewSecretMeta(s, []byte("key"))
+	if m.Keys[0].Hash != again.Keys[0].Hash {
+		t.Errorf("TestNewSecretMeta: hashes with the same key are different")
+	}
+}
+
+func TestNewInformerRejectsValues(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewInformer(Change[*corev1.Secret]{ChangeType: CTAdd, ObjectType: OTSecret, New: &corev1.Secret{}}); err == nil {
+		t.Errorf("TestNewInformerRejectsValues: got err == nil for a *corev1.Secret, want err != nil")
+	}
+	if _, err := NewInformer(Change[*corev1.ConfigMap]{ChangeType: CTAdd, ObjectType: OTConfigMap, New: &corev1.ConfigMap{}}); err == nil {
+		t.Errorf("TestNewInformerRejectsValues: got err == nil for a *corev1.ConfigMap, want err != nil")
+	}
+	if _, err := NewInformer(MustNewChange(&SecretMeta{}, nil, CTAdd)); err != nil {
+		t.Errorf("TestNewInformerRejectsValues: got err == %v for a *SecretMeta, want err == nil", err)
+	}
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// defaultServiceAccount is the service account a pod uses when spec.serviceAccountName is not set.
+const defaultServiceAccount = "default"
+
+// AutomountServiceAccountToken reports if sa automounts its token into pods. This is the default when
+// automountServiceAccountToken is not set. Pods can still override this in their own spec.
+func AutomountServiceAccountToken(sa *corev1.ServiceAccount) bool {
+	if sa == nil || sa.AutomountServiceAccountToken == nil {
+		return true
+	}
+	return *sa.AutomountServiceAccountToken
+}
+
+// ServiceAccountFlags returns the ChangeFlags for a change to a ServiceAccount. CFAutomountTokenEnabled is
+// set when an update turns on automounting of the token.
+func ServiceAccountFlags(c Change[*corev1.ServiceAccount]) ChangeFlag {
+	if c.ChangeType != CTUpdate {
+		return 0
+	}
+	if !AutomountServiceAccountToken(c.Old) && AutomountServiceAccountToken(c.New) {
+		return CFAutomountTokenEnabled
+	}
+	return 0
+}
+
+// LegacyTokenServiceAccount returns the service account that s is a legacy token Secret for. false is returned
+// if s is not a legacy token Secret.
+func LegacyTokenServiceAccount(s *SecretMeta) (types.NamespacedName, bool) {
+	if s == nil || s.Type != corev1.SecretTypeServiceAccountToken {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: s.Namespace, Name: s.Annotations[corev1.ServiceAccountNameKey]}, true
+}
+
+// SecretFlags returns the ChangeFlags for a change to a Secret. CFLegacyServiceAccountToken is set when the
+// Secret is a legacy token Secret.
+func SecretFlags(c Change[*SecretMeta]) ChangeFlag {
+	if _, ok := LegacyTokenServiceAccount(c.latest()); ok {
+		return CFLegacyServiceAccountToken
+	}
+	return 0
+}
+
+// PodServiceAccount returns the service account pod runs as. It is empty if pod is nil.
+func PodServiceAccount(pod *corev1.Pod) types.NamespacedName {
+	if pod == nil {
+		return types.NamespacedName{}
+	}
+	name := pod.Spec.ServiceAccountName
+	if name == "" {
+		name = defaultServiceAccount
+	}
+	return types.NamespacedName{Namespace: pod.Namespace, Name: name}
+}
+
+// ServiceAccountPods returns the pods from pods that run as the service account sa. pods is usually from the
+// pods a processor has seen from the informers reader.
+func ServiceAccountPods(sa types.NamespacedName, pods []*corev1.Pod) []*corev1.Pod {
+	var out []*corev1.Pod
+	for _, pod := range pods {
+		if pod != nil && PodServiceAccount(pod) == sa {
+			out = append(out, pod)
+		}
+	}
+	return out
+}
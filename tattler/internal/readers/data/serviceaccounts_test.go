@@ -0,0 +1,149 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestServiceAccountFlags(t *testing.T) {
+	t.Parallel()
+
+	on, off := true, false
+	sa := func(automount *bool) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{AutomountServiceAccountToken: automount}
+	}
+
+	tests := []struct {
+		name   string
+		change Change[*corev1.ServiceAccount]
+		want   ChangeFlag
+	}{
+		{
+			name:   "Add",
+			change: Change[*corev1.ServiceAccount]{ChangeType: CTAdd, New: sa(nil)},
+		},
+		{
+			name:   "Off to default",
+			change: Change[*corev1.ServiceAccount]{ChangeType: CTUpdate, Old: sa(&off), New: sa(nil)},
+			want:   CFAutomountTokenEnabled,
+		},
+		{
+			name:   "Off to on",
+			change: Change[*corev1.ServiceAccount]{ChangeType: CTUpdate, Old: sa(&off), New: sa(&on)},
+			want:   CFAutomountTokenEnabled,
+		},
+		{
+			name:   "Default to off",
+			change: Change[*corev1.ServiceAccount]{ChangeType: CTUpdate, Old: sa(nil), New: sa(&off)},
+		},
+	}
+
+	for _, test := range tests {
+		if got := ServiceAccountFlags(test.change); got != test.want {
+			t.Errorf("TestServiceAccountFlags(%s): got %#x, want %#x", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSecretFlags(t *testing.T) {
+	t.Parallel()
+
+	token := &SecretMeta{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: "builder"},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+	opaque := &SecretMeta{Type: corev1.SecretTypeOpaque}
+
+	if got := SecretFlags(Change[*SecretMeta]{ChangeType: CTAdd, New: token}); got != CFLegacyServiceAccountToken {
+		t.Errorf("TestSecretFlags(token): got %#x, want %#x", got, CFLegacyServiceAccountToken)
+	}
+	if got := SecretFlags(Change[*SecretMeta]{ChangeType: CTAdd, New: opaque}); got != 0 {
+		t.Errorf("TestSecretFlags(opaque): got %#x, want 0", got)
+	}
+
+	sa, ok := LegacyTokenServiceAccount(token)
+	if !ok || sa != (types.NamespacedName{Namespace: "ns", Name: "builder"}) {
+		t.Errorf("TestSecretFlags(LegacyTokenServiceAccount): got %v, %v, want ns/builder, true", sa, ok)
+	}
+}
+
+func TestPodServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want types.NamespacedName
+	}{
+		{
+			name: "default service account",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}},
+			want: types.NamespacedName{Namespace: "ns", Name: "default"},
+		},
+		{
+			name: "named service account",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "builder"},
+			},
+			want: types.NamespacedName{Namespace: "ns", Name: "builder"},
+		},
+		{name: "nil pod"},
+	}
+
+	for _, test := range tests {
+		if got := PodServiceAccount(test.pod); got != test.want {
+			t.Errorf("TestPodServiceAccount(%s): got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestServiceAccountPods(t *testing.T) {
+	t.Parallel()
+
+	defaultPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "default"}}
+	builderPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "builder"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "builder"},
+	}
+	otherNS := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "builder"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "builder"},
+	}
+	pods := []*corev1.Pod{defaultPod, builderPod, otherNS, nil}
+
+	tests := []struct {
+		name string
+		sa   types.NamespacedName
+		want []*corev1.Pod
+	}{
+		{
+			name: "default service account",
+			sa:   types.NamespacedName{Namespace: "ns", Name: "default"},
+			want: []*corev1.Pod{defaultPod},
+		},
+		{
+			name: "named service account",
+			sa:   types.NamespacedName{Namespace: "ns", Name: "builder"},
+			want: []*corev1.Pod{builderPod},
+		},
+		{
+			name: "no pods",
+			sa:   types.NamespacedName{Namespace: "ns", Name: "none"},
+		},
+	}
+
+	for _, test := range tests {
+		got := ServiceAccountPods(test.sa, pods)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestServiceAccountPods(%s): -want/+got\n%s", test.name, diff)
+		}
+	}
+}
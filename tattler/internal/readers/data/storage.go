@@ -0,0 +1,65 @@
+package data
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PersistentVolumeClaimRef returns the namespace and name of the PersistentVolumeClaim that pv is bound to
+// through its claimRef. false is returned if pv is not bound to a claim.
+func PersistentVolumeClaimRef(pv *corev1.PersistentVolume) (types.NamespacedName, bool) {
+	if pv == nil || pv.Spec.ClaimRef == nil {
+		return types.NamespacedName{}, false
+	}
+	ref := pv.Spec.ClaimRef
+	if ref.Kind != "" && ref.Kind != "PersistentVolumeClaim" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, true
+}
+
+// PodClaims returns the namespace and name of each PersistentVolumeClaim that pod's volumes use. This
+// includes the claims created for generic ephemeral volumes, which are named <pod name>-<volume name>.
+func PodClaims(pod *corev1.Pod) []types.NamespacedName {
+	if pod == nil {
+		return nil
+	}
+
+	var claims []types.NamespacedName
+	for _, v := range pod.Spec.Volumes {
+		switch {
+		case v.PersistentVolumeClaim != nil:
+			claims = append(claims, types.NamespacedName{Namespace: pod.Namespace, Name: v.PersistentVolumeClaim.ClaimName})
+		case v.Ephemeral != nil:
+			claims = append(claims, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name + "-" + v.Name})
+		}
+	}
+	return claims
+}
+
+// PersistentVolumePods resolves pv to its bound claim and returns the pods from pods that use that claim.
+// pods is usually from the pods a processor has seen from the informers reader.
+func PersistentVolumePods(pv *corev1.PersistentVolume, pods []*corev1.Pod) []*corev1.Pod {
+	claim, ok := PersistentVolumeClaimRef(pv)
+	if !ok {
+		return nil
+	}
+	return ClaimPods(claim, pods)
+}
+
+// ClaimPods returns the pods from pods that use the PersistentVolumeClaim claim.
+func ClaimPods(claim types.NamespacedName, pods []*corev1.Pod) []*corev1.Pod {
+	var out []*corev1.Pod
+	for _, pod := range pods {
+		if pod == nil || pod.Namespace != claim.Namespace {
+			continue
+		}
+		for _, c := range PodClaims(pod) {
+			if c == claim {
+				out = append(out, pod)
+				break
+			}
+		}
+	}
+	return out
+}
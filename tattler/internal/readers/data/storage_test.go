@@ -0,0 +1,93 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func claimPod(ns, name string, volumes ...corev1.Volume) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec:       corev1.PodSpec{Volumes: volumes},
+	}
+}
+
+func claimVolume(claim string) corev1.Volume {
+	return corev1.Volume{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+		},
+	}
+}
+
+func TestPodClaims(t *testing.T) {
+	t.Parallel()
+
+	pod := claimPod(
+		"ns",
+		"pod",
+		claimVolume("claim"),
+		corev1.Volume{Name: "scratch", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{}}},
+		corev1.Volume{Name: "empty", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	)
+
+	want := []types.NamespacedName{
+		{Namespace: "ns", Name: "claim"},
+		{Namespace: "ns", Name: "pod-scratch"},
+	}
+	if diff := pretty.Compare(want, PodClaims(pod)); diff != "" {
+		t.Errorf("TestPodClaims: -want/+got\n%s", diff)
+	}
+}
+
+func TestPersistentVolumePods(t *testing.T) {
+	t.Parallel()
+
+	uses := claimPod("ns", "uses", claimVolume("claim"))
+	otherClaim := claimPod("ns", "otherClaim", claimVolume("other"))
+	otherNS := claimPod("other", "otherNS", claimVolume("claim"))
+	pods := []*corev1.Pod{uses, otherClaim, otherNS, nil}
+
+	tests := []struct {
+		name string
+		pv   *corev1.PersistentVolume
+		want []*corev1.Pod
+	}{
+		{
+			name: "pv is nil",
+		},
+		{
+			name: "pv is not bound",
+			pv:   &corev1.PersistentVolume{},
+		},
+		{
+			name: "claimRef is not a PersistentVolumeClaim",
+			pv: &corev1.PersistentVolume{
+				Spec: corev1.PersistentVolumeSpec{
+					ClaimRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "claim"},
+				},
+			},
+		},
+		{
+			name: "pv is bound",
+			pv: &corev1.PersistentVolume{
+				Spec: corev1.PersistentVolumeSpec{
+					ClaimRef: &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: "ns", Name: "claim"},
+				},
+			},
+			want: []*corev1.Pod{uses},
+		},
+	}
+
+	for _, test := range tests {
+		got := PersistentVolumePods(test.pv, pods)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestPersistentVolumePods(%s): -want/+got\n%s", test.name, diff)
+		}
+	}
+}
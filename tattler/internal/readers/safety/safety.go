@@ -25,15 +25,21 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strings"
 
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // Secrets provide a set of safety checks for exposing Kubernetes resources to the outside world.
-// It currently scrubs sensitive information from informers that have pods with containers that have
-// environment variables with names that match a secret regular expression.
+// It currently scrubs sensitive information from informers that have pods or workloads with pod templates
+// with containers that have environment variables or command arguments with names that match a secret
+// regular expression.
+// It also removes any Secret or ConfigMap values that reach it.
 type Secrets struct {
 	in  <-chan data.Entry
 	out chan data.Entry
@@ -87,6 +93,10 @@ func (s *Secrets) run() {
 // entryRouter routes an entry to the appropriate scrubber. If there is no scrubber for the entry,
 // it is passed through.
 func (s *Secrets) entryRouter(e data.Entry) {
+	if e.Type != data.ETUnknown {
+		s.scrubValues(e.Object())
+	}
+
 	switch e.Type {
 	case data.ETInformer:
 		err := s.informerScrubber(e)
@@ -106,23 +116,146 @@ func (s *Secrets) informerScrubber(e data.Entry) error {
 	}
 
 	switch i.Type {
+	// Updates and deletes carry the old object as well, which is scrubbed the same as the new one.
 	case data.OTPod:
-		p, ok := i.Object().(*corev1.Pod)
-		if !ok {
+		c, err := i.Pod()
+		if err != nil {
 			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to pod: %v", err)
 		}
-		s.scrubPod(p)
+		s.scrubPod(c.Old)
+		s.scrubPod(c.New)
+	case data.OTDeployment:
+		c, err := i.Deployment()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to deployment")
+		}
+		for _, d := range []*appsv1.Deployment{c.Old, c.New} {
+			if d != nil {
+				s.scrubPodTemplate(&d.Spec.Template)
+			}
+		}
+	case data.OTReplicaSet:
+		c, err := i.ReplicaSet()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to replica set")
+		}
+		for _, rs := range []*appsv1.ReplicaSet{c.Old, c.New} {
+			if rs != nil {
+				s.scrubPodTemplate(&rs.Spec.Template)
+			}
+		}
+	case data.OTStatefulSet:
+		c, err := i.StatefulSet()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to stateful set")
+		}
+		for _, ss := range []*appsv1.StatefulSet{c.Old, c.New} {
+			if ss != nil {
+				s.scrubPodTemplate(&ss.Spec.Template)
+			}
+		}
+	case data.OTDaemonSet:
+		c, err := i.DaemonSet()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to daemon set")
+		}
+		for _, ds := range []*appsv1.DaemonSet{c.Old, c.New} {
+			if ds != nil {
+				s.scrubPodTemplate(&ds.Spec.Template)
+			}
+		}
+	case data.OTJob:
+		c, err := i.Job()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to job")
+		}
+		for _, j := range []*batchv1.Job{c.Old, c.New} {
+			if j != nil {
+				s.scrubPodTemplate(&j.Spec.Template)
+			}
+		}
+	case data.OTCronJob:
+		c, err := i.CronJob()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: error casting object to cron job")
+		}
+		for _, cj := range []*batchv1.CronJob{c.Old, c.New} {
+			if cj != nil {
+				s.scrubPodTemplate(&cj.Spec.JobTemplate.Spec.Template)
+			}
+		}
+	case data.OTSecret:
+		c, err := i.Secret()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: secret entry does not hold a SecretMeta")
+		}
+		s.scrubValues(c.Old)
+		s.scrubValues(c.New)
+	case data.OTConfigMap:
+		c, err := i.ConfigMap()
+		if err != nil {
+			return fmt.Errorf("safety.Secrets.informerRouter: config map entry does not hold a ConfigMapMeta")
+		}
+		s.scrubValues(c.Old)
+		s.scrubValues(c.New)
 	}
 	return nil
 }
 
-// scrubPod scrubs sensitive information from a pod.
+// scrubValues removes the values of Secrets and ConfigMaps. Readers never emit values, so this is
+// a second line of defense. Other objects are left alone.
+func (s *Secrets) scrubValues(obj runtime.Object) {
+	switch v := obj.(type) {
+	case *data.SecretMeta:
+		if v != nil {
+			delete(v.Annotations, data.LastAppliedAnnotation)
+		}
+	case *data.ConfigMapMeta:
+		if v != nil {
+			delete(v.Annotations, data.LastAppliedAnnotation)
+		}
+	case *corev1.Secret:
+		if v != nil {
+			v.Data = nil
+			v.StringData = nil
+			delete(v.Annotations, data.LastAppliedAnnotation)
+		}
+	case *corev1.ConfigMap:
+		if v != nil {
+			v.Data = nil
+			v.BinaryData = nil
+			delete(v.Annotations, data.LastAppliedAnnotation)
+		}
+	}
+}
+
+// scrubPod scrubs sensitive information from a pod. p may be nil.
 func (s *Secrets) scrubPod(p *corev1.Pod) {
-	spec := p.Spec
+	if p == nil {
+		return
+	}
+	s.scrubPodSpec(&p.Spec)
+}
+
+// scrubPodTemplate scrubs sensitive information from the pod template of a workload.
+func (s *Secrets) scrubPodTemplate(t *corev1.PodTemplateSpec) {
+	s.scrubPodSpec(&t.Spec)
+}
+
+// scrubPodSpec scrubs sensitive information from the containers, init containers and ephemeral containers
+// of a pod spec.
+func (s *Secrets) scrubPodSpec(spec *corev1.PodSpec) {
 	for i, cont := range spec.Containers {
 		spec.Containers[i] = s.scrubContainer(cont)
 	}
-	p.Spec = spec
+	for i, cont := range spec.InitContainers {
+		spec.InitContainers[i] = s.scrubContainer(cont)
+	}
+	for i := range spec.EphemeralContainers {
+		s.scrubEnv(spec.EphemeralContainers[i].Env)
+		s.scrubArgs(spec.EphemeralContainers[i].Command)
+		s.scrubArgs(spec.EphemeralContainers[i].Args)
+	}
 }
 
 var secretRE = regexp.MustCompile(`(?i)(token|pass|pwd|jwt|hash|secret|bearer|cred|secure|signing|cert|code|key)`)
@@ -130,11 +263,38 @@ var redacted = "REDACTED"
 
 // scrubContainer scrubs sensitive information from a container.
 func (s *Secrets) scrubContainer(c corev1.Container) corev1.Container {
-	for i, ev := range c.Env {
+	s.scrubEnv(c.Env)
+	s.scrubArgs(c.Command)
+	s.scrubArgs(c.Args)
+	return c
+}
+
+// scrubEnv redacts the values of environment variables with names that match secretRE.
+func (s *Secrets) scrubEnv(env []corev1.EnvVar) {
+	for i, ev := range env {
 		if secretRE.MatchString(ev.Name) {
 			ev.Value = redacted
-			c.Env[i] = ev
+			env[i] = ev
+		}
+	}
+}
+
+// scrubArgs redacts the values of arguments with names that match secretRE. "--password=value" and
+// "PASSWORD=value" have the part after the "=" redacted, and "--token value" has the next argument redacted.
+func (s *Secrets) scrubArgs(args []string) {
+	for i := 0; i < len(args); i++ {
+		if name, _, ok := strings.Cut(args[i], "="); ok {
+			if secretRE.MatchString(name) {
+				args[i] = name + "=" + redacted
+			}
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") || !secretRE.MatchString(args[i]) {
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			args[i] = redacted
 		}
 	}
-	return c
 }
@@ -5,7 +5,10 @@ import (
 
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 	"github.com/kylelemons/godebug/pretty"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestScrubInformer(t *testing.T) {
@@ -89,6 +92,127 @@ func TestScrubInformer(t *testing.T) {
 	}
 }
 
+func TestScrubWorkloadTemplates(t *testing.T) {
+	t.Parallel()
+
+	template := func() corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Env: []corev1.EnvVar{
+							{
+								Name:  "DB_PASSWORD",
+								Value: "password123",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     data.Entry
+		template func(data.Informer) corev1.PodTemplateSpec
+	}{
+		{
+			name: "Deployment",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: template()}}, nil, data.CTAdd),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*appsv1.Deployment).Spec.Template
+			},
+		},
+		{
+			name: "ReplicaSet",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(&appsv1.ReplicaSet{Spec: appsv1.ReplicaSetSpec{Template: template()}}, nil, data.CTAdd),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*appsv1.ReplicaSet).Spec.Template
+			},
+		},
+		{
+			name: "StatefulSet",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(&appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: template()}}, nil, data.CTAdd),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*appsv1.StatefulSet).Spec.Template
+			},
+		},
+		{
+			name: "DaemonSet",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(&appsv1.DaemonSet{Spec: appsv1.DaemonSetSpec{Template: template()}}, nil, data.CTAdd),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*appsv1.DaemonSet).Spec.Template
+			},
+		},
+		{
+			name: "Job",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(&batchv1.Job{Spec: batchv1.JobSpec{Template: template()}}, nil, data.CTAdd),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*batchv1.Job).Spec.Template
+			},
+		},
+		{
+			name: "CronJob",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(
+						&batchv1.CronJob{
+							Spec: batchv1.CronJobSpec{
+								JobTemplate: batchv1.JobTemplateSpec{
+									Spec: batchv1.JobSpec{Template: template()},
+								},
+							},
+						},
+						nil,
+						data.CTAdd,
+					),
+				),
+			),
+			template: func(i data.Informer) corev1.PodTemplateSpec {
+				return i.Object().(*batchv1.CronJob).Spec.JobTemplate.Spec.Template
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s := &Secrets{}
+		if err := s.informerScrubber(test.data); err != nil {
+			t.Errorf("TestScrubWorkloadTemplates(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+
+		i, err := test.data.Informer()
+		if err != nil {
+			panic(err)
+		}
+		got := test.template(i).Spec.Containers[0].Env[0].Value
+		if got != "REDACTED" {
+			t.Errorf("TestScrubWorkloadTemplates(%s): got %s, want REDACTED", test.name, got)
+		}
+	}
+}
+
 func TestScrubPod(t *testing.T) {
 	t.Parallel()
 
@@ -188,3 +312,293 @@ func TestScrubContainer(t *testing.T) {
 		}
 	}
 }
+
+func TestScrubValues(t *testing.T) {
+	t.Parallel()
+
+	annotations := func() map[string]string {
+		return map[string]string{data.LastAppliedAnnotation: "value"}
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations()},
+		Data:       map[string][]byte{"key": []byte("value")},
+		StringData: map[string]string{"key": "value"},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations()},
+		Data:       map[string]string{"key": "value"},
+		BinaryData: map[string][]byte{"key": []byte("value")},
+	}
+	secretMeta := &data.SecretMeta{ObjectMeta: metav1.ObjectMeta{Annotations: annotations()}}
+
+	s := &Secrets{}
+	s.scrubValues(secret)
+	s.scrubValues(configMap)
+	s.scrubValues(secretMeta)
+	s.scrubValues((*data.ConfigMapMeta)(nil))
+
+	if secret.Data != nil || secret.StringData != nil || len(secret.Annotations) != 0 {
+		t.Errorf("TestScrubValues: Secret was not scrubbed: %+v", secret)
+	}
+	if configMap.Data != nil || configMap.BinaryData != nil || len(configMap.Annotations) != 0 {
+		t.Errorf("TestScrubValues: ConfigMap was not scrubbed: %+v", configMap)
+	}
+	if len(secretMeta.Annotations) != 0 {
+		t.Errorf("TestScrubValues: SecretMeta was not scrubbed: %+v", secretMeta)
+	}
+}
+
+func TestScrubInformerSecretUpdate(t *testing.T) {
+	t.Parallel()
+
+	meta := func() *data.SecretMeta {
+		return &data.SecretMeta{
+			ObjectMeta: metav1.ObjectMeta{
+				UID:         "uid",
+				Annotations: map[string]string{data.LastAppliedAnnotation: "value"},
+			},
+		}
+	}
+	e := data.MustNewEntry(data.MustNewInformer(data.MustNewChange(meta(), meta(), data.CTUpdate)))
+
+	s := &Secrets{}
+	if err := s.informerScrubber(e); err != nil {
+		t.Fatalf("TestScrubInformerSecretUpdate: got err == %v, want err == nil", err)
+	}
+
+	i, _ := e.Informer()
+	c, _ := i.Secret()
+	if len(c.Old.Annotations) != 0 || len(c.New.Annotations) != 0 {
+		t.Errorf("TestScrubInformerSecretUpdate: got Old(%v) New(%v), want both scrubbed", c.Old.Annotations, c.New.Annotations)
+	}
+}
+
+func TestScrubInformerUpdate(t *testing.T) {
+	t.Parallel()
+
+	env := func() []corev1.EnvVar {
+		return []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "password123"}}
+	}
+	spec := func() corev1.PodSpec {
+		return corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Env: env()}},
+			Containers:     []corev1.Container{{Name: "main", Env: env()}},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug", Env: env()}},
+			},
+		}
+	}
+	// values returns the env values of every container in spec.
+	values := func(spec corev1.PodSpec) []string {
+		var out []string
+		for _, c := range spec.InitContainers {
+			out = append(out, c.Env[0].Value)
+		}
+		for _, c := range spec.Containers {
+			out = append(out, c.Env[0].Value)
+		}
+		for _, c := range spec.EphemeralContainers {
+			out = append(out, c.Env[0].Value)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		data  data.Entry
+		specs func(data.Informer) []corev1.PodSpec
+	}{
+		{
+			name: "Pod",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(
+						&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: spec()},
+						&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: spec()},
+						data.CTUpdate,
+					),
+				),
+			),
+			specs: func(i data.Informer) []corev1.PodSpec {
+				c, _ := i.Pod()
+				return []corev1.PodSpec{c.Old.Spec, c.New.Spec}
+			},
+		},
+		{
+			name: "Deployment",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: spec()}}},
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: spec()}}},
+						data.CTUpdate,
+					),
+				),
+			),
+			specs: func(i data.Informer) []corev1.PodSpec {
+				c, _ := i.Deployment()
+				return []corev1.PodSpec{c.Old.Spec.Template.Spec, c.New.Spec.Template.Spec}
+			},
+		},
+		{
+			name: "Deleted pod",
+			data: data.MustNewEntry(
+				data.MustNewInformer(
+					data.MustNewChange(nil, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: spec()}, data.CTDelete),
+				),
+			),
+			specs: func(i data.Informer) []corev1.PodSpec {
+				c, _ := i.Pod()
+				return []corev1.PodSpec{c.Old.Spec}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s := &Secrets{}
+		if err := s.informerScrubber(test.data); err != nil {
+			t.Errorf("TestScrubInformerUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+
+		i, err := test.data.Informer()
+		if err != nil {
+			panic(err)
+		}
+		for _, spec := range test.specs(i) {
+			want := []string{redacted, redacted, redacted}
+			if diff := pretty.Compare(want, values(spec)); diff != "" {
+				t.Errorf("TestScrubInformerUpdate(%s): -want/+got:\n%s", test.name, diff)
+			}
+		}
+	}
+}
+
+func TestScrubArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "No sensitive information",
+			args: []string{"--verbose", "--output=json", "run", "backup"},
+			want: []string{"--verbose", "--output=json", "run", "backup"},
+		},
+		{
+			name: "Flag with =",
+			args: []string{"--password=hunter2", "--user=admin"},
+			want: []string{"--password=REDACTED", "--user=admin"},
+		},
+		{
+			name: "Flag and value",
+			args: []string{"--token", "abc123", "--user", "admin"},
+			want: []string{"--token", "REDACTED", "--user", "admin"},
+		},
+		{
+			name: "Flag followed by a flag",
+			args: []string{"--insecure", "--user", "admin"},
+			want: []string{"--insecure", "--user", "admin"},
+		},
+		{
+			name: "Flag at the end",
+			args: []string{"run", "--api-key"},
+			want: []string{"run", "--api-key"},
+		},
+		{
+			name: "Environment assignment",
+			args: []string{"env", "DB_PASSWORD=hunter2", "backup"},
+			want: []string{"env", "DB_PASSWORD=REDACTED", "backup"},
+		},
+	}
+
+	for _, test := range tests {
+		s := &Secrets{}
+		s.scrubArgs(test.args)
+
+		if diff := pretty.Compare(test.want, test.args); diff != "" {
+			t.Errorf("TestScrubArgs(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestScrubJobUpdate(t *testing.T) {
+	t.Parallel()
+
+	template := func() corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Command: []string{"backup", "--password=hunter2"},
+						Args:    []string{"--token", "abc123"},
+						Env:     []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "password123"}},
+					},
+				},
+			},
+		}
+	}
+	want := corev1.Container{
+		Command: []string{"backup", "--password=REDACTED"},
+		Args:    []string{"--token", "REDACTED"},
+		Env:     []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "REDACTED"}},
+	}
+	job := func() *batchv1.Job {
+		return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{UID: "uid"}, Spec: batchv1.JobSpec{Template: template()}}
+	}
+	cronJob := func() *batchv1.CronJob {
+		return &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{UID: "uid"},
+			Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template()}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		data       data.Entry
+		containers func(data.Informer) []corev1.Container
+	}{
+		{
+			name: "Job",
+			data: data.MustNewEntry(data.MustNewInformer(data.MustNewChange(job(), job(), data.CTUpdate))),
+			containers: func(i data.Informer) []corev1.Container {
+				c, _ := i.Job()
+				return []corev1.Container{c.Old.Spec.Template.Spec.Containers[0], c.New.Spec.Template.Spec.Containers[0]}
+			},
+		},
+		{
+			name: "CronJob",
+			data: data.MustNewEntry(data.MustNewInformer(data.MustNewChange(cronJob(), cronJob(), data.CTUpdate))),
+			containers: func(i data.Informer) []corev1.Container {
+				c, _ := i.CronJob()
+				return []corev1.Container{
+					c.Old.Spec.JobTemplate.Spec.Template.Spec.Containers[0],
+					c.New.Spec.JobTemplate.Spec.Template.Spec.Containers[0],
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s := &Secrets{}
+		if err := s.informerScrubber(test.data); err != nil {
+			t.Errorf("TestScrubJobUpdate(%s): got err == %v, want err == nil", test.name, err)
+			continue
+		}
+
+		i, err := test.data.Informer()
+		if err != nil {
+			panic(err)
+		}
+		for _, got := range test.containers(i) {
+			if diff := pretty.Compare(want, got); diff != "" {
+				t.Errorf("TestScrubJobUpdate(%s): -want/+got:\n%s", test.name, diff)
+			}
+		}
+	}
+}
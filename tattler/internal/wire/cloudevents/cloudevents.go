@@ -0,0 +1,484 @@
+/*
+Package cloudevents encodes data.Entry values as CloudEvents 1.0 for HTTP, in structured, binary or batched
+content mode.
+
+An Entry maps to a CloudEvent as:
+
+	id:              UID/resourceVersion/<added|updated|deleted>/<origin> of the object. The resourceVersion is
+	                 left out if the object has none, the origin if the reader does not track it, and the
+	                 last two for entries that are not changes. A delete recovered from a tombstone has the
+	                 resourceVersion of the update before it, so the change type keeps their ids apart
+	source:          the Entry's Cluster, see WithSource()
+	specversion:     1.0
+	type:            io.tattler.<object type>.<added|updated|deleted>, such as io.tattler.pod.updated. Entries that
+	                 are not changes are io.tattler.<entry type>, such as io.tattler.synccomplete
+	subject:         namespace/name of the object, or name if it is cluster scoped
+	time:            the Entry's Observed
+	datacontenttype: the content type of the wire.Format
+	data:            the Entry encoded with the wire package
+
+Usage:
+
+	enc, err := cloudevents.New(cloudevents.WithDataFormat(wire.FProto))
+	if err != nil {
+		// Do something
+	}
+
+	msgs, err := enc.Batches(cloudevents.MBatch, batches)
+	if err != nil {
+		// Do something
+	}
+	for _, msg := range msgs {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Body))
+		...
+		maps.Copy(req.Header, msg.Header)
+	}
+*/
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	// SpecVersion is the CloudEvents version events are encoded in.
+	SpecVersion = "1.0"
+	// TypePrefix is the prefix of every event type.
+	TypePrefix = "io.tattler"
+
+	// ContentTypeStructured is the content type of a structured mode message.
+	ContentTypeStructured = "application/cloudevents+json"
+	// ContentTypeBatch is the content type of a batched mode message.
+	ContentTypeBatch = "application/cloudevents-batch+json"
+
+	// defaultSource is the source of entries without a Cluster.
+	defaultSource = "tattler"
+)
+
+//go:generate stringer -type=Mode -linecomment
+
+// Mode is a CloudEvents HTTP content mode.
+type Mode uint8
+
+const (
+	// MUnknown indicates a bug in the code.
+	MUnknown Mode = 0 // Unknown
+	// MStructured encodes the whole event, attributes and data, as the JSON body.
+	MStructured Mode = 1 // Structured
+	// MBinary puts the attributes in ce- headers and the data in the body.
+	MBinary Mode = 2 // Binary
+	// MBatch encodes many events as a JSON array of structured events.
+	MBatch Mode = 3 // Batch
+)
+
+// Event is a CloudEvent.
+type Event struct {
+	// ID identifies the event. Source and ID are unique for each distinct event.
+	ID string
+	// Source identifies where the event happened.
+	Source string
+	// SpecVersion is the CloudEvents version.
+	SpecVersion string
+	// Type is the type of the event.
+	Type string
+	// Subject is the object the event is about, in the context of Source.
+	Subject string
+	// Time is when the event happened.
+	Time time.Time
+	// DataContentType is the content type of Data.
+	DataContentType string
+	// Data is the event data.
+	Data []byte
+}
+
+// Entry decodes the data.Entry in Data.
+func (ev Event) Entry() (data.Entry, error) {
+	f, err := formatOf(ev.DataContentType)
+	if err != nil {
+		return data.Entry{}, err
+	}
+	return wire.Unmarshal(f, ev.Data)
+}
+
+// structured is the JSON event format. Data is used if the data is JSON, otherwise DataBase64.
+type structured struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. This is the CloudEvents JSON event format.
+func (ev Event) MarshalJSON() ([]byte, error) {
+	s := structured{
+		ID:              ev.ID,
+		Source:          ev.Source,
+		SpecVersion:     ev.SpecVersion,
+		Type:            ev.Type,
+		Subject:         ev.Subject,
+		DataContentType: ev.DataContentType,
+	}
+	if !ev.Time.IsZero() {
+		t := ev.Time.UTC()
+		s.Time = &t
+	}
+	if ev.Data != nil {
+		if isJSON(ev.DataContentType) {
+			s.Data = ev.Data
+		} else {
+			s.DataBase64 = base64.StdEncoding.EncodeToString(ev.Data)
+		}
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ev *Event) UnmarshalJSON(b []byte) error {
+	var s structured
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*ev = Event{
+		ID:              s.ID,
+		Source:          s.Source,
+		SpecVersion:     s.SpecVersion,
+		Type:            s.Type,
+		Subject:         s.Subject,
+		DataContentType: s.DataContentType,
+	}
+	if s.Time != nil {
+		ev.Time = *s.Time
+	}
+	switch {
+	case s.DataBase64 != "":
+		d, err := base64.StdEncoding.DecodeString(s.DataBase64)
+		if err != nil {
+			return fmt.Errorf("invalid data_base64: %w", err)
+		}
+		ev.Data = d
+	case s.Data != nil:
+		ev.Data = []byte(s.Data)
+	}
+	return ev.validate()
+}
+
+// validate checks the required attributes are set.
+func (ev Event) validate() error {
+	switch {
+	case ev.ID == "":
+		return fmt.Errorf("event is missing id")
+	case ev.Source == "":
+		return fmt.Errorf("event is missing source")
+	case ev.Type == "":
+		return fmt.Errorf("event is missing type")
+	case ev.SpecVersion != SpecVersion:
+		return fmt.Errorf("event has specversion %q, want %q", ev.SpecVersion, SpecVersion)
+	}
+	return nil
+}
+
+// Message is the headers and body of an HTTP request or response carrying CloudEvents.
+type Message struct {
+	// Header holds the Content-Type and, in binary mode, the ce- attribute headers.
+	Header http.Header
+	// Body is the HTTP body.
+	Body []byte
+}
+
+// Encoder encodes data.Entry values as CloudEvents.
+type Encoder struct {
+	source string
+	format wire.Format
+}
+
+// Option is an option for New().
+type Option func(*Encoder) error
+
+// WithSource sets the source of every event, instead of the Entry's Cluster.
+func WithSource(source string) Option {
+	return func(e *Encoder) error {
+		if source == "" {
+			return fmt.Errorf("source cannot be empty")
+		}
+		e.source = source
+		return nil
+	}
+}
+
+// WithDataFormat sets the wire.Format used for event data. Defaults to wire.FJSON.
+func WithDataFormat(f wire.Format) Option {
+	return func(e *Encoder) error {
+		switch f {
+		case wire.FJSON, wire.FProto:
+		default:
+			return fmt.Errorf("unsupported data format %v", f)
+		}
+		e.format = f
+		return nil
+	}
+}
+
+// New creates a new Encoder.
+func New(options ...Option) (*Encoder, error) {
+	e := &Encoder{format: wire.FJSON}
+	for _, o := range options {
+		if err := o(e); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Event converts e to an Event.
+func (enc *Encoder) Event(e data.Entry) (Event, error) {
+	b, err := wire.Marshal(enc.format, e)
+	if err != nil {
+		return Event{}, err
+	}
+
+	ev := Event{
+		ID:              string(e.UID()),
+		Source:          enc.source,
+		SpecVersion:     SpecVersion,
+		Type:            eventType(e),
+		Time:            e.Observed,
+		DataContentType: enc.format.ContentType(),
+		Data:            b,
+	}
+	if ev.Source == "" {
+		ev.Source = e.Cluster
+	}
+	if ev.Source == "" {
+		ev.Source = defaultSource
+	}
+	if obj := e.Object(); obj != nil {
+		if m, err := meta.Accessor(obj); err == nil {
+			ev.Subject = m.GetName()
+			if m.GetNamespace() != "" {
+				ev.Subject = m.GetNamespace() + "/" + m.GetName()
+			}
+			if rv := m.GetResourceVersion(); rv != "" {
+				ev.ID += "/" + rv
+			}
+		}
+	}
+	if ac, ok := change(e); ok {
+		ev.ID += "/" + verbs[ac.ChangeType]
+		if e.Origin != data.ORUnknown {
+			ev.ID += "/" + strings.ToLower(e.Origin.String())
+		}
+	}
+	if err := ev.validate(); err != nil {
+		return Event{}, err
+	}
+	return ev, nil
+}
+
+// Entry encodes e as a Message in mode m. MBatch is a batch of one event.
+func (enc *Encoder) Entry(m Mode, e data.Entry) (Message, error) {
+	ev, err := enc.Event(e)
+	if err != nil {
+		return Message{}, err
+	}
+	switch m {
+	case MStructured:
+		return structuredMessage(ev)
+	case MBinary:
+		return binaryMessage(ev), nil
+	case MBatch:
+		return batchMessage([]Event{ev})
+	}
+	return Message{}, fmt.Errorf("unknown Mode: %v", m)
+}
+
+// Batches encodes the entries in b, in the order wire.Entries() returns them. MBatch returns a single
+// Message, the other modes a Message per entry. An empty b returns no messages.
+func (enc *Encoder) Batches(m Mode, b batching.Batches) ([]Message, error) {
+	entries := wire.Entries(b)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	events := make([]Event, 0, len(entries))
+	for _, e := range entries {
+		ev, err := enc.Event(e)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	switch m {
+	case MStructured:
+		msgs := make([]Message, 0, len(events))
+		for _, ev := range events {
+			msg, err := structuredMessage(ev)
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, msg)
+		}
+		return msgs, nil
+	case MBinary:
+		msgs := make([]Message, 0, len(events))
+		for _, ev := range events {
+			msgs = append(msgs, binaryMessage(ev))
+		}
+		return msgs, nil
+	case MBatch:
+		msg, err := batchMessage(events)
+		if err != nil {
+			return nil, err
+		}
+		return []Message{msg}, nil
+	}
+	return nil, fmt.Errorf("unknown Mode: %v", m)
+}
+
+// Decode decodes the events in an HTTP message in any mode, using the Content-Type to tell which.
+func Decode(h http.Header, body []byte) ([]Event, error) {
+	ct, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch ct {
+	case ContentTypeStructured:
+		var ev Event
+		if err := json.Unmarshal(body, &ev); err != nil {
+			return nil, err
+		}
+		return []Event{ev}, nil
+	case ContentTypeBatch:
+		var events []Event
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+
+	ev := Event{
+		ID:              h.Get("ce-id"),
+		Source:          h.Get("ce-source"),
+		SpecVersion:     h.Get("ce-specversion"),
+		Type:            h.Get("ce-type"),
+		Subject:         h.Get("ce-subject"),
+		DataContentType: h.Get("Content-Type"),
+		Data:            body,
+	}
+	if t := h.Get("ce-time"); t != "" {
+		var err error
+		if ev.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return nil, fmt.Errorf("invalid ce-time: %w", err)
+		}
+	}
+	if err := ev.validate(); err != nil {
+		return nil, err
+	}
+	return []Event{ev}, nil
+}
+
+func structuredMessage(ev Event) (Message, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Header: http.Header{"Content-Type": {ContentTypeStructured}}, Body: b}, nil
+}
+
+func binaryMessage(ev Event) Message {
+	h := http.Header{}
+	h.Set("ce-id", ev.ID)
+	h.Set("ce-source", ev.Source)
+	h.Set("ce-specversion", ev.SpecVersion)
+	h.Set("ce-type", ev.Type)
+	if ev.Subject != "" {
+		h.Set("ce-subject", ev.Subject)
+	}
+	if !ev.Time.IsZero() {
+		h.Set("ce-time", ev.Time.UTC().Format(time.RFC3339Nano))
+	}
+	h.Set("Content-Type", ev.DataContentType)
+	return Message{Header: h, Body: ev.Data}
+}
+
+func batchMessage(events []Event) (Message, error) {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Header: http.Header{"Content-Type": {ContentTypeBatch}}, Body: b}, nil
+}
+
+// eventType returns the CloudEvents type of e.
+func eventType(e data.Entry) string {
+	ac, ok := change(e)
+	if !ok {
+		return TypePrefix + "." + strings.ToLower(e.Type.String())
+	}
+	return TypePrefix + "." + strings.ToLower(ac.ObjectType.String()) + "." + verbs[ac.ChangeType]
+}
+
+// change returns the change held by e, if it holds one.
+func change(e data.Entry) (data.AnyChange, bool) {
+	var ac data.AnyChange
+	var err error
+	switch e.Type {
+	case data.ETInformer:
+		var i data.Informer
+		if i, err = e.Informer(); err == nil {
+			ac, err = i.Change()
+		}
+	case data.ETPersistentVolume:
+		var pv data.PersistentVolume
+		if pv, err = e.PersistentVolume(); err == nil {
+			ac, err = pv.Change()
+		}
+	case data.ETEvent:
+		var ev data.Event
+		if ev, err = e.Event(); err == nil {
+			ac, err = ev.Change()
+		}
+	default:
+		return data.AnyChange{}, false
+	}
+	return ac, err == nil
+}
+
+// verbs is the last part of the event type for each ChangeType.
+var verbs = map[data.ChangeType]string{
+	data.CTUnknown: "unknown",
+	data.CTAdd:     "added",
+	data.CTUpdate:  "updated",
+	data.CTDelete:  "deleted",
+}
+
+// formatOf returns the wire.Format of a datacontenttype.
+func formatOf(contentType string) (wire.Format, error) {
+	ct, _, _ := mime.ParseMediaType(contentType)
+	for _, f := range []wire.Format{wire.FJSON, wire.FProto} {
+		if ct == f.ContentType() {
+			return f, nil
+		}
+	}
+	return wire.FUnknown, fmt.Errorf("data content type %q is not a wire.Format", contentType)
+}
+
+// isJSON reports if contentType is JSON, which the JSON event format puts in data rather than data_base64.
+func isJSON(contentType string) bool {
+	ct, _, _ := mime.ParseMediaType(contentType)
+	return ct == "application/json" || strings.HasSuffix(ct, "+json") || ct == "text/json"
+}
@@ -0,0 +1,326 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+	"github.com/element-of-surprise/auditARG/tattler/internal/wire"
+
+	"github.com/kylelemons/godebug/pretty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var observed = time.Date(2024, 5, 1, 12, 30, 15, 123456789, time.UTC)
+
+func newPod(uid, rv string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pod-" + uid,
+			Namespace:       "default",
+			UID:             types.UID(uid),
+			ResourceVersion: rv,
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+	}
+}
+
+func entry(sd data.SourceData, cluster string, seq uint64) data.Entry {
+	e := data.MustNewEntry(sd)
+	e.Origin = data.ORWatch
+	e.Observed = observed
+	e.Reader = "informers"
+	e.Cluster = cluster
+	e.Seq = seq
+	return e
+}
+
+var (
+	podUpdate = entry(
+		data.MustNewInformer(data.MustNewChange(newPod("1", "11"), newPod("1", "10"), data.CTUpdate)),
+		"cluster-a",
+		1,
+	)
+	nodeAdd = entry(
+		data.MustNewInformer(data.MustNewChange(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "node", ResourceVersion: "5"}},
+			nil,
+			data.CTAdd,
+		)),
+		"cluster-a",
+		2,
+	)
+	syncComplete = entry(data.NewSyncComplete("informers", observed), "", 3)
+)
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []Option
+		entry   data.Entry
+		want    Event
+	}{
+		{
+			name:  "Namespaced update",
+			entry: podUpdate,
+			want: Event{
+				ID:              "1/11/updated/watch",
+				Source:          "cluster-a",
+				SpecVersion:     SpecVersion,
+				Type:            "io.tattler.pod.updated",
+				Subject:         "default/pod-1",
+				Time:            observed,
+				DataContentType: "application/json",
+			},
+		},
+		{
+			name:    "Cluster scoped add with source",
+			options: []Option{WithSource("/clusters/prod"), WithDataFormat(wire.FProto)},
+			entry:   nodeAdd,
+			want: Event{
+				ID:              "node/5/added/watch",
+				Source:          "/clusters/prod",
+				SpecVersion:     SpecVersion,
+				Type:            "io.tattler.node.added",
+				Subject:         "node-1",
+				Time:            observed,
+				DataContentType: "application/x-protobuf",
+			},
+		},
+		{
+			name:  "Not a change without a cluster",
+			entry: syncComplete,
+			want: Event{
+				ID:              string(syncComplete.UID()),
+				Source:          "tattler",
+				SpecVersion:     SpecVersion,
+				Type:            "io.tattler.synccomplete",
+				Subject:         "informers",
+				Time:            observed,
+				DataContentType: "application/json",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		enc, err := New(test.options...)
+		if err != nil {
+			t.Fatalf("TestEvent(%s): New() got err == %s, want err == nil", test.name, err)
+		}
+		got, err := enc.Event(test.entry)
+		if err != nil {
+			t.Errorf("TestEvent(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if got.Data == nil {
+			t.Errorf("TestEvent(%s): got nil Data, want the encoded entry", test.name)
+		}
+		got.Data = nil
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestEvent(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+
+	if _, err := New(WithDataFormat(wire.FUnknown)); err == nil {
+		t.Errorf("TestEvent(FUnknown): got err == nil, want err != nil")
+	}
+}
+
+func TestEventTombstoneID(t *testing.T) {
+	t.Parallel()
+
+	// A delete the watch missed is recovered from a tombstone with the state of the last update, so both
+	// have the same resourceVersion.
+	del := data.MustNewChange(nil, newPod("1", "11"), data.CTDelete)
+	del.Flags = data.CFStaleFinalState
+	tombstone := entry(data.MustNewInformer(del), "cluster-a", 2)
+
+	enc, err := New()
+	if err != nil {
+		t.Fatalf("TestEventTombstoneID: got err == %s, want err == nil", err)
+	}
+	update, err := enc.Event(podUpdate)
+	if err != nil {
+		t.Fatalf("TestEventTombstoneID: got err == %s, want err == nil", err)
+	}
+	got, err := enc.Event(tombstone)
+	if err != nil {
+		t.Fatalf("TestEventTombstoneID: got err == %s, want err == nil", err)
+	}
+	if want := "1/11/deleted/watch"; got.ID != want {
+		t.Errorf("TestEventTombstoneID: got id %q, want %q", got.ID, want)
+	}
+	if got.Source == update.Source && got.ID == update.ID {
+		t.Errorf("TestEventTombstoneID: got the delete and the update with the same source and id %q", got.ID)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range []wire.Format{wire.FJSON, wire.FProto} {
+		enc, err := New(WithDataFormat(f))
+		if err != nil {
+			t.Fatalf("TestEntry(%v): New() got err == %s, want err == nil", f, err)
+		}
+		for _, m := range []Mode{MStructured, MBinary, MBatch} {
+			msg, err := enc.Entry(m, podUpdate)
+			if err != nil {
+				t.Errorf("TestEntry(%v, %v): got err == %s, want err == nil", f, m, err)
+				continue
+			}
+
+			switch m {
+			case MStructured:
+				if got := msg.Header.Get("Content-Type"); got != ContentTypeStructured {
+					t.Errorf("TestEntry(%v, %v): got Content-Type %s, want %s", f, m, got, ContentTypeStructured)
+				}
+				if want := `"type":"io.tattler.pod.updated"`; !strings.Contains(string(msg.Body), want) {
+					t.Errorf("TestEntry(%v, %v): got body %s, want it to contain %s", f, m, msg.Body, want)
+				}
+			case MBinary:
+				if got := msg.Header.Get("ce-type"); got != "io.tattler.pod.updated" {
+					t.Errorf("TestEntry(%v, %v): got ce-type %s, want io.tattler.pod.updated", f, m, got)
+				}
+				if got := msg.Header.Get("Content-Type"); got != f.ContentType() {
+					t.Errorf("TestEntry(%v, %v): got Content-Type %s, want %s", f, m, got, f.ContentType())
+				}
+			}
+
+			events, err := Decode(msg.Header, msg.Body)
+			if err != nil {
+				t.Errorf("TestEntry(%v, %v): Decode() got err == %s, want err == nil", f, m, err)
+				continue
+			}
+			if len(events) != 1 {
+				t.Errorf("TestEntry(%v, %v): got %d events, want 1", f, m, len(events))
+				continue
+			}
+			got, err := events[0].Entry()
+			if err != nil {
+				t.Errorf("TestEntry(%v, %v): Event.Entry() got err == %s, want err == nil", f, m, err)
+				continue
+			}
+			if diff := pretty.Compare(podUpdate, got); diff != "" {
+				t.Errorf("TestEntry(%v, %v): -want/+got:\n%s", f, m, diff)
+			}
+		}
+	}
+
+	// Structured mode with JSON data embeds it as JSON, anything else is base64.
+	enc, _ := New()
+	msg, _ := enc.Entry(MStructured, podUpdate)
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Body, &raw); err != nil {
+		t.Fatalf("TestEntry: got err == %s, want err == nil", err)
+	}
+	if _, ok := raw["data"]; !ok {
+		t.Errorf("TestEntry(JSON data): got no data attribute, want one")
+	}
+	enc, _ = New(WithDataFormat(wire.FProto))
+	msg, _ = enc.Entry(MStructured, podUpdate)
+	raw = nil
+	if err := json.Unmarshal(msg.Body, &raw); err != nil {
+		t.Fatalf("TestEntry: got err == %s, want err == nil", err)
+	}
+	if _, ok := raw["data_base64"]; !ok {
+		t.Errorf("TestEntry(proto data): got no data_base64 attribute, want one")
+	}
+}
+
+func TestBatches(t *testing.T) {
+	t.Parallel()
+
+	b := batching.Batches{
+		data.ETInformer: batching.Batch{
+			podUpdate.UID(): podUpdate,
+			nodeAdd.UID():   nodeAdd,
+		},
+		data.ETSyncComplete: batching.Batch{
+			syncComplete.UID(): syncComplete,
+		},
+	}
+	enc, err := New()
+	if err != nil {
+		t.Fatalf("TestBatches: New() got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		mode     Mode
+		wantMsgs int
+	}{
+		{mode: MStructured, wantMsgs: 3},
+		{mode: MBinary, wantMsgs: 3},
+		{mode: MBatch, wantMsgs: 1},
+	}
+
+	for _, test := range tests {
+		msgs, err := enc.Batches(test.mode, b)
+		if err != nil {
+			t.Errorf("TestBatches(%v): got err == %s, want err == nil", test.mode, err)
+			continue
+		}
+		if len(msgs) != test.wantMsgs {
+			t.Errorf("TestBatches(%v): got %d messages, want %d", test.mode, len(msgs), test.wantMsgs)
+			continue
+		}
+
+		var types []string
+		for _, msg := range msgs {
+			events, err := Decode(msg.Header, msg.Body)
+			if err != nil {
+				t.Fatalf("TestBatches(%v): Decode() got err == %s, want err == nil", test.mode, err)
+			}
+			for _, ev := range events {
+				types = append(types, ev.Type)
+			}
+		}
+		want := []string{"io.tattler.pod.updated", "io.tattler.node.added", "io.tattler.synccomplete"}
+		if diff := pretty.Compare(want, types); diff != "" {
+			t.Errorf("TestBatches(%v): -want/+got:\n%s", test.mode, diff)
+		}
+	}
+
+	if msgs, err := enc.Batches(MBatch, batching.Batches{}); err != nil || msgs != nil {
+		t.Errorf("TestBatches(empty): got %v, %v, want nil, nil", msgs, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		h    http.Header
+		body string
+	}{
+		{
+			name: "Binary missing id",
+			h:    http.Header{"Ce-Source": {"s"}, "Ce-Specversion": {"1.0"}, "Ce-Type": {"t"}},
+		},
+		{
+			name: "Structured wrong specversion",
+			h:    http.Header{"Content-Type": {ContentTypeStructured}},
+			body: `{"id":"1","source":"s","specversion":"0.3","type":"t"}`,
+		},
+		{
+			name: "Batch not an array",
+			h:    http.Header{"Content-Type": {ContentTypeBatch}},
+			body: `{"id":"1","source":"s","specversion":"1.0","type":"t"}`,
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := Decode(test.h, []byte(test.body)); err == nil {
+			t.Errorf("TestDecodeErrors(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
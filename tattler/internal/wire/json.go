@@ -0,0 +1,203 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+)
+
+// jsonEntry is the FJSON layout of an Entry.
+type jsonEntry struct {
+	Version   uint32     `json:"version"`
+	EntryType string     `json:"entryType"`
+	Origin    string     `json:"origin,omitempty"`
+	Observed  *time.Time `json:"observed,omitempty"`
+	Reader    string     `json:"reader,omitempty"`
+	Cluster   string     `json:"cluster,omitempty"`
+	Seq       uint64     `json:"seq,omitempty"`
+	UID       string     `json:"uid,omitempty"`
+
+	ChangeType string          `json:"changeType,omitempty"`
+	ObjectType string          `json:"objectType,omitempty"`
+	Flags      uint32          `json:"flags,omitempty"`
+	Old        json.RawMessage `json:"old,omitempty"`
+	New        json.RawMessage `json:"new,omitempty"`
+
+	// Data is the output of Registration.Encode if it is valid JSON, otherwise it is in BinaryData.
+	Data       json.RawMessage `json:"data,omitempty"`
+	BinaryData []byte          `json:"binaryData,omitempty"`
+	Event      *jsonEvent      `json:"event,omitempty"`
+}
+
+// jsonEvent is the FJSON layout of eventInfo.
+type jsonEvent struct {
+	Count          int32      `json:"count"`
+	FirstTimestamp *time.Time `json:"firstTimestamp,omitempty"`
+	LastTimestamp  *time.Time `json:"lastTimestamp,omitempty"`
+}
+
+// jsonBatch is the FJSON layout of Batches.
+type jsonBatch struct {
+	Version uint32      `json:"version"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+// names maps the String() of ChangeType, ObjectType and Origin values back to the value.
+var (
+	changeTypes = names(data.CTUnknown, data.CTDelete)
+	objectTypes = names(data.OTUnknown, data.OTNodeLiveness)
+	origins     = names(data.ORUnknown, data.ORWatch)
+)
+
+func names[T ~uint8](first, last T) map[string]T {
+	m := map[string]T{}
+	for v := first; v <= last; v++ {
+		m[fmt.Sprint(v)] = v
+	}
+	return m
+}
+
+func marshalJSON(r record) ([]byte, error) {
+	return json.Marshal(toJSON(r))
+}
+
+func unmarshalJSON(b []byte) (record, error) {
+	var je jsonEntry
+	if err := json.Unmarshal(b, &je); err != nil {
+		return record{}, err
+	}
+	return fromJSON(je)
+}
+
+func marshalJSONBatch(records []record) ([]byte, error) {
+	jb := jsonBatch{Version: Version, Entries: make([]jsonEntry, 0, len(records))}
+	for _, r := range records {
+		jb.Entries = append(jb.Entries, toJSON(r))
+	}
+	return json.Marshal(jb)
+}
+
+func unmarshalJSONBatch(b []byte) ([]record, error) {
+	var jb jsonBatch
+	if err := json.Unmarshal(b, &jb); err != nil {
+		return nil, err
+	}
+	if jb.Version == 0 || jb.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, jb.Version)
+	}
+	records := make([]record, 0, len(jb.Entries))
+	for i, je := range jb.Entries {
+		r, err := fromJSON(je)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func toJSON(r record) jsonEntry {
+	je := jsonEntry{
+		Version:   r.Version,
+		EntryType: r.EntryType,
+		Observed:  timePtr(r.Observed),
+		Reader:    r.Reader,
+		Cluster:   r.Cluster,
+		Seq:       r.Seq,
+		UID:       r.UID,
+		Old:       r.Old.Raw,
+		New:       r.New.Raw,
+		Flags:     uint32(r.Flags),
+	}
+	if r.Origin != data.ORUnknown {
+		je.Origin = r.Origin.String()
+	}
+	if r.ChangeType != data.CTUnknown {
+		je.ChangeType = r.ChangeType.String()
+	}
+	if r.ObjectType != data.OTUnknown {
+		je.ObjectType = r.ObjectType.String()
+	}
+	if r.Data != nil {
+		if json.Valid(r.Data) {
+			je.Data = r.Data
+		} else {
+			je.BinaryData = r.Data
+		}
+	}
+	if r.Event != nil {
+		je.Event = &jsonEvent{
+			Count:          r.Event.Count,
+			FirstTimestamp: timePtr(r.Event.FirstTimestamp),
+			LastTimestamp:  timePtr(r.Event.LastTimestamp),
+		}
+	}
+	return je
+}
+
+func fromJSON(je jsonEntry) (record, error) {
+	r := record{
+		Version:   je.Version,
+		EntryType: je.EntryType,
+		Observed:  timeVal(je.Observed),
+		Reader:    je.Reader,
+		Cluster:   je.Cluster,
+		Seq:       je.Seq,
+		UID:       je.UID,
+		Flags:     data.ChangeFlag(je.Flags),
+		Old:       object{Raw: je.Old},
+		New:       object{Raw: je.New},
+	}
+	if r.Version == 0 || r.Version > Version {
+		return record{}, fmt.Errorf("%w: %d", ErrVersion, r.Version)
+	}
+
+	var ok bool
+	if je.Origin != "" {
+		if r.Origin, ok = origins[je.Origin]; !ok {
+			return record{}, fmt.Errorf("unknown origin %q", je.Origin)
+		}
+	}
+	if je.ChangeType != "" {
+		if r.ChangeType, ok = changeTypes[je.ChangeType]; !ok {
+			return record{}, fmt.Errorf("unknown changeType %q", je.ChangeType)
+		}
+	}
+	if je.ObjectType != "" {
+		if r.ObjectType, ok = objectTypes[je.ObjectType]; !ok {
+			return record{}, fmt.Errorf("unknown objectType %q", je.ObjectType)
+		}
+	}
+	switch {
+	case je.Data != nil:
+		r.Data = je.Data
+	case je.BinaryData != nil:
+		r.Data = je.BinaryData
+	}
+	if je.Event != nil {
+		r.Event = &eventInfo{
+			Count:          je.Event.Count,
+			FirstTimestamp: timeVal(je.Event.FirstTimestamp),
+			LastTimestamp:  timeVal(je.Event.LastTimestamp),
+		}
+	}
+	return r, nil
+}
+
+// timePtr returns nil for the zero time, so that it is left out.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func timeVal(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
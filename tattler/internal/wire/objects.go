@@ -0,0 +1,200 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// protoMarshaler is implemented by Kubernetes API types.
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// protoUnmarshaler is implemented by Kubernetes API types.
+type protoUnmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// codec rebuilds the SourceData for an ObjectType.
+type codec struct {
+	// build rebuilds the SourceData from r.
+	build func(r record) (data.SourceData, error)
+	// et is the EntryType that holds the ObjectType.
+	et data.EntryType
+	// proto is set if the object type has a Kubernetes protobuf encoding. This is not the same as
+	// implementing protoMarshaler, as our own types get Marshal() from their embedded ObjectMeta.
+	proto bool
+}
+
+// codecs holds the codec for every ObjectType.
+var codecs = map[data.ObjectType]codec{
+	data.OTNode:                           informer[corev1.Node](true),
+	data.OTPod:                            informer[corev1.Pod](true),
+	data.OTNamespace:                      informer[corev1.Namespace](true),
+	data.OTDeployment:                     informer[appsv1.Deployment](true),
+	data.OTReplicaSet:                     informer[appsv1.ReplicaSet](true),
+	data.OTStatefulSet:                    informer[appsv1.StatefulSet](true),
+	data.OTDaemonSet:                      informer[appsv1.DaemonSet](true),
+	data.OTJob:                            informer[batchv1.Job](true),
+	data.OTCronJob:                        informer[batchv1.CronJob](true),
+	data.OTService:                        informer[corev1.Service](true),
+	data.OTEndpointSlice:                  informer[discoveryv1.EndpointSlice](true),
+	data.OTRole:                           informer[rbacv1.Role](true),
+	data.OTClusterRole:                    informer[rbacv1.ClusterRole](true),
+	data.OTRoleBinding:                    informer[rbacv1.RoleBinding](true),
+	data.OTClusterRoleBinding:             informer[rbacv1.ClusterRoleBinding](true),
+	data.OTSecret:                         informer[data.SecretMeta](false),
+	data.OTConfigMap:                      informer[data.ConfigMapMeta](false),
+	data.OTNetworkPolicy:                  informer[networkingv1.NetworkPolicy](true),
+	data.OTIngress:                        informer[networkingv1.Ingress](true),
+	data.OTIngressClass:                   informer[networkingv1.IngressClass](true),
+	data.OTGateway:                        informer[unstructured.Unstructured](false),
+	data.OTHTTPRoute:                      informer[unstructured.Unstructured](false),
+	data.OTValidatingWebhookConfiguration: informer[admissionregistrationv1.ValidatingWebhookConfiguration](true),
+	data.OTMutatingWebhookConfiguration:   informer[admissionregistrationv1.MutatingWebhookConfiguration](true),
+	data.OTValidatingAdmissionPolicy:      informer[admissionregistrationv1.ValidatingAdmissionPolicy](true),
+	data.OTServiceAccount:                 informer[corev1.ServiceAccount](true),
+	data.OTNodeLiveness:                   informer[data.NodeLiveness](false),
+
+	data.OTPersistentVolume:      persistentVolume[corev1.PersistentVolume](),
+	data.OTPersistentVolumeClaim: persistentVolume[corev1.PersistentVolumeClaim](),
+	data.OTStorageClass:          persistentVolume[storagev1.StorageClass](),
+
+	data.OTEvent:         event[corev1.Event](),
+	data.OTEventsV1Event: event[eventsv1.Event](),
+}
+
+// k8sObject is a pointer to T that is a Kubernetes object.
+type k8sObject[T any] interface {
+	*T
+	data.K8Object
+}
+
+func informer[T any, PT k8sObject[T]](proto bool) codec {
+	return codec{
+		et:    data.ETInformer,
+		proto: proto,
+		build: func(r record) (data.SourceData, error) {
+			c, err := decodeChange[T, PT](r)
+			if err != nil {
+				return nil, err
+			}
+			return data.NewInformer(c)
+		},
+	}
+}
+
+func persistentVolume[T any, PT k8sObject[T]]() codec {
+	return codec{
+		et:    data.ETPersistentVolume,
+		proto: true,
+		build: func(r record) (data.SourceData, error) {
+			c, err := decodeChange[T, PT](r)
+			if err != nil {
+				return nil, err
+			}
+			return data.NewPersistentVolume(c)
+		},
+	}
+}
+
+func event[T any, PT k8sObject[T]]() codec {
+	return codec{
+		et:    data.ETEvent,
+		proto: true,
+		build: func(r record) (data.SourceData, error) {
+			c, err := decodeChange[T, PT](r)
+			if err != nil {
+				return nil, err
+			}
+			ev, err := data.NewEvent(c)
+			if err != nil {
+				return nil, err
+			}
+			if r.Event != nil {
+				ev.FirstTimestamp = r.Event.FirstTimestamp
+				ev.LastTimestamp = r.Event.LastTimestamp
+				ev.Count = r.Event.Count
+			}
+			return ev, nil
+		},
+	}
+}
+
+// decodeChange rebuilds the Change in r.
+func decodeChange[T any, PT k8sObject[T]](r record) (data.Change[PT], error) {
+	c := data.Change[PT]{ChangeType: r.ChangeType, ObjectType: r.ObjectType, Flags: r.Flags}
+
+	var err error
+	if c.Old, err = decodeObject[T, PT](r.Old); err != nil {
+		return data.Change[PT]{}, fmt.Errorf("old object: %w", err)
+	}
+	if c.New, err = decodeObject[T, PT](r.New); err != nil {
+		return data.Change[PT]{}, fmt.Errorf("new object: %w", err)
+	}
+	return c, nil
+}
+
+// decodeObject decodes o. It returns nil if o has no object.
+func decodeObject[T any, PT k8sObject[T]](o object) (PT, error) {
+	if o.Raw == nil {
+		return nil, nil
+	}
+
+	obj := PT(new(T))
+	if o.Proto {
+		u, ok := any(obj).(protoUnmarshaler)
+		if !ok {
+			return nil, fmt.Errorf("%T has no protobuf encoding", obj)
+		}
+		if err := u.Unmarshal(o.Raw); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	if err := json.Unmarshal(o.Raw, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// encodeObject encodes obj as Kubernetes protobuf if proto is set, otherwise as JSON. A nil obj is an
+// empty object.
+func encodeObject(obj runtime.Object, proto bool) (object, error) {
+	if obj == nil {
+		return object{}, nil
+	}
+	if proto {
+		m, ok := obj.(protoMarshaler)
+		if !ok {
+			return object{}, fmt.Errorf("%T has no protobuf encoding", obj)
+		}
+		b, err := m.Marshal()
+		if err != nil {
+			return object{}, err
+		}
+		if b == nil {
+			b = []byte{}
+		}
+		return object{Raw: b, Proto: true}, nil
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return object{}, err
+	}
+	return object{Raw: b}, nil
+}
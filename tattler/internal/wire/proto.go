@@ -0,0 +1,277 @@
+package wire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the FProto schema in the package documentation.
+const (
+	batchVersion protowire.Number = 1
+	batchEntries protowire.Number = 2
+
+	entryVersion    protowire.Number = 1
+	entryEntryType  protowire.Number = 2
+	entryOrigin     protowire.Number = 3
+	entryObserved   protowire.Number = 4
+	entryReader     protowire.Number = 5
+	entryCluster    protowire.Number = 6
+	entrySeq        protowire.Number = 7
+	entryUID        protowire.Number = 8
+	entryChangeType protowire.Number = 9
+	entryObjectType protowire.Number = 10
+	entryFlags      protowire.Number = 11
+	entryOld        protowire.Number = 12
+	entryNew        protowire.Number = 13
+	entryData       protowire.Number = 14
+	entryEvent      protowire.Number = 15
+
+	objectEncoding protowire.Number = 1
+	objectRaw      protowire.Number = 2
+
+	eventCount protowire.Number = 1
+	eventFirst protowire.Number = 2
+	eventLast  protowire.Number = 3
+)
+
+// encodingKubernetesProtobuf is the Object.Encoding for Kubernetes protobuf.
+const encodingKubernetesProtobuf = 1
+
+func appendProtoBatch(b []byte, records []record) []byte {
+	b = appendUint(b, batchVersion, Version)
+	for _, r := range records {
+		b = protowire.AppendTag(b, batchEntries, protowire.BytesType)
+		b = protowire.AppendBytes(b, appendProto(nil, r))
+	}
+	return b
+}
+
+func consumeProtoBatch(b []byte) ([]record, error) {
+	var version uint64
+	var records []record
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == batchVersion && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			version = v
+			return n, nil
+		case num == batchEntries && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			r, err := consumeProto(v)
+			if err != nil {
+				return 0, fmt.Errorf("entry %d: %w", len(records), err)
+			}
+			records = append(records, r)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 || version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, version)
+	}
+	return records, nil
+}
+
+func appendProto(b []byte, r record) []byte {
+	b = appendUint(b, entryVersion, uint64(r.Version))
+	b = appendString(b, entryEntryType, r.EntryType)
+	b = appendUint(b, entryOrigin, uint64(r.Origin))
+	b = appendTime(b, entryObserved, r.Observed)
+	b = appendString(b, entryReader, r.Reader)
+	b = appendString(b, entryCluster, r.Cluster)
+	b = appendUint(b, entrySeq, r.Seq)
+	b = appendString(b, entryUID, r.UID)
+	b = appendUint(b, entryChangeType, uint64(r.ChangeType))
+	b = appendUint(b, entryObjectType, uint64(r.ObjectType))
+	b = appendUint(b, entryFlags, uint64(r.Flags))
+	b = appendObject(b, entryOld, r.Old)
+	b = appendObject(b, entryNew, r.New)
+	if r.Data != nil {
+		b = protowire.AppendTag(b, entryData, protowire.BytesType)
+		b = protowire.AppendBytes(b, r.Data)
+	}
+	if r.Event != nil {
+		var ev []byte
+		ev = appendUint(ev, eventCount, uint64(r.Event.Count))
+		ev = appendTime(ev, eventFirst, r.Event.FirstTimestamp)
+		ev = appendTime(ev, eventLast, r.Event.LastTimestamp)
+		b = protowire.AppendTag(b, entryEvent, protowire.BytesType)
+		b = protowire.AppendBytes(b, ev)
+	}
+	return b
+}
+
+func consumeProto(b []byte) (record, error) {
+	var r record
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			switch num {
+			case entryVersion:
+				r.Version = uint32(v)
+			case entryOrigin:
+				r.Origin = data.Origin(v)
+			case entryObserved:
+				r.Observed = unixNano(v)
+			case entrySeq:
+				r.Seq = v
+			case entryChangeType:
+				r.ChangeType = data.ChangeType(v)
+			case entryObjectType:
+				r.ObjectType = data.ObjectType(v)
+			case entryFlags:
+				r.Flags = data.ChangeFlag(v)
+			}
+			return n, nil
+		}
+		if typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		var err error
+		switch num {
+		case entryEntryType:
+			r.EntryType = string(v)
+		case entryReader:
+			r.Reader = string(v)
+		case entryCluster:
+			r.Cluster = string(v)
+		case entryUID:
+			r.UID = string(v)
+		case entryOld:
+			r.Old, err = consumeObject(v)
+		case entryNew:
+			r.New, err = consumeObject(v)
+		case entryData:
+			r.Data = append([]byte{}, v...)
+		case entryEvent:
+			r.Event, err = consumeEvent(v)
+		}
+		return n, err
+	})
+	if err != nil {
+		return record{}, err
+	}
+	if r.Version == 0 || r.Version > Version {
+		return record{}, fmt.Errorf("%w: %d", ErrVersion, r.Version)
+	}
+	return r, nil
+}
+
+func appendObject(b []byte, num protowire.Number, o object) []byte {
+	if o.Raw == nil {
+		return b
+	}
+	var ob []byte
+	if o.Proto {
+		ob = appendUint(ob, objectEncoding, encodingKubernetesProtobuf)
+	}
+	ob = protowire.AppendTag(ob, objectRaw, protowire.BytesType)
+	ob = protowire.AppendBytes(ob, o.Raw)
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, ob)
+}
+
+func consumeObject(b []byte) (object, error) {
+	// An object that is present always has Raw, even if the encoding is empty.
+	o := object{Raw: []byte{}}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == objectEncoding && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			o.Proto = v == encodingKubernetesProtobuf
+			return n, nil
+		case num == objectRaw && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			o.Raw = append([]byte{}, v...)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	return o, err
+}
+
+func consumeEvent(b []byte) (*eventInfo, error) {
+	ev := &eventInfo{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.VarintType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		v, n := protowire.ConsumeVarint(b)
+		switch num {
+		case eventCount:
+			ev.Count = int32(v)
+		case eventFirst:
+			ev.FirstTimestamp = unixNano(v)
+		case eventLast:
+			ev.LastTimestamp = unixNano(v)
+		}
+		return n, nil
+	})
+	return ev, err
+}
+
+// consumeFields calls field with the value of each field in b, which returns the length of the value it
+// consumed or a negative protowire error code.
+func consumeFields(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// appendUint appends v, leaving it out if it is zero as proto3 does.
+func appendUint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendTime appends t as an int64 of Unix nanoseconds. The zero time is left out.
+func appendTime(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendUint(b, num, uint64(t.UnixNano()))
+}
+
+func unixNano(v uint64) time.Time {
+	return time.Unix(0, int64(v)).UTC()
+}
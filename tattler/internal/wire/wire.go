@@ -0,0 +1,378 @@
+/*
+Package wire provides the canonical, versioned encoding of data.Entry and batching.Batches. Processors should
+use this instead of inventing their own layout, so that every consumer sees the same payload. Anything
+encoded here can be decoded back into data.Entry values and fed into the pipeline again.
+
+There are two formats. FJSON is for people and for consumers that only speak JSON. FProto is the protobuf
+wire format and is much smaller, as Kubernetes objects are embedded in their own protobuf encoding where
+they have one.
+
+Every encoded Entry carries the schema Version, the envelope metadata the Runner adds (Observed, Reader,
+Cluster and Seq), the Origin and, for changes, the ChangeType, ObjectType and ChangeFlags. Decoding fails
+on a schema version newer than this package understands.
+
+Usage is simple:
+
+	b, err := wire.MarshalBatches(wire.FProto, batches)
+	if err != nil {
+		// Do something
+	}
+	...
+	batches, err := wire.UnmarshalBatches(wire.FProto, b)
+	if err != nil {
+		// Do something
+	}
+
+Types registered with data.Register() are encoded with their Registration.Encode and decoded with
+Registration.Decode. Entries of a registered type without an Encode cannot be encoded.
+
+The FProto schema is:
+
+	syntax = "proto3";
+
+	package tattler.wire.v1;
+
+	message Batch {
+		uint32 version = 1;
+		repeated Entry entries = 2;
+	}
+
+	message Entry {
+		uint32 version = 1;
+		// entry_type is the registered name of the EntryType, as EntryType values of types registered
+		// outside the data package depend on registration order.
+		string entry_type = 2;
+		uint32 origin = 3;
+		// observed_unix_nano is unset if Observed was the zero time.
+		int64 observed_unix_nano = 4;
+		string reader = 5;
+		string cluster = 6;
+		uint64 seq = 7;
+		string uid = 8;
+		uint32 change_type = 9;
+		uint32 object_type = 10;
+		uint32 flags = 11;
+		Object old = 12;
+		Object new = 13;
+		// data is the output of Registration.Encode for types that are not changes.
+		bytes data = 14;
+		Event event = 15;
+	}
+
+	message Object {
+		enum Encoding {
+			JSON = 0;
+			KUBERNETES_PROTOBUF = 1;
+		}
+		Encoding encoding = 1;
+		bytes raw = 2;
+	}
+
+	message Event {
+		int32 count = 1;
+		int64 first_unix_nano = 2;
+		int64 last_unix_nano = 3;
+	}
+
+Kubernetes protobuf does not include TypeMeta, so objects encoded that way are decoded with an empty TypeMeta.
+This is how informers receive them from the API server, so it is normally empty anyway.
+*/
+package wire
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+)
+
+// Version is the schema version this package writes. It can decode this version and any before it.
+const Version = 1
+
+var (
+	// ErrVersion is returned when decoding data with a missing schema version or one newer than Version.
+	ErrVersion = errors.New("unsupported schema version")
+	// ErrEntryType is returned when an Entry's type is not registered or cannot be encoded.
+	ErrEntryType = errors.New("unsupported entry type")
+)
+
+//go:generate stringer -type=Format -linecomment
+
+// Format is an encoding format.
+type Format uint8
+
+const (
+	// FUnknown indicates a bug in the code.
+	FUnknown Format = 0 // Unknown
+	// FJSON is JSON. An encoded Entry or Batches is a single line.
+	FJSON Format = 1 // JSON
+	// FProto is the protobuf wire format.
+	FProto Format = 2 // Proto
+)
+
+// ContentType returns the MIME type of data in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FJSON:
+		return "application/json"
+	case FProto:
+		return "application/x-protobuf"
+	}
+	return "application/octet-stream"
+}
+
+// Marshal encodes e in format f.
+func Marshal(f Format, e data.Entry) ([]byte, error) {
+	r, err := toRecord(f, e)
+	if err != nil {
+		return nil, err
+	}
+	switch f {
+	case FJSON:
+		return marshalJSON(r)
+	case FProto:
+		return appendProto(nil, r), nil
+	}
+	return nil, fmt.Errorf("unknown Format: %v", f)
+}
+
+// Unmarshal decodes an Entry encoded by Marshal() in format f.
+func Unmarshal(f Format, b []byte) (data.Entry, error) {
+	var r record
+	var err error
+	switch f {
+	case FJSON:
+		r, err = unmarshalJSON(b)
+	case FProto:
+		r, err = consumeProto(b)
+	default:
+		return data.Entry{}, fmt.Errorf("unknown Format: %v", f)
+	}
+	if err != nil {
+		return data.Entry{}, err
+	}
+	return fromRecord(r)
+}
+
+// MarshalBatches encodes all entries in b in format f. Entries are ordered by EntryType, Reader, Seq and UID,
+// so the same Batches always has the same encoding.
+func MarshalBatches(f Format, b batching.Batches) ([]byte, error) {
+	entries := Entries(b)
+	records := make([]record, 0, len(entries))
+	for _, e := range entries {
+		r, err := toRecord(f, e)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+
+	switch f {
+	case FJSON:
+		return marshalJSONBatch(records)
+	case FProto:
+		return appendProtoBatch(nil, records), nil
+	}
+	return nil, fmt.Errorf("unknown Format: %v", f)
+}
+
+// UnmarshalBatches decodes Batches encoded by MarshalBatches() in format f.
+func UnmarshalBatches(f Format, b []byte) (batching.Batches, error) {
+	var records []record
+	var err error
+	switch f {
+	case FJSON:
+		records, err = unmarshalJSONBatch(b)
+	case FProto:
+		records, err = consumeProtoBatch(b)
+	default:
+		return nil, fmt.Errorf("unknown Format: %v", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batches := batching.Batches{}
+	for i, r := range records {
+		e, err := fromRecord(r)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		batch, ok := batches[e.Type]
+		if !ok {
+			batch = batching.Batch{}
+			batches[e.Type] = batch
+		}
+		batch[e.UID()] = e
+	}
+	return batches, nil
+}
+
+// Entries returns the entries in b in the order MarshalBatches() encodes them.
+func Entries(b batching.Batches) []data.Entry {
+	var n int
+	for _, batch := range b {
+		n += len(batch)
+	}
+	entries := make([]data.Entry, 0, n)
+	for _, batch := range b {
+		for _, e := range batch {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b data.Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.Reader, b.Reader),
+			cmp.Compare(a.Seq, b.Seq),
+			cmp.Compare(a.UID(), b.UID()),
+		)
+	})
+	return entries
+}
+
+// record is an Entry in the form both formats encode.
+type record struct {
+	Version   uint32
+	EntryType string
+	Origin    data.Origin
+	Observed  time.Time
+	Reader    string
+	Cluster   string
+	Seq       uint64
+	UID       string
+
+	ChangeType data.ChangeType
+	ObjectType data.ObjectType
+	Flags      data.ChangeFlag
+	Old        object
+	New        object
+
+	Data  []byte
+	Event *eventInfo
+}
+
+// object is an encoded Kubernetes object.
+type object struct {
+	// Raw is the encoded object, nil if there is no object.
+	Raw []byte
+	// Proto is set if Raw is Kubernetes protobuf, otherwise it is JSON.
+	Proto bool
+}
+
+// eventInfo holds the fields of a data.Event that the reader may set after creating it.
+type eventInfo struct {
+	FirstTimestamp time.Time
+	LastTimestamp  time.Time
+	Count          int32
+}
+
+// toRecord converts e to a record for format f.
+func toRecord(f Format, e data.Entry) (record, error) {
+	r := record{
+		Version:   Version,
+		EntryType: e.Type.String(),
+		Origin:    e.Origin,
+		Observed:  e.Observed,
+		Reader:    e.Reader,
+		Cluster:   e.Cluster,
+		Seq:       e.Seq,
+	}
+	if e.Data() == nil {
+		return record{}, fmt.Errorf("%w: Entry has no data", ErrEntryType)
+	}
+	r.UID = string(e.UID())
+
+	var ac data.AnyChange
+	var err error
+	switch e.Type {
+	case data.ETInformer:
+		var i data.Informer
+		if i, err = e.Informer(); err == nil {
+			ac, err = i.Change()
+		}
+	case data.ETPersistentVolume:
+		var pv data.PersistentVolume
+		if pv, err = e.PersistentVolume(); err == nil {
+			ac, err = pv.Change()
+		}
+	case data.ETEvent:
+		var ev data.Event
+		if ev, err = e.Event(); err == nil {
+			ac, err = ev.Change()
+			r.Event = &eventInfo{FirstTimestamp: ev.FirstTimestamp, LastTimestamp: ev.LastTimestamp, Count: ev.Count}
+		}
+	default:
+		reg, ok := e.Type.Registration()
+		if !ok || reg.Encode == nil {
+			return record{}, fmt.Errorf("%w: %v has no Encoder", ErrEntryType, e.Type)
+		}
+		if r.Data, err = reg.Encode(e.Data()); err != nil {
+			return record{}, fmt.Errorf("could not encode %v: %w", e.Type, err)
+		}
+		return r, nil
+	}
+	if err != nil {
+		return record{}, fmt.Errorf("could not get the change for %v: %w", e.Type, err)
+	}
+
+	r.ChangeType = ac.ChangeType
+	r.ObjectType = ac.ObjectType
+	r.Flags = ac.Flags
+	useProto := f == FProto && codecs[ac.ObjectType].proto
+	if r.Old, err = encodeObject(ac.Old, useProto); err != nil {
+		return record{}, fmt.Errorf("could not encode old %v: %w", ac.ObjectType, err)
+	}
+	if r.New, err = encodeObject(ac.New, useProto); err != nil {
+		return record{}, fmt.Errorf("could not encode new %v: %w", ac.ObjectType, err)
+	}
+	return r, nil
+}
+
+// fromRecord rebuilds the Entry in r.
+func fromRecord(r record) (data.Entry, error) {
+	if r.Version == 0 || r.Version > Version {
+		return data.Entry{}, fmt.Errorf("%w: %d", ErrVersion, r.Version)
+	}
+	et, ok := data.LookupEntryType(r.EntryType)
+	if !ok {
+		return data.Entry{}, fmt.Errorf("%w: %q is not registered", ErrEntryType, r.EntryType)
+	}
+
+	var sd data.SourceData
+	switch et {
+	case data.ETInformer, data.ETPersistentVolume, data.ETEvent:
+		c, ok := codecs[r.ObjectType]
+		if !ok || c.et != et {
+			return data.Entry{}, fmt.Errorf("%w: %v cannot hold %v", ErrEntryType, et, r.ObjectType)
+		}
+		var err error
+		if sd, err = c.build(r); err != nil {
+			return data.Entry{}, fmt.Errorf("could not rebuild %v %v: %w", et, r.ObjectType, err)
+		}
+	default:
+		reg, _ := et.Registration()
+		if reg.Decode == nil {
+			return data.Entry{}, fmt.Errorf("%w: %v has no Decoder", ErrEntryType, et)
+		}
+		var err error
+		if sd, err = reg.Decode(r.Data); err != nil {
+			return data.Entry{}, fmt.Errorf("could not decode %v: %w", et, err)
+		}
+	}
+
+	e, err := data.NewEntry(sd)
+	if err != nil {
+		return data.Entry{}, err
+	}
+	e.Origin = r.Origin
+	e.Observed = r.Observed
+	e.Reader = r.Reader
+	e.Cluster = r.Cluster
+	e.Seq = r.Seq
+	return e, nil
+}
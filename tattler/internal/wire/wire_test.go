@@ -0,0 +1,314 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	"github.com/kylelemons/godebug/pretty"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// recorded is a SourceData registered with an Encoder whose output is not JSON.
+type recorded struct {
+	uid types.UID
+}
+
+func (r recorded) GetUID() types.UID      { return r.uid }
+func (r recorded) Object() runtime.Object { return nil }
+
+var _ = data.MustRegister(data.Registration{
+	Name:   "Recorded",
+	Sample: recorded{},
+	Encode: func(d data.SourceData) ([]byte, error) {
+		return []byte("\x00" + d.(recorded).uid), nil
+	},
+	Decode: func(b []byte) (data.SourceData, error) {
+		return recorded{uid: types.UID(bytes.TrimPrefix(b, []byte{0}))}, nil
+	},
+})
+
+// unencodable is a SourceData registered without an Encoder.
+type unencodable struct {
+	recorded
+}
+
+var _ = data.MustRegister(data.Registration{Name: "Unencodable", Sample: unencodable{}})
+
+var observed = time.Date(2024, 5, 1, 12, 30, 15, 123456789, time.UTC)
+
+// newPod returns a pod. Times are local, as metav1.Time decodes them that way.
+func newPod(uid, rv string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pod-" + uid,
+			Namespace:         "default",
+			UID:               types.UID(uid),
+			ResourceVersion:   rv,
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)),
+			Labels:            map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "web", Image: "nginx:1.25"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func entry(t *testing.T, sd data.SourceData, origin data.Origin, seq uint64) data.Entry {
+	t.Helper()
+
+	e, err := data.NewEntry(sd)
+	if err != nil {
+		t.Fatalf("data.NewEntry(%T): %v", sd, err)
+	}
+	e.Origin = origin
+	e.Observed = observed
+	e.Reader = "informers"
+	e.Cluster = "cluster-a"
+	e.Seq = seq
+	return e
+}
+
+func testEntries(t *testing.T) map[string]data.Entry {
+	podUpdate := data.MustNewChange(newPod("1", "11"), newPod("1", "10"), data.CTUpdate)
+	podUpdate.Flags = data.CFStaleFinalState
+
+	secret := data.MustNewChange(
+		&data.SecretMeta{
+			ObjectMeta: metav1.ObjectMeta{Name: "s", Namespace: "default", UID: "secret"},
+			Type:       corev1.SecretTypeOpaque,
+			Keys:       []data.KeyInfo{{Name: "password", Size: 8}},
+		},
+		nil,
+		data.CTAdd,
+	)
+
+	gw := &unstructured.Unstructured{}
+	gw.SetAPIVersion(data.GatewayGroup + "/v1")
+	gw.SetKind("Gateway")
+	gw.SetName("gw")
+	gw.SetUID("gateway")
+	gateway := data.MustNewChange(gw, nil, data.CTAdd)
+
+	liveness := data.MustNewChange(
+		data.NewNodeLiveness(
+			&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "lease"}},
+			observed,
+		),
+		nil,
+		data.CTAdd,
+	)
+
+	pvc := data.MustNewChange(
+		nil,
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "default", UID: "pvc"}},
+		data.CTDelete,
+	)
+
+	ev := data.MustNewEvent(data.MustNewChange(
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "ev", Namespace: "default", UID: "event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pod-1", UID: "1"},
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+		},
+		nil,
+		data.CTAdd,
+	))
+	// Set as the events reader does when it collapses repeated events.
+	ev.Count = 5
+	ev.FirstTimestamp = observed.Add(-time.Minute)
+	ev.LastTimestamp = observed
+
+	sc := data.NewSyncComplete("informers", time.Date(2024, 5, 1, 0, 0, 1, 0, time.Local))
+
+	return map[string]data.Entry{
+		"Pod add":      entry(t, data.MustNewInformer(data.MustNewChange(newPod("1", "10"), nil, data.CTAdd)), data.ORInitialList, 1),
+		"Pod update":   entry(t, data.MustNewInformer(podUpdate), data.ORWatch, 2),
+		"SecretMeta":   entry(t, data.MustNewInformer(secret), data.ORWatch, 3),
+		"Gateway":      entry(t, data.MustNewInformer(gateway), data.ORWatch, 4),
+		"NodeLiveness": entry(t, data.MustNewInformer(liveness), data.ORWatch, 5),
+		"PVC delete":   entry(t, data.MustNewPersistentVolume(pvc), data.ORWatch, 6),
+		"Event":        entry(t, ev, data.ORUnknown, 7),
+		"SyncComplete": entry(t, sc, data.ORUnknown, 8),
+		"Registered":   entry(t, recorded{uid: "recorded"}, data.ORUnknown, 9),
+		"No envelope":  data.MustNewEntry(data.MustNewInformer(data.MustNewChange(newPod("2", "1"), nil, data.CTAdd))),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, e := range testEntries(t) {
+		for _, f := range []Format{FJSON, FProto} {
+			b, err := Marshal(f, e)
+			if err != nil {
+				t.Errorf("TestRoundTrip(%s, %v): Marshal() got err == %s, want err == nil", name, f, err)
+				continue
+			}
+			got, err := Unmarshal(f, b)
+			if err != nil {
+				t.Errorf("TestRoundTrip(%s, %v): Unmarshal() got err == %s, want err == nil", name, f, err)
+				continue
+			}
+			if diff := pretty.Compare(e, got); diff != "" {
+				t.Errorf("TestRoundTrip(%s, %v): -want/+got:\n%s", name, f, diff)
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	e := testEntries(t)["Pod update"]
+	b, err := Marshal(FJSON, e)
+	if err != nil {
+		t.Fatalf("TestJSON: got err == %s, want err == nil", err)
+	}
+	if bytes.ContainsRune(b, '\n') {
+		t.Errorf("TestJSON: got encoding with a newline, want a single line")
+	}
+	for _, want := range []string{
+		`"version":1`,
+		`"entryType":"Informer"`,
+		`"origin":"Watch"`,
+		`"observed":"2024-05-01T12:30:15.123456789Z"`,
+		`"reader":"informers"`,
+		`"cluster":"cluster-a"`,
+		`"seq":2`,
+		`"uid":"1"`,
+		`"changeType":"Update"`,
+		`"objectType":"Pod"`,
+		`"flags":32`,
+		`"resourceVersion":"10"`,
+		`"resourceVersion":"11"`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("TestJSON: got %s, want it to contain %s", b, want)
+		}
+	}
+
+	// The proto encoding of Kubernetes objects is what makes FProto compact.
+	p, err := Marshal(FProto, e)
+	if err != nil {
+		t.Fatalf("TestJSON: got err == %s, want err == nil", err)
+	}
+	if len(p) >= len(b) {
+		t.Errorf("TestJSON: got FProto of %d bytes, want less than FJSON of %d bytes", len(p), len(b))
+	}
+}
+
+func TestBatches(t *testing.T) {
+	t.Parallel()
+
+	batches := batching.Batches{}
+	for _, e := range testEntries(t) {
+		if batches[e.Type] == nil {
+			batches[e.Type] = batching.Batch{}
+		}
+		batches[e.Type][e.UID()] = e
+	}
+
+	entries := Entries(batches)
+	for i := 1; i < len(entries); i++ {
+		a, b := entries[i-1], entries[i]
+		if a.Type > b.Type || (a.Type == b.Type && a.Reader == b.Reader && a.Seq > b.Seq) {
+			t.Errorf("TestBatches: got Entries() with %v/%d before %v/%d", a.Type, a.Seq, b.Type, b.Seq)
+		}
+	}
+
+	for _, f := range []Format{FJSON, FProto} {
+		b, err := MarshalBatches(f, batches)
+		if err != nil {
+			t.Fatalf("TestBatches(%v): MarshalBatches() got err == %s, want err == nil", f, err)
+		}
+		again, err := MarshalBatches(f, batches)
+		if err != nil {
+			t.Fatalf("TestBatches(%v): MarshalBatches() got err == %s, want err == nil", f, err)
+		}
+		if !bytes.Equal(b, again) {
+			t.Errorf("TestBatches(%v): got different encodings of the same Batches, want the same", f)
+		}
+
+		got, err := UnmarshalBatches(f, b)
+		if err != nil {
+			t.Fatalf("TestBatches(%v): UnmarshalBatches() got err == %s, want err == nil", f, err)
+		}
+		if diff := pretty.Compare(batches, got); diff != "" {
+			t.Errorf("TestBatches(%v): -want/+got:\n%s", f, diff)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Marshal(FJSON, data.MustNewEntry(unencodable{})); !errors.Is(err, ErrEntryType) {
+		t.Errorf("TestErrors(no Encoder): got err == %v, want ErrEntryType", err)
+	}
+	if _, err := Marshal(FUnknown, testEntries(t)["Pod add"]); err == nil {
+		t.Errorf("TestErrors(FUnknown): got err == nil, want err != nil")
+	}
+
+	tests := []struct {
+		name    string
+		b       string
+		wantErr error
+	}{
+		{
+			name:    "No version",
+			b:       `{"entryType":"SyncComplete","data":{}}`,
+			wantErr: ErrVersion,
+		},
+		{
+			name:    "Newer version",
+			b:       `{"version":2,"entryType":"SyncComplete","data":{}}`,
+			wantErr: ErrVersion,
+		},
+		{
+			name:    "Unknown EntryType",
+			b:       `{"version":1,"entryType":"Nope","data":{}}`,
+			wantErr: ErrEntryType,
+		},
+		{
+			name:    "ObjectType not held by EntryType",
+			b:       `{"version":1,"entryType":"PersistentVolumes","changeType":"Add","objectType":"Pod","new":{}}`,
+			wantErr: ErrEntryType,
+		},
+		{
+			name: "Unknown changeType",
+			b:    `{"version":1,"entryType":"Informer","changeType":"Moved","objectType":"Pod","new":{}}`,
+		},
+		{
+			name: "Invalid change",
+			b:    `{"version":1,"entryType":"Informer","changeType":"Update","objectType":"Pod","new":{}}`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Unmarshal(FJSON, []byte(test.b))
+		switch {
+		case err == nil:
+			t.Errorf("TestErrors(%s): got err == nil, want err != nil", test.name)
+		case test.wantErr != nil && !errors.Is(err, test.wantErr):
+			t.Errorf("TestErrors(%s): got err == %s, want %s", test.name, err, test.wantErr)
+		}
+	}
+
+	if _, err := Unmarshal(FProto, []byte{0xff}); err == nil {
+		t.Errorf("TestErrors(bad proto): got err == nil, want err != nil")
+	}
+}
@@ -2,20 +2,22 @@ package tattler
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/chain"
 	preprocess "github.com/element-of-surprise/auditARG/tattler/internal/preproccessing"
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
 	"github.com/element-of-surprise/auditARG/tattler/internal/readers/safety"
 	"github.com/element-of-surprise/auditARG/tattler/internal/routing"
 )
 
-// Reader defines the interface that must be implemented by all readers.
-// We do not support a Reader that is not within this package.
+// Reader defines the interface that must be implemented by all readers. A Reader outside of this module
+// can output its own data types by registering them with RegisterSourceData().
 type Reader interface {
 	// SetOut sets the output channel that the reader must output on. Must return an error and be a no-op
 	// if Run() has been called.
@@ -24,6 +26,36 @@ type Reader interface {
 	Run(context.Context) error
 }
 
+// Namer can be implemented by a Reader to set the name the Runner puts in Entry.Reader. Readers that do
+// not implement it, or return an empty name, are named by their type.
+type Namer interface {
+	// Name returns the name of the Reader.
+	Name() string
+}
+
+// Entry is the data a Reader outputs.
+type Entry = data.Entry
+
+// EntryType is the type of an Entry.
+type EntryType = data.EntryType
+
+// SourceData is implemented by data held in an Entry.
+type SourceData = data.SourceData
+
+// SourceDataRegistration describes a SourceData type for RegisterSourceData().
+type SourceDataRegistration = data.Registration
+
+// RegisterSourceData registers a SourceData type so that NewEntry() accepts it, and returns the EntryType
+// that its Entries will have. Call this from an init() function in the package that defines the type.
+func RegisterSourceData(reg SourceDataRegistration) (EntryType, error) {
+	return data.Register(reg)
+}
+
+// NewEntry creates an Entry holding d. The type of d must be registered.
+func NewEntry(d SourceData) (Entry, error) {
+	return data.NewEntry(d)
+}
+
 // PreProcessor is function that processes data before it is sent to a processor. It must be thread-safe.
 // This is where you would alter data before it is sent for processing. Any change here affects
 // all processors.
@@ -36,8 +68,12 @@ type Runner struct {
 	secrets       *safety.Secrets
 	batcher       *batching.Batcher
 	router        *routing.Batches
-	readers       []Reader
+	readers       []addedReader
 	preProcessors []PreProcessor
+	clusterID     string
+
+	chainKey        ed25519.PrivateKey
+	chainCheckpoint time.Duration
 
 	logger *slog.Logger
 
@@ -45,6 +81,13 @@ type Runner struct {
 	started bool
 }
 
+// addedReader is a Reader added with AddReader(). done is closed to stop stamping its output if it fails
+// to Run().
+type addedReader struct {
+	reader Reader
+	done   chan struct{}
+}
+
 // Option is an option for New().
 type Option func(*Runner) error
 
@@ -59,6 +102,17 @@ func WithLogger(l *slog.Logger) Option {
 	}
 }
 
+// WithClusterID sets the cluster identifier the Runner puts in Entry.Cluster for every entry.
+func WithClusterID(id string) Option {
+	return func(r *Runner) error {
+		if id == "" {
+			return fmt.Errorf("cluster ID cannot be empty")
+		}
+		r.clusterID = id
+		return nil
+	}
+}
+
 // WithPreProcessor appends PreProcessors to the Runner.
 func WithPreProcessor(p ...PreProcessor) Option {
 	return func(r *Runner) error {
@@ -67,6 +121,24 @@ func WithPreProcessor(p ...PreProcessor) Option {
 	}
 }
 
+// WithHashChain adds a tamper-evident hash chain to the Batches sent to processors. Each Batches gets a
+// ChainLink entry with its sequence number and a hash over its entries and the previous link. The first link,
+// and then the first link after each checkpoint interval, is signed with key. Records of the Batches can be
+// checked with the public key, see the chain package.
+func WithHashChain(key ed25519.PrivateKey, checkpoint time.Duration) Option {
+	return func(r *Runner) error {
+		if len(key) != ed25519.PrivateKeySize {
+			return fmt.Errorf("key must be an ed25519 private key")
+		}
+		if checkpoint <= 0 {
+			return fmt.Errorf("checkpoint interval must be > 0")
+		}
+		r.chainKey = key
+		r.chainCheckpoint = checkpoint
+		return nil
+	}
+}
+
 // New constructs a new Runner.
 func New(ctx context.Context, in chan data.Entry, batchTimespan time.Duration, options ...Option) (*Runner, error) {
 	r := &Runner{
@@ -98,7 +170,17 @@ func New(ctx context.Context, in chan data.Entry, batchTimespan time.Duration, o
 		return nil, err
 	}
 
-	batcher, err := batching.New(ctx, batchingIn, routerIn, batchTimespan)
+	var batcherOut = routerIn
+
+	if r.chainKey != nil {
+		batcherOut = make(chan batching.Batches, 1)
+		_, err := chain.New(ctx, batcherOut, routerIn, r.chainKey, r.chainCheckpoint, chain.WithLogger(r.logger))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batcher, err := batching.New(ctx, batchingIn, batcherOut, batchTimespan)
 	if err != nil {
 		return nil, err
 	}
@@ -117,23 +199,79 @@ func New(ctx context.Context, in chan data.Entry, batchTimespan time.Duration, o
 
 // AddReader adds a reader's output channel as input to be processed. A Reader does not need to have
 // SetOut() or Run() called, as these are handled by AddReader() and Start(). You can add a reader
-// after Start() has been called. This allows staggering the start of readers.
+// after Start() has been called. This allows staggering the start of readers. Each reader gets its own
+// output channel, and entries on it have their Reader, Cluster and Seq set, and Observed if the
+// reader did not set it. The channel is read until the reader closes it.
 func (r *Runner) AddReader(ctx context.Context, reader Reader) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if err := reader.SetOut(ctx, r.input); err != nil {
-		return fmt.Errorf("Reader(%T).SetOut(): %w", r, err)
+	out := make(chan data.Entry, 1)
+	if err := reader.SetOut(ctx, out); err != nil {
+		return fmt.Errorf("Reader(%T).SetOut(): %w", reader, err)
 	}
+
+	// The output is read before Run() is called, as readers send the initial list of objects from Run().
+	ar := addedReader{reader: reader, done: make(chan struct{})}
+	go r.stamp(readerName(reader), out, ar.done)
+
 	if r.started {
-		if err := reader.Run(ctx); err != nil {
-			return fmt.Errorf("reader(%T): %w", reader, err)
+		if err := r.run(ctx, ar); err != nil {
+			return err
 		}
 	}
-	r.readers = append(r.readers, reader)
+	r.readers = append(r.readers, ar)
+	return nil
+}
+
+// run runs the reader in ar. If Run() fails, stamping its output is stopped, as it may never close it.
+func (r *Runner) run(ctx context.Context, ar addedReader) error {
+	if err := ar.reader.Run(ctx); err != nil {
+		close(ar.done)
+		return fmt.Errorf("reader(%T): %w", ar.reader, err)
+	}
 	return nil
 }
 
+// stamp sets the envelope of each entry on in and sends it to the Runner's input. Returns when in is closed
+// or done is closed.
+func (r *Runner) stamp(name string, in chan data.Entry, done chan struct{}) {
+	var seq uint64
+	for {
+		var e data.Entry
+		select {
+		case <-done:
+			return
+		case entry, ok := <-in:
+			if !ok {
+				return
+			}
+			e = entry
+		}
+
+		seq++
+		e.Reader = name
+		e.Cluster = r.clusterID
+		e.Seq = seq
+		if e.Observed.IsZero() {
+			e.Observed = time.Now()
+		}
+		select {
+		case <-done:
+			return
+		case r.input <- e:
+		}
+	}
+}
+
+// readerName returns the name of reader for Entry.Reader.
+func readerName(reader Reader) string {
+	if n, ok := reader.(Namer); ok && n.Name() != "" {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", reader)
+}
+
 // AddProcessor registers a processors input to receive Batches data. This cannot be called
 // after Start() has been called.
 func (r *Runner) AddProcessor(ctx context.Context, name string, in chan batching.Batches) error {
@@ -151,14 +289,15 @@ func (r *Runner) Start(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	for _, reader := range r.readers {
-		if err := reader.Run(ctx); err != nil {
-			return fmt.Errorf("reader(%T): %w", reader, err)
+	for _, ar := range r.readers {
+		if err := r.run(ctx, ar); err != nil {
+			return err
 		}
 	}
 
 	if err := r.router.Start(ctx); err != nil {
 		return err
 	}
+	r.started = true
 	return nil
 }
@@ -0,0 +1,288 @@
+package tattler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/auditARG/tattler/internal/batching"
+	"github.com/element-of-surprise/auditARG/tattler/internal/chain"
+	"github.com/element-of-surprise/auditARG/tattler/internal/readers/data"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeReader outputs entries when Run() is called and then closes its output.
+type fakeReader struct {
+	name    string
+	entries []data.Entry
+	out     chan data.Entry
+}
+
+func (f *fakeReader) Name() string {
+	return f.name
+}
+
+func (f *fakeReader) SetOut(ctx context.Context, out chan data.Entry) error {
+	f.out = out
+	return nil
+}
+
+func (f *fakeReader) Run(ctx context.Context) error {
+	go func() {
+		defer close(f.out)
+		for _, e := range f.entries {
+			f.out <- e
+		}
+	}()
+	return nil
+}
+
+// syncReader sends its entries from Run(), as readers that wait for their initial list do, and then
+// returns err.
+type syncReader struct {
+	name    string
+	entries []data.Entry
+	err     error
+	out     chan data.Entry
+}
+
+func (s *syncReader) Name() string {
+	return s.name
+}
+
+func (s *syncReader) SetOut(ctx context.Context, out chan data.Entry) error {
+	s.out = out
+	return nil
+}
+
+func (s *syncReader) Run(ctx context.Context) error {
+	for _, e := range s.entries {
+		s.out <- e
+	}
+	return s.err
+}
+
+func podEntry(uid string, observed time.Time) data.Entry {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: uid, UID: types.UID(uid)}}
+	e := data.MustNewEntry(data.MustNewInformer(data.MustNewChange(pod, nil, data.CTAdd)))
+	e.Observed = observed
+	return e
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	observed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r, err := New(
+		ctx,
+		make(chan data.Entry, 1),
+		10*time.Millisecond,
+		WithClusterID("cluster-a"),
+		WithPreProcessor(func(context.Context, data.Entry) error { return nil }),
+	)
+	if err != nil {
+		t.Fatalf("TestEnvelope: got err == %v, want err == nil", err)
+	}
+
+	named := &fakeReader{name: "named", entries: []data.Entry{podEntry("a", observed), podEntry("b", observed), podEntry("a", observed)}}
+	unnamed := &struct{ *fakeReader }{&fakeReader{entries: []data.Entry{podEntry("c", time.Time{})}}}
+	for _, reader := range []Reader{named, unnamed} {
+		if err := r.AddReader(ctx, reader); err != nil {
+			t.Fatalf("TestEnvelope: got err == %v, want err == nil", err)
+		}
+	}
+
+	out := make(chan batching.Batches, 10)
+	if err := r.AddProcessor(ctx, "test", out); err != nil {
+		t.Fatalf("TestEnvelope: got err == %v, want err == nil", err)
+	}
+	if err := r.Start(ctx); err != nil {
+		t.Fatalf("TestEnvelope: got err == %v, want err == nil", err)
+	}
+
+	got := map[types.UID]data.Entry{}
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case batches := <-out:
+			for e := range batches.Iter(ctx) {
+				got[e.UID()] = e
+			}
+		case <-timeout:
+			t.Fatalf("TestEnvelope: timed out with %d entries, want 3", len(got))
+		}
+	}
+
+	// "a" was sent twice, batching keeps the later entry, which is the third from the reader.
+	tests := []struct {
+		uid          types.UID
+		wantReader   string
+		wantSeq      uint64
+		wantObserved bool
+	}{
+		{uid: "a", wantReader: "named", wantSeq: 3, wantObserved: true},
+		{uid: "b", wantReader: "named", wantSeq: 2, wantObserved: true},
+		{uid: "c", wantReader: "*struct { *tattler.fakeReader }", wantSeq: 1},
+	}
+	for _, test := range tests {
+		e := got[test.uid]
+		if e.Reader != test.wantReader {
+			t.Errorf("TestEnvelope(%s): got Reader == %q, want %q", test.uid, e.Reader, test.wantReader)
+		}
+		if e.Cluster != "cluster-a" {
+			t.Errorf("TestEnvelope(%s): got Cluster == %q, want cluster-a", test.uid, e.Cluster)
+		}
+		if e.Seq != test.wantSeq {
+			t.Errorf("TestEnvelope(%s): got Seq == %d, want %d", test.uid, e.Seq, test.wantSeq)
+		}
+		switch {
+		case test.wantObserved && !e.Observed.Equal(observed):
+			t.Errorf("TestEnvelope(%s): got Observed == %v, want %v", test.uid, e.Observed, observed)
+		case e.Observed.IsZero():
+			t.Errorf("TestEnvelope(%s): got zero Observed, want it set by the Runner", test.uid)
+		}
+	}
+}
+
+func TestHashChain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(ctx, make(chan data.Entry, 1), 10*time.Millisecond, WithHashChain(priv, time.Hour))
+	if err != nil {
+		t.Fatalf("TestHashChain: got err == %v, want err == nil", err)
+	}
+	reader := &fakeReader{name: "reader", entries: []data.Entry{podEntry("a", time.Now()), podEntry("b", time.Now())}}
+	if err := r.AddReader(ctx, reader); err != nil {
+		t.Fatalf("TestHashChain: got err == %v, want err == nil", err)
+	}
+	out := make(chan batching.Batches, 10)
+	if err := r.AddProcessor(ctx, "test", out); err != nil {
+		t.Fatalf("TestHashChain: got err == %v, want err == nil", err)
+	}
+	if err := r.Start(ctx); err != nil {
+		t.Fatalf("TestHashChain: got err == %v, want err == nil", err)
+	}
+
+	v, err := chain.NewVerifier(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for v.Report().Entries < 2 {
+		select {
+		case batches := <-out:
+			if len(batches[data.ETChainLink]) != 1 {
+				t.Fatalf("TestHashChain: got %d links in a Batches, want 1", len(batches[data.ETChainLink]))
+			}
+			v.Batches(batches)
+		case <-timeout:
+			t.Fatalf("TestHashChain: timed out with %d entries, want 2", v.Report().Entries)
+		}
+	}
+
+	report := v.Report()
+	if len(report.Problems) != 0 || report.Checkpoints != 1 {
+		t.Errorf("TestHashChain: got problems %v and %d checkpoints, want none and 1", report.Problems, report.Checkpoints)
+	}
+}
+
+func TestRunSendsInitialList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	entries := func(prefix string) []data.Entry {
+		var out []data.Entry
+		for i := 0; i < 5; i++ {
+			out = append(out, podEntry(fmt.Sprintf("%s-%d", prefix, i), time.Now()))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		afterStart bool
+		err        error
+		wantErr    bool
+	}{
+		{name: "Before Start"},
+		{name: "After Start", afterStart: true},
+		{name: "Run fails before Start", err: errors.New("failed"), wantErr: true},
+		{name: "Run fails after Start", afterStart: true, err: errors.New("failed"), wantErr: true},
+	}
+
+	for _, test := range tests {
+		r, err := New(ctx, make(chan data.Entry, 1), 10*time.Millisecond)
+		if err != nil {
+			t.Fatalf("TestRunSendsInitialList(%s): got err == %v, want err == nil", test.name, err)
+		}
+		out := make(chan batching.Batches, 10)
+		if err := r.AddProcessor(ctx, "test", out); err != nil {
+			t.Fatalf("TestRunSendsInitialList(%s): got err == %v, want err == nil", test.name, err)
+		}
+		reader := &syncReader{name: "sync", entries: entries(test.name), err: test.err}
+
+		// Run() only returns once its entries are read, so this hangs if the output is not read until then.
+		errCh := make(chan error, 1)
+		go func() {
+			if test.afterStart {
+				if err := r.Start(ctx); err != nil {
+					errCh <- err
+					return
+				}
+				errCh <- r.AddReader(ctx, reader)
+				return
+			}
+			if err := r.AddReader(ctx, reader); err != nil {
+				errCh <- err
+				return
+			}
+			errCh <- r.Start(ctx)
+		}()
+
+		select {
+		case err = <-errCh:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("TestRunSendsInitialList(%s): timed out waiting for Run()", test.name)
+		}
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRunSendsInitialList(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRunSendsInitialList(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		got := 0
+		timeout := time.After(5 * time.Second)
+		for got < len(reader.entries) {
+			select {
+			case batches := <-out:
+				for e := range batches.Iter(ctx) {
+					if e.Reader != "sync" {
+						t.Errorf("TestRunSendsInitialList(%s): got entry from reader %q, want sync", test.name, e.Reader)
+					}
+					got++
+				}
+			case <-timeout:
+				t.Fatalf("TestRunSendsInitialList(%s): timed out with %d entries, want %d", test.name, got, len(reader.entries))
+			}
+		}
+	}
+}